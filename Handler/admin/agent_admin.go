@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-go-project/agent"
+	"my-go-project/paging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SetAgentRequest struct {
+	CommissionRate float64 `json:"commission_rate" binding:"min=0,max=100"` // % ของยอดขาย
+	Active         *bool   `json:"active"`
+}
+
+type AgentAllocationRequest struct {
+	LottoIDs []uint `json:"lotto_ids"`
+}
+
+// agentParam อ่าน :agent_id (ตอบ error ให้แล้วถ้าไม่ถูกต้อง)
+func agentParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("agent_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid agent_id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// agentError แปลง error ของ package agent เป็น HTTP status
+func agentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, agent.ErrNotAgent):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, agent.ErrInactive), errors.Is(err, agent.ErrNothingToPay):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, agent.ErrInvalidRate):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+	}
+}
+
+// GET /agents?limit=50&cursor=
+// ตัวแทนทั้งหมด พร้อมจำนวนสลากที่ถืออยู่และค่าคอมที่ยังไม่จ่าย
+func ListAgents(c *gin.Context, db *gorm.DB) {
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	type row struct {
+		AgentID        uint    `json:"agent_id"`
+		Username       string  `json:"username"`
+		Email          string  `json:"email"`
+		CommissionRate float64 `json:"commission_rate"`
+		Active         bool    `json:"active"`
+		Allocated      int     `json:"allocated"`
+		Unpaid         float64 `json:"unpaid_commission"`
+	}
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM agents").Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := `
+		SELECT a.agent_id, u.username, u.email, a.commission_rate, a.active,
+		       (SELECT COUNT(*) FROM agent_allocations AS al WHERE al.agent_id = a.agent_id) AS allocated,
+		       (SELECT COALESCE(SUM(amount), 0) FROM agent_commissions AS ac WHERE ac.agent_id = a.agent_id AND ac.status = 'accrued') AS unpaid
+		FROM agents AS a
+		JOIN users AS u ON u.user_id = a.agent_id`
+	var args []interface{}
+	if cond, condArgs := page.After("a.agent_id", "a.agent_id", false); cond != "" {
+		sql += " WHERE " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY a.agent_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var rows []row
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].AgentID}.Encode()
+	}
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+// PUT /agents/:agent_id
+// ตั้งผู้ใช้เป็นตัวแทน (role = agent) หรือแก้อัตราค่าคอม/ปิดการใช้งาน
+func SetAgent(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentParam(c)
+	if !ok {
+		return
+	}
+	var req SetAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	a, err := agent.Upsert(db, agentID, req.CommissionRate, req.Active == nil || *req.Active)
+	if err != nil {
+		agentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   a,
+	})
+}
+
+// POST /agents/:agent_id/allocations
+// แบ่งสลากให้ตัวแทนขาย (คนอื่นซื้อใบเหล่านี้ไม่ได้จนกว่าจะคืน)
+func AllocateToAgent(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentParam(c)
+	if !ok {
+		return
+	}
+	var req AgentAllocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.LottoIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "lotto_ids is required"})
+		return
+	}
+
+	allocated, unavailable, err := agent.Allocate(db, agentID, req.LottoIDs)
+	if err != nil {
+		agentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"allocated":     allocated,
+		"not_available": unavailable, // ขายแล้ว มีคนจอง หรือแบ่งให้ตัวแทนอื่นแล้ว
+	})
+}
+
+// DELETE /agents/:agent_id/allocations
+// คืนสลากจากตัวแทนกลับไปขายทั่วไป (ไม่ส่ง lotto_ids = คืนทั้งหมด)
+func ReleaseAgentAllocations(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentParam(c)
+	if !ok {
+		return
+	}
+	var req AgentAllocationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request body"})
+			return
+		}
+	}
+
+	released, err := agent.Release(db, agentID, req.LottoIDs)
+	if err != nil {
+		agentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"released": released,
+	})
+}
+
+// POST /agents/:agent_id/statements/:draw_id/settle
+// ปิดยอดงวด: จ่ายค่าคอมที่ค้างของงวดนั้นเข้า wallet ตัวแทน (draw_id 0 = สลากที่ไม่ระบุงวด)
+func SettleAgentCommission(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentParam(c)
+	if !ok {
+		return
+	}
+	drawID, err := strconv.ParseUint(c.Param("draw_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+		return
+	}
+
+	amount, err := agent.Settle(db, agentID, uint(drawID))
+	if err != nil {
+		agentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "จ่ายค่าคอมมิชชันเข้ากระเป๋าตัวแทนแล้ว",
+		"amount":  amount,
+	})
+}
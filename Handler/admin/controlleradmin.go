@@ -55,10 +55,33 @@ func ClearDataHandler(c *gin.Context, db *gorm.DB) {
 	// 2. ลบข้อมูลทั้งหมดจากตารางอื่นๆ
 	//    (เรียงลำดับโดยคำนึงถึง Foreign Key Constraints ถ้ามี เช่น ลบ detail ก่อน master)
 	tablesToClear := []string{
+		"lotto_reservations",
+		"agent_allocations",
+		"agent_commissions",
+		"referrals",
+		"watchlist",
+		"ticket_listings",
+		"ticket_transfers",
+		"wallet_transactions",
+		"point_transactions",
+		"notifications",
+		"device_tokens",
+		"subscription_runs",
+		"subscriptions",
+		"user_limits",
 		"rewards",
 		"purchases_detail",
+		"syndicate_shares",
+		"promotion_uses",
 		"purchases",
+		"promotions",
+		"agents",
+		"syndicate_members",
+		"syndicates",
 		"lotto",
+		"draw_results",
+		"draws",
+		"price_rules",
 	}
 
 	for _, table := range tablesToClear {
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/live"
+	"my-go-project/market"
+	"my-go-project/models"
+	"my-go-project/paging"
+	"my-go-project/subscription"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateDrawRequest struct {
+	DrawDate string `json:"draw_date" binding:"required"` // รูปแบบ 2006-01-02
+}
+
+// POST /draws
+// สร้างงวดใหม่ (สถานะเริ่มต้น open)
+func CreateDraw(c *gin.Context, db *gorm.DB) {
+	var req CreateDrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request body"})
+		return
+	}
+
+	drawDate, err := time.ParseInLocation("2006-01-02", req.DrawDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "draw_date ต้องอยู่ในรูปแบบ YYYY-MM-DD"})
+		return
+	}
+
+	draw := models.Draw{DrawDate: drawDate, Status: "open"}
+	if err := db.Create(&draw).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to create draw: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   draw,
+	})
+}
+
+// GET /draws?status=open&limit=50&cursor=...
+// ดึงรายการงวด (ใหม่สุดก่อน แบ่งหน้าแบบ cursor)
+func ListDraws(c *gin.Context, db *gorm.DB) {
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var where []string
+	var args []interface{}
+	if status := c.Query("status"); status != "" {
+		where = append(where, "status = ?")
+		args = append(args, status)
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM draws"+whereSQL(where), args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if cond, condArgs := page.After("draw_date", "draw_id", true); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+	args = append(args, page.Limit+1)
+
+	var draws []models.Draw
+	if err := db.Raw("SELECT * FROM draws"+whereSQL(where)+" ORDER BY draw_date DESC, draw_id DESC LIMIT ?", args...).Scan(&draws).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(draws))
+	draws = draws[:n]
+	next := ""
+	if more {
+		last := draws[n-1]
+		next = paging.Cursor{Value: last.DrawDate.Format("2006-01-02"), ID: last.DrawID}.Encode()
+	}
+
+	paging.Respond(c, draws, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+// POST /draws/:draw_id/subscriptions/run
+// สั่งซื้ออัตโนมัติให้ผู้ที่ตั้ง subscription ไว้ในงวดนี้ (ข้ามรายการที่ซื้อสำเร็จแล้ว)
+func RunDrawSubscriptions(c *gin.Context, db *gorm.DB) {
+	drawID, err := strconv.ParseUint(c.Param("draw_id"), 10, 64)
+	if err != nil || drawID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+		return
+	}
+
+	report, err := subscription.RunForDraw(db, uint(drawID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "draw not found"})
+		return
+	}
+	if errors.Is(err, subscription.ErrDrawNotOpen) {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	live.InventoryChanged()
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// POST /draws/:draw_id/close
+// ปิดการขายของงวด และยกเลิกประกาศขายต่อที่ยังค้างอยู่
+func CloseDrawSales(c *gin.Context, db *gorm.DB) {
+	drawID, err := strconv.ParseUint(c.Param("draw_id"), 10, 64)
+	if err != nil || drawID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+		return
+	}
+
+	result := db.Exec("UPDATE draws SET status = 'closed' WHERE draw_id = ? AND status = 'open'", drawID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "draw not found or not open"})
+		return
+	}
+
+	cancelled, err := market.CancelAtCutoff(db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	live.InventoryChanged() // งวดที่ปิดแล้วหายจากยอดคงเหลือ
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":             "success",
+		"message":            "ปิดการขายงวดนี้แล้ว",
+		"listings_cancelled": cancelled,
+	})
+}
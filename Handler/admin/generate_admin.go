@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-go-project/generator"
+	"my-go-project/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GenerateLottoRequest struct {
+	DrawID    *uint    `json:"draw_id"`
+	Count     int      `json:"count"      binding:"required,min=1"`
+	Strategy  string   `json:"strategy"`   // random (ค่าเริ่มต้น) / sequential
+	BlockSize int      `json:"block_size"` // ขนาดช่วงเลขติดกัน (sequential)
+	Exclude   []string `json:"exclude"`    // เช่น ["4", "13", "xxxxx0"]
+	Balance   bool     `json:"balance"`    // ให้แต่ละหลักมีเลข 0-9 เท่า ๆ กัน
+	Price     float64  `json:"price"`
+	Sets      int      `json:"sets"       binding:"omitempty,min=1,max=100"`
+	CreatedBy *uint    `json:"created_by"`
+	ChunkSize int      `json:"chunk_size" binding:"omitempty,min=100,max=10000"` // จำนวนแถวต่อการบันทึก 1 ครั้ง
+}
+
+// POST /lotto/generate/bulk
+// สร้างเลขสลากไม่ซ้ำกันของงวดฝั่ง server แล้วบันทึกทีละก้อน (ทำเบื้องหลัง)
+// ดูความคืบหน้าที่ GET /lotto/generate/jobs/:job_id
+func GenerateLottoBulk(c *gin.Context, db *gorm.DB) {
+	var req GenerateLottoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	if req.DrawID != nil {
+		var draw models.Draw
+		if err := db.Where("draw_id = ?", *req.DrawID).First(&draw).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "draw not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		if draw.Status != "open" {
+			c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "draw is not open for sale"})
+			return
+		}
+	}
+
+	job, err := generator.Start(db, generator.Request{
+		DrawID: req.DrawID,
+		Options: generator.Options{
+			Count:     req.Count,
+			Strategy:  req.Strategy,
+			BlockSize: req.BlockSize,
+			Exclude:   req.Exclude,
+			Balance:   req.Balance,
+		},
+		Price:     req.Price,
+		Sets:      req.Sets,
+		CreatedBy: req.CreatedBy,
+		ChunkSize: req.ChunkSize,
+	}, func(lottoIDs []uint) {
+		afterLottoInsert(db, req.DrawID, lottoIDs)
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data":   job,
+	})
+}
+
+// GET /lotto/generate/jobs/:job_id
+// ความคืบหน้าของงานสร้างสลาก
+func GenerateLottoStatus(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil || jobID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid job_id"})
+		return
+	}
+
+	job, ok := generator.Get(jobID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   job,
+	})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-go-project/importer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ขนาดไฟล์สูงสุดที่รับ
+const maxImportBytes = 32 << 20
+
+// POST /lotto/import?dry_run=true  (multipart: file, draw_id, price, created_by)
+// นำเข้าสลากจากไฟล์ CSV / XLSX ของผู้จัดจำหน่าย คอลัมน์ number, set, price, draw
+// (draw เป็น draw_id หรือวันที่ YYYY-MM-DD เว้นว่าง = ใช้ draw_id จากฟอร์ม)
+// มีแถวผิดแม้แถวเดียว → ไม่บันทึกเลย คืนรายการแถวที่ผิด, dry_run → ตรวจอย่างเดียวไม่บันทึก
+func ImportLotto(c *gin.Context, db *gorm.DB) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "file is required (max 32MB)"})
+		return
+	}
+	format, err := importer.FormatOf(fh.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", c.PostForm("dry_run")))
+
+	var def importer.Defaults
+	if s := c.PostForm("draw_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+			return
+		}
+		drawID := uint(id)
+		def.DrawID = &drawID
+	}
+	if s := c.PostForm("price"); s != "" {
+		price, err := strconv.ParseFloat(s, 64)
+		if err != nil || price <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid price"})
+			return
+		}
+		def.Price = price
+	}
+	var createdBy *uint
+	if s := c.PostForm("created_by"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid created_by"})
+			return
+		}
+		uid := uint(id)
+		createdBy = &uid
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	// อ่านไฟล์ทีละแถว ตรวจรูปแบบ
+	res, err := importer.Parse(f, format, def)
+	if err != nil {
+		if errors.Is(err, importer.ErrNoHeader) || errors.Is(err, importer.ErrEmptyFile) || errors.Is(err, importer.ErrTooManyRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "cannot read file: " + err.Error()})
+		return
+	}
+
+	// ตรวจกับฐานข้อมูลและบันทึกใน transaction เดียว (ทั้งไฟล์สำเร็จหรือไม่บันทึกเลย)
+	tx := db.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	draws, err := importer.Validate(tx, res)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	report := gin.H{
+		"dry_run":     dryRun,
+		"valid_rows":  len(res.Rows),
+		"error_count": res.ErrorCount,
+		"errors":      res.Errors, // แสดงไม่เกิน importer.MaxErrors แถว
+		"draws":       draws,
+	}
+	if res.ErrorCount > 0 {
+		report["status"] = "error"
+		report["message"] = "file has invalid rows, nothing was imported"
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	if dryRun {
+		report["status"] = "success"
+		report["message"] = "file is valid, nothing was imported (dry run)"
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	idsByDraw, err := importer.Insert(tx, res.Rows, createdBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to insert lotto: " + err.Error()})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to commit transaction"})
+		return
+	}
+
+	inserted := 0
+	for drawID, ids := range idsByDraw {
+		inserted += len(ids)
+		var draw *uint
+		if drawID != 0 {
+			id := drawID
+			draw = &id
+		}
+		go afterLottoInsert(db, draw, ids)
+	}
+
+	report["status"] = "success"
+	report["message"] = "import completed"
+	report["inserted"] = inserted
+	c.JSON(http.StatusCreated, report)
+}
@@ -2,40 +2,159 @@ package handlers
 
 import (
 	"fmt"
-	"math/rand"
+	"log"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"my-go-project/generator"
+	"my-go-project/live"
 	"my-go-project/models"
+	"my-go-project/paging"
+	"my-go-project/subscription"
+	"my-go-project/watch"
 )
 
+// GET /lotto?status=sell&min_price=80&max_price=120&draw_id=3&created_by=1&sort=price&order=desc&limit=100&cursor=...
+// แบ่งหน้าแบบ cursor: ส่ง next_cursor ของหน้าก่อนกลับมาเพื่อดึงหน้าถัดไป
 func GetAllLotto(c *gin.Context, db *gorm.DB) {
+	page, err := paging.FromQuery(c, 100, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 
-	const sql = "SELECT * FROM lotto ORDER BY lotto_id ASC"
+	// คอลัมน์ที่เรียงได้ (ใช้ lotto_id ตัดสินเมื่อค่าเท่ากัน)
+	sortCols := map[string]string{"lotto_id": "lotto_id", "lotto_number": "lotto_number", "price": "price"}
+	sortCol, ok := sortCols[c.DefaultQuery("sort", "lotto_id")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "sort must be lotto_id, lotto_number or price"})
+		return
+	}
+	desc := c.Query("order") == "desc"
+
+	// --- ตัวกรอง ---
+	var where []string
+	var args []interface{}
+	if v := c.Query("min_price"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid min_price"})
+			return
+		}
+		where = append(where, "price >= ?")
+		args = append(args, f)
+	}
+	if v := c.Query("max_price"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid max_price"})
+			return
+		}
+		where = append(where, "price <= ?")
+		args = append(args, f)
+	}
+	if v := c.Query("draw_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+			return
+		}
+		where = append(where, "draw_id = ?")
+		args = append(args, id)
+	}
+	if v := c.Query("created_by"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid created_by"})
+			return
+		}
+		where = append(where, "created_by = ?")
+		args = append(args, id)
+	}
+
+	// จำนวนแยกตามสถานะ (ไม่รวมตัวกรอง status เพื่อให้เห็นภาพรวม)
+	type StatusCount struct {
+		Status string
+		Total  int64
+	}
+	var statusCounts []StatusCount
+	countSQL := "SELECT status, COUNT(*) AS total FROM lotto" + whereSQL(where) + " GROUP BY status"
+	if err := db.Raw(countSQL, args...).Scan(&statusCounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "sell" && status != "sold" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "status must be sell or sold"})
+		return
+	}
+	counts := gin.H{"sell": int64(0), "sold": int64(0)}
+	var total int64
+	for _, sc := range statusCounts {
+		counts[sc.Status] = sc.Total
+		if status == "" || status == sc.Status {
+			total += sc.Total
+		}
+	}
+	if status != "" {
+		where = append(where, "status = ?")
+		args = append(args, status)
+	}
+
+	// --- หน้าปัจจุบัน ---
+	if cond, condArgs := page.After(sortCol, "lotto_id", desc); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	sql := "SELECT * FROM lotto" + whereSQL(where) + " ORDER BY " + sortCol + " " + dir
+	if sortCol != "lotto_id" {
+		sql += ", lotto_id " + dir
+	}
+	sql += " LIMIT ?"
+	args = append(args, page.Limit+1)
 
-	// 2. Execute คำสั่ง SQL และ Scan ผลลัพธ์ลงใน slice `items`
 	var items []models.Lotto
-	if err := db.Raw(sql).Scan(&items).Error; err != nil {
-		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	if err := db.Raw(sql, args...).Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
+	n, more := page.Trim(len(items))
+	items = items[:n]
+	next := ""
+	if more {
+		last := items[n-1]
+		cur := paging.Cursor{ID: last.LottoID}
+		switch sortCol {
+		case "lotto_number":
+			cur.Value = last.LottoNumber
+		case "price":
+			cur.Value = strconv.FormatFloat(last.Price, 'f', -1, 64)
+		}
+		next = cur.Encode()
+	}
+
 	// --- ส่วนของการตอบกลับ ---
-	c.JSON(200, gin.H{
-		"status": "success",
-		"count":  len(items), // ใช้ count แทน page/limit เพื่อแยกให้ออกว่าเป็นตัวใหม่
-		"data":   items,
+	paging.Respond(c, items, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, gin.H{
+		"counts": counts,
 	})
 }
 
-// สุ่มเลข 6 หลักแบบสตริง เช่น "042317"
-func random6() string {
-	return fmt.Sprintf("%06d", rand.Intn(1_000_000))
+func whereSQL(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(where, " AND ")
 }
 
 type PreviewUpdateItem struct {
@@ -50,9 +169,8 @@ type PreviewUpdateItem struct {
 // import "strings"
 
 // สุ่มเลขใหม่ 100 ตัว ไม่ยุ่งกับ DB เดิม
+// ใช้ตัวสร้างเลขเดียวกับ /lotto/generate/bulk (?strategy=&block_size=&exclude=4,13&balance=true)
 func PreviewNewLotto(c *gin.Context) {
-	rand.Seed(time.Now().UnixNano())
-
 	// จำนวนที่ต้องการสุ่ม (default = 100)
 	want := 100
 	if v := c.Query("count"); v != "" {
@@ -64,16 +182,23 @@ func PreviewNewLotto(c *gin.Context) {
 		want = 10000
 	}
 
-	seen := make(map[string]struct{}, want)
-	out := make([]map[string]interface{}, 0, want)
-
-	for len(out) < want {
-		n := random6()
-		if _, dup := seen[n]; dup {
-			continue
-		}
-		seen[n] = struct{}{}
+	opts := generator.Options{
+		Count:    want,
+		Strategy: c.Query("strategy"),
+		Balance:  c.Query("balance") == "true",
+	}
+	opts.BlockSize, _ = strconv.Atoi(c.Query("block_size"))
+	if v := c.Query("exclude"); v != "" {
+		opts.Exclude = strings.Split(v, ",")
+	}
+	numbers, err := generator.Generate(opts, func(int) bool { return false })
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 
+	out := make([]map[string]interface{}, 0, len(numbers))
+	for _, n := range numbers {
 		out = append(out, map[string]interface{}{
 			"lotto_number": n,
 			"status":       "sell", // default
@@ -89,16 +214,17 @@ func PreviewNewLotto(c *gin.Context) {
 	})
 }
 
-// Reset + Insert ใหม่
 type NewLottoItem struct {
 	LottoNumber string  `json:"lotto_number"`
 	Status      string  `json:"status"`
 	Price       float64 `json:"price"`
 	CreatedBy   *uint   `json:"created_by"`
+	Sets        int     `json:"sets"` // จำนวนชุดของเลขนี้ (ค่าเริ่มต้น 1)
 }
 
 type ResetInsertReq struct {
-	Items []NewLottoItem `json:"items"`
+	DrawID *uint          `json:"draw_id"` // งวดของสลากทั้งชุด (ไม่ส่งก็ได้)
+	Items  []NewLottoItem `json:"items"`
 }
 
 // handlersadmin/lotto_handler.go (หรือไฟล์ที่คุณเก็บ handler)
@@ -132,6 +258,8 @@ func ClearLottoDataHandler(c *gin.Context, db *gorm.DB) {
         return
     }
 
+    live.InventoryChanged()
+
     c.JSON(http.StatusOK, gin.H{
         "status":  "success",
         "message": "Lotto data has been cleared.",
@@ -140,6 +268,28 @@ func ClearLottoDataHandler(c *gin.Context, db *gorm.DB) {
 
 // handlersadmin/lotto_handler.go (หรือไฟล์ที่คุณเก็บ handler)
 
+var lottoNumberRe = regexp.MustCompile(`^[0-9]{6}$`)
+
+// ขีดจำกัดของ InsertLottoHandler: จำนวนเลขต่อคำขอ และจำนวนแถวต่อ INSERT (6 placeholder ต่อแถว)
+const (
+	maxInsertNumbers = 10000
+	insertBatchRows  = 2000
+)
+
+// afterLottoInsert มีสลากใหม่แล้ว → ซื้ออัตโนมัติให้ผู้ที่ตั้ง subscription ไว้ก่อน
+// แล้วแจ้งผู้ติดตามเลขถึงใบที่ยังเหลือ (เรียกแบบ go afterLottoInsert(...) เพื่อทำเบื้องหลัง)
+func afterLottoInsert(db *gorm.DB, drawID *uint, lottoIDs []uint) {
+	if drawID != nil {
+		if _, err := subscription.RunForDraw(db, *drawID); err != nil {
+			log.Printf("subscription: draw %d failed: %v", *drawID, err)
+		}
+	}
+	if err := watch.Notify(db, lottoIDs, 0); err != nil {
+		log.Printf("watch: notify failed: %v", err)
+	}
+	live.InventoryChanged()
+}
+
 // InsertLottoHandler inserts a new batch of lotto items.
 func InsertLottoHandler(c *gin.Context, db *gorm.DB) {
     var req ResetInsertReq
@@ -148,21 +298,61 @@ func InsertLottoHandler(c *gin.Context, db *gorm.DB) {
         return
     }
 
+    // ตรวจรูปแบบ: เลข 6 หลัก, สถานะ sell/sold, จำนวนชุด 1-100 (ไม่ส่ง/0 = 1 ชุด)
+    var invalid []string
+    for _, item := range req.Items {
+        if !lottoNumberRe.MatchString(item.LottoNumber) || (item.Status != "" && item.Status != "sell" && item.Status != "sold") || item.Sets < 0 || item.Sets > 100 {
+            invalid = append(invalid, item.LottoNumber)
+        }
+    }
+    if len(invalid) > 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "lotto_number must be 6 digits, status sell/sold and sets 1-100 (omit for 1)", "invalid": invalid})
+        return
+    }
+    if len(req.Items) > maxInsertNumbers {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": fmt.Sprintf("at most %d items per request, use /lotto/import or /lotto/generate/bulk", maxInsertNumbers)})
+        return
+    }
+
+    // เลขเดียวกันห้ามซ้ำในคำขอ (ถ้าต้องการหลายใบให้ใช้ sets)
+    seen := make(map[string]struct{}, len(req.Items))
+    numbers := make([]string, 0, len(req.Items))
+    var duplicates []string
+    for _, item := range req.Items {
+        if _, ok := seen[item.LottoNumber]; ok {
+            duplicates = append(duplicates, item.LottoNumber)
+            continue
+        }
+        seen[item.LottoNumber] = struct{}{}
+        numbers = append(numbers, item.LottoNumber)
+    }
+    if len(duplicates) > 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "duplicate lotto_number in items, use sets instead", "duplicates": duplicates})
+        return
+    }
+
     tx := db.Begin()
     if tx.Error != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to begin transaction"})
         return
     }
 
-    var args []interface{}
-    var sqlBuilder strings.Builder
-    sqlBuilder.WriteString("INSERT INTO lotto (lotto_number, status, price, created_by) VALUES ")
+    // เลขเดียวกันห้ามซ้ำกับที่มีอยู่แล้วในงวดเดียวกัน
+    var existing []string
+    if err := tx.Raw("SELECT DISTINCT lotto_number FROM lotto WHERE lotto_number IN ? AND draw_id <=> ? FOR UPDATE", numbers, req.DrawID).Scan(&existing).Error; err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "duplicate check failed: " + err.Error()})
+        return
+    }
+    if len(existing) > 0 {
+        tx.Rollback()
+        c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "lotto_number already exists in this draw", "duplicates": existing})
+        return
+    }
 
-    for i, item := range req.Items {
-        if i > 0 {
-            sqlBuilder.WriteString(", ")
-        }
-        sqlBuilder.WriteString("(?, ?, ?, ?)")
+    // 1 แถว = 1 ใบ (ชุดที่ 1..sets) ส่งทีละก้อนไม่ให้ placeholder เกินขีดจำกัดของ MySQL
+    var rows [][]interface{}
+    for _, item := range req.Items {
         status := item.Status
         if status == "" {
             status = "sell"
@@ -171,10 +361,38 @@ func InsertLottoHandler(c *gin.Context, db *gorm.DB) {
         if price <= 0 {
             price = 80
         }
-        args = append(args, item.LottoNumber, status, price, item.CreatedBy)
+        sets := item.Sets
+        if sets == 0 {
+            sets = 1
+        }
+        for setNo := 1; setNo <= sets; setNo++ {
+            rows = append(rows, []interface{}{item.LottoNumber, status, price, item.CreatedBy, req.DrawID, setNo})
+        }
     }
+    inserted := len(rows)
 
-    if err := tx.Exec(sqlBuilder.String(), args...).Error; err != nil {
+    for start := 0; start < len(rows); start += insertBatchRows {
+        end := start + insertBatchRows
+        if end > len(rows) {
+            end = len(rows)
+        }
+        var args []interface{}
+        placeholders := make([]string, 0, end-start)
+        for _, r := range rows[start:end] {
+            placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?)")
+            args = append(args, r...)
+        }
+        sql := "INSERT INTO lotto (lotto_number, status, price, created_by, draw_id, set_no) VALUES " + strings.Join(placeholders, ", ")
+        if err := tx.Exec(sql, args...).Error; err != nil {
+            tx.Rollback()
+            c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "insert failed: " + err.Error()})
+            return
+        }
+    }
+
+    // id ของสลากที่เพิ่งเพิ่ม (เลขในงวดนี้ไม่ซ้ำของเดิม จึงเป็นชุดใหม่ทั้งหมด)
+    var insertedIDs []uint
+    if err := tx.Raw("SELECT lotto_id FROM lotto WHERE lotto_number IN ? AND draw_id <=> ?", numbers, req.DrawID).Scan(&insertedIDs).Error; err != nil {
         tx.Rollback()
         c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "insert failed: " + err.Error()})
         return
@@ -185,9 +403,13 @@ func InsertLottoHandler(c *gin.Context, db *gorm.DB) {
         return
     }
 
+    // มีสลากใหม่แล้ว → ซื้ออัตโนมัติ / แจ้งผู้ติดตามเลข (ทำเบื้องหลัง)
+    go afterLottoInsert(db, req.DrawID, insertedIDs)
+
     c.JSON(http.StatusOK, gin.H{
         "status":   "success",
-        "inserted": len(req.Items),
+        "inserted": inserted,
+        "numbers":  len(req.Items),
     })
 }
 
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-go-project/models"
+	"my-go-project/pricing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PriceRuleRequest struct {
+	Name         string  `json:"name"          binding:"required,max=100"`
+	Pattern      *string `json:"pattern"`                                 // "double", "triple" หรือ เช่น "xxxx99"
+	MaxRemaining *int    `json:"max_remaining" binding:"omitempty,min=0"` // เหลือขายไม่เกินกี่ใบ
+	HoursBefore  *int    `json:"hours_before"  binding:"omitempty,min=0"` // ก่อนออกรางวัลไม่เกินกี่ชั่วโมง
+	Adjust       string  `json:"adjust"        binding:"required"`        // percent / amount / fixed
+	Value        float64 `json:"value"`
+	Priority     int     `json:"priority"`
+	Active       *bool   `json:"active"`
+}
+
+func (r PriceRuleRequest) apply(rule *models.PriceRule) {
+	rule.Name = r.Name
+	rule.Pattern = r.Pattern
+	if rule.Pattern != nil && *rule.Pattern == "" {
+		rule.Pattern = nil
+	}
+	rule.MaxRemaining = r.MaxRemaining
+	rule.HoursBefore = r.HoursBefore
+	rule.Adjust = r.Adjust
+	rule.Value = r.Value
+	rule.Priority = r.Priority
+	rule.Active = r.Active == nil || *r.Active
+}
+
+// GET /pricing/rules
+// กฎราคาทั้งหมด (เรียงตามลำดับที่ใช้คำนวณ)
+func ListPriceRules(c *gin.Context, db *gorm.DB) {
+	var rules []models.PriceRule
+	if err := db.Order("priority ASC, rule_id ASC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rules,
+	})
+}
+
+// POST /pricing/rules
+// เพิ่มกฎราคา
+func CreatePriceRule(c *gin.Context, db *gorm.DB) {
+	var req PriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var rule models.PriceRule
+	req.apply(&rule)
+	if err := pricing.Validate(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err := db.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   rule,
+	})
+}
+
+// PUT /pricing/rules/:rule_id
+// แก้กฎราคา (ส่งข้อมูลทั้งกฎ)
+func UpdatePriceRule(c *gin.Context, db *gorm.DB) {
+	rule, ok := findPriceRule(c, db)
+	if !ok {
+		return
+	}
+	var req PriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	req.apply(&rule)
+	if err := pricing.Validate(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err := db.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rule,
+	})
+}
+
+// DELETE /pricing/rules/:rule_id
+func DeletePriceRule(c *gin.Context, db *gorm.DB) {
+	rule, ok := findPriceRule(c, db)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ลบกฎราคาเรียบร้อยแล้ว",
+	})
+}
+
+// findPriceRule อ่านกฎจาก :rule_id (ตอบ error ให้แล้วถ้าไม่พบ)
+func findPriceRule(c *gin.Context, db *gorm.DB) (models.PriceRule, bool) {
+	var rule models.PriceRule
+	ruleID, err := strconv.ParseUint(c.Param("rule_id"), 10, 64)
+	if err != nil || ruleID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid rule_id"})
+		return rule, false
+	}
+	if err := db.Where("rule_id = ?", ruleID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "price rule not found"})
+			return rule, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return rule, false
+	}
+	return rule, true
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/promotion"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PromotionRequest struct {
+	Code         *string    `json:"code"` // ว่าง = โปรอัตโนมัติ
+	Name         string     `json:"name"           binding:"required,max=100"`
+	Kind         string     `json:"kind"           binding:"required"` // percent / fixed / bundle
+	Value        float64    `json:"value"`
+	BuyQty       int        `json:"buy_qty"        binding:"omitempty,min=0"`
+	FreeQty      int        `json:"free_qty"       binding:"omitempty,min=0"`
+	MinTotal     float64    `json:"min_total"      binding:"omitempty,min=0"`
+	MaxDiscount  float64    `json:"max_discount"   binding:"omitempty,min=0"`
+	PerUserLimit int        `json:"per_user_limit" binding:"omitempty,min=0"`
+	TotalLimit   int        `json:"total_limit"    binding:"omitempty,min=0"`
+	StartsAt     *time.Time `json:"starts_at"` // RFC3339 เช่น 2025-11-01T00:00:00+07:00
+	EndsAt       *time.Time `json:"ends_at"`
+	Active       *bool      `json:"active"`
+}
+
+func (r PromotionRequest) apply(p *models.Promotion) {
+	p.Code = nil
+	if r.Code != nil {
+		if code := promotion.Normalize(*r.Code); code != "" {
+			p.Code = &code
+		}
+	}
+	p.Name = r.Name
+	p.Kind = r.Kind
+	p.Value = r.Value
+	p.BuyQty = r.BuyQty
+	p.FreeQty = r.FreeQty
+	p.MinTotal = r.MinTotal
+	p.MaxDiscount = r.MaxDiscount
+	p.PerUserLimit = r.PerUserLimit
+	p.TotalLimit = r.TotalLimit
+	p.StartsAt = r.StartsAt
+	p.EndsAt = r.EndsAt
+	p.Active = r.Active == nil || *r.Active
+}
+
+type promotionRow struct {
+	models.Promotion
+	Used          int     `json:"used"`
+	TotalDiscount float64 `json:"total_discount"`
+}
+
+// GET /promotions
+// โปรโมชันทั้งหมด พร้อมจำนวนครั้งที่ถูกใช้และยอดส่วนลดรวม
+func ListPromotions(c *gin.Context, db *gorm.DB) {
+	var rows []promotionRow
+	if err := db.Raw(`
+		SELECT p.*, COUNT(u.use_id) AS used, COALESCE(SUM(u.discount), 0) AS total_discount
+		FROM promotions AS p
+		LEFT JOIN promotion_uses AS u ON u.promotion_id = p.promotion_id
+		GROUP BY p.promotion_id
+		ORDER BY p.promotion_id DESC`).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rows,
+	})
+}
+
+// POST /promotions
+// สร้างโปรโมชัน / โค้ดส่วนลด
+func CreatePromotion(c *gin.Context, db *gorm.DB) {
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var p models.Promotion
+	req.apply(&p)
+	if !savePromotion(c, db, &p) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   p,
+	})
+}
+
+// PUT /promotions/:promotion_id
+// แก้โปรโมชัน (ส่งข้อมูลทั้งโปร)
+func UpdatePromotion(c *gin.Context, db *gorm.DB) {
+	p, ok := findPromotion(c, db)
+	if !ok {
+		return
+	}
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	req.apply(&p)
+	if !savePromotion(c, db, &p) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   p,
+	})
+}
+
+// DELETE /promotions/:promotion_id
+// ปิดโปรโมชัน (ไม่ลบจริง เพราะบิลเก่ายังอ้างถึง)
+func DeletePromotion(c *gin.Context, db *gorm.DB) {
+	p, ok := findPromotion(c, db)
+	if !ok {
+		return
+	}
+	if err := db.Model(&p).Update("active", false).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ปิดโปรโมชันเรียบร้อยแล้ว",
+	})
+}
+
+// savePromotion ตรวจและบันทึก (ตอบ error ให้แล้วถ้าไม่สำเร็จ)
+func savePromotion(c *gin.Context, db *gorm.DB, p *models.Promotion) bool {
+	if err := promotion.Validate(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return false
+	}
+	if p.Code != nil {
+		var taken int64
+		if err := db.Model(&models.Promotion{}).Where("code = ? AND promotion_id <> ?", *p.Code, p.PromotionID).Count(&taken).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return false
+		}
+		if taken > 0 {
+			c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "promotion code already exists"})
+			return false
+		}
+	}
+	if err := db.Save(p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return false
+	}
+	return true
+}
+
+// findPromotion อ่านโปรจาก :promotion_id (ตอบ error ให้แล้วถ้าไม่พบ)
+func findPromotion(c *gin.Context, db *gorm.DB) (models.Promotion, bool) {
+	var p models.Promotion
+	promotionID, err := strconv.ParseUint(c.Param("promotion_id"), 10, 64)
+	if err != nil || promotionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid promotion_id"})
+		return p, false
+	}
+	if err := db.Where("promotion_id = ?", promotionID).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "promotion not found"})
+			return p, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return p, false
+	}
+	return p, true
+}
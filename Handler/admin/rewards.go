@@ -2,9 +2,19 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"my-go-project/live"
 	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/push"
+	"my-go-project/sampler"
+	"my-go-project/stats"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -30,14 +40,8 @@ type RewardPreview struct {
 // GET /rewards/generate-preview
 // ฟังก์ชันสำหรับ "สุ่มรางวัล" เพื่อให้ Admin ตรวจสอบก่อน
 func GenerateRewardsPreview(c *gin.Context, db *gorm.DB) {
-	var lottos []models.Lotto
-
-
-	if err := db.Model(&models.Lotto{}).
-	
-		Order("RAND()").
-		Limit(4).
-		Find(&lottos).Error; err != nil {
+	lottos, err := sampler.Lottos(db, 4, "")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "database error"})
 		return
 	}
@@ -101,66 +105,104 @@ func ReleaseRewards(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-		// --- สร้าง slice ของ lotto_number ---
-		lottoNumbers := make([]string, 0, len(req.Rewards))
+		// --- ผลรางวัลพร้อมเลขและงวดของแต่ละรางวัล ---
+		results := make([]models.DrawResult, 0, len(req.Rewards))
+		releasedAt := time.Now().Truncate(time.Second)
 		for _, r := range req.Rewards {
 			var lotto models.Lotto
-			if err := tx.Select("lotto_number").Where("lotto_id = ?", r.LottoID).First(&lotto).Error; err != nil {
+			if err := tx.Select("lotto_number", "draw_id").Where("lotto_id = ?", r.LottoID).First(&lotto).Error; err != nil {
 				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to fetch lotto numbers"})
 				return
 			}
-			lottoNumbers = append(lottoNumbers, lotto.LottoNumber)
+			results = append(results, models.DrawResult{
+				DrawID:      lotto.DrawID,
+				ReleasedAt:  releasedAt,
+				PrizeTier:   r.PrizeTier,
+				PrizeMoney:  r.PrizeMoney,
+				LottoNumber: lotto.LottoNumber,
+			})
 		}
 
-			if err := tx.Exec(`
-		UPDATE purchases_detail pd
-		JOIN lotto l ON l.lotto_id = pd.lotto_id
-		SET pd.status = 'ถูก'
-		WHERE l.lotto_number IN ?
-	`, lottoNumbers).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to update winning purchase details"})
-		return
+	// เก็บผลรางวัลย้อนหลังไว้ทำสถิติ (ปล่อยรางวัลเดิมของงวดเดิมซ้ำ → แทนที่ผลเดิม รวมถึงผลที่ไม่ระบุงวด)
+	type drawTier struct {
+		draw uint // 0 = ไม่ระบุงวด
+		tier int
 	}
-
-	// สถานะไม่ถูก
-	if err := tx.Exec(`
-		UPDATE purchases_detail pd
-		JOIN lotto l ON l.lotto_id = pd.lotto_id
-		SET pd.status = 'ไม่ถูก'
-		WHERE l.lotto_number NOT IN ? AND pd.status = 'ยัง'
-	`, lottoNumbers).Error; err != nil {
+	replaced := map[drawTier]bool{}
+	for _, r := range results {
+		key := drawTier{tier: r.PrizeTier}
+		if r.DrawID != nil {
+			key.draw = *r.DrawID
+		}
+		if replaced[key] {
+			continue
+		}
+		replaced[key] = true
+		if err := tx.Exec("DELETE FROM draw_results WHERE draw_id <=> ? AND prize_tier = ?", r.DrawID, r.PrizeTier).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to archive results"})
+			return
+		}
+	}
+	if err := tx.Create(&results).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to update losing purchase details"})
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to archive results"})
 		return
 	}
 
-	if err := tx.Exec(`
-		UPDATE purchases_detail pd
+	// ผลตรวจของสลากในงวดที่ประกาศเท่านั้น เทียบกับผลของงวดเดียวกัน (งวดอื่น/งวดที่ยังรอผลไม่เกี่ยว)
+	// ใบที่ยังไม่ขึ้นเงินเริ่มใหม่จาก 'ยัง' เพื่อให้ปล่อยผลงวดเดิมซ้ำ (แก้ผล) ได้ถูกต้อง
+	scope, scopeArgs := resultDrawScope(results)
+	statusUpdates := []struct {
+		sql     string
+		message string
+	}{
+		{`UPDATE purchases_detail pd
+		JOIN lotto l ON l.lotto_id = pd.lotto_id
+		SET pd.status = 'ยัง'
+		WHERE pd.cash_in <> 'ขึ้นเงิน' AND ` + scope, "failed to reset purchase details"},
+		// รางวัลที่ 1-3 ตรงทั้ง 6 หลัก
+		{`UPDATE purchases_detail pd
+		JOIN lotto l ON l.lotto_id = pd.lotto_id
+		JOIN rewards r ON r.prize_tier BETWEEN 1 AND 3
+		JOIN lotto lr ON lr.lotto_id = r.lotto_id AND lr.draw_id <=> l.draw_id
+		SET pd.status = 'ถูก'
+		WHERE l.lotto_number = lr.lotto_number AND ` + scope, "failed to update winning purchase details"},
+		{`UPDATE purchases_detail pd
 		JOIN lotto l ON l.lotto_id = pd.lotto_id
 		JOIN rewards r ON r.prize_tier = 4
-		JOIN lotto lr ON lr.lotto_id = r.lotto_id
+		JOIN lotto lr ON lr.lotto_id = r.lotto_id AND lr.draw_id <=> l.draw_id
 		SET pd.status = 'ถูก'
-		WHERE RIGHT(l.lotto_number, 3) = RIGHT(lr.lotto_number, 3)
-	`).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to update prize tier 4 winning details"})
-		return
+		WHERE RIGHT(l.lotto_number, 3) = RIGHT(lr.lotto_number, 3) AND ` + scope, "failed to update prize tier 4 winning details"},
+		{`UPDATE purchases_detail pd
+		JOIN lotto l ON l.lotto_id = pd.lotto_id
+		JOIN rewards r ON r.prize_tier = 5
+		JOIN lotto lr ON lr.lotto_id = r.lotto_id AND lr.draw_id <=> l.draw_id
+		SET pd.status = 'ถูก'
+		WHERE RIGHT(l.lotto_number, 2) = RIGHT(lr.lotto_number, 2) AND ` + scope, "failed to update prize tier 5 winning details"},
+		// ที่เหลือของงวดนี้ = ไม่ถูก
+		{`UPDATE purchases_detail pd
+		JOIN lotto l ON l.lotto_id = pd.lotto_id
+		SET pd.status = 'ไม่ถูก'
+		WHERE pd.status = 'ยัง' AND ` + scope, "failed to update losing purchase details"},
+	}
+	for _, u := range statusUpdates {
+		if err := tx.Exec(u.sql, scopeArgs...).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": u.message})
+			return
+		}
 	}
 
-			if err := tx.Exec(`
-			UPDATE purchases_detail pd
-			JOIN lotto l ON l.lotto_id = pd.lotto_id
-			JOIN rewards r ON r.prize_tier = 5
-			JOIN lotto lr ON lr.lotto_id = r.lotto_id
-			SET pd.status = 'ถูก'
-			WHERE RIGHT(l.lotto_number, 2) = RIGHT(lr.lotto_number, 2)
-		`).Error; err != nil {
+	// งวดที่ประกาศผลแล้ว → สถานะ released (ห้ามโอน/ขายต่อสลากของงวดนี้)
+	if ids := resultDrawIDs(results); len(ids) > 0 {
+		if err := tx.Exec("UPDATE draws SET status = 'released' WHERE draw_id IN ?", ids).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to update prize tier 5 winning details"})
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to update draw status"})
 			return
 		}
+	}
 
 	// Commit Transaction
 	if err := tx.Commit().Error; err != nil {
@@ -168,6 +210,31 @@ func ReleaseRewards(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
+	// มีผลรางวัลใหม่ → คำนวณสถิติเลขร้อน/เลขเย็นใหม่ (ทำเบื้องหลัง)
+	go func() {
+		if _, err := stats.Recompute(db); err != nil {
+			log.Printf("stats: recompute failed: %v", err)
+		}
+	}()
+
+	// ส่งผลเข้า live stream หลัง commit เท่านั้น (ถ้าย้อนกลับ client จะไม่เห็นผลที่ไม่ได้ประกาศจริง)
+	// ทุกรางวัลของการประกาศครั้งนี้พร้อมกัน แต่แยกเป็น 1 event ต่อรางวัล เรียงจากรางวัลที่ 1
+	for _, ev := range drawResultEvents(results) {
+		live.Broadcast(live.TypeDrawResult, ev)
+	}
+
+	// แจ้งผลรางวัลทุกเครื่องที่สมัครรับ และแจ้งผู้ถือสลากที่ถูกรางวัล (ทำเบื้องหลัง)
+	drawIDs := resultDrawIDs(results)
+	announce := drawResultsMessage(results)
+	go func() {
+		if err := push.ToTopic(push.TopicDrawResults, announce); err != nil {
+			log.Printf("push: draw results failed: %v", err)
+		}
+		if _, err := notify.Winners(db, drawIDs); err != nil {
+			log.Printf("notify: winners failed: %v", err)
+		}
+	}()
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": fmt.Sprintf("ปล่อยรางวัลสำเร็จ! มีผลรางวัลใหม่ทั้งหมด %d รางวัล และอัปเดตผลการซื้อเรียบร้อยแล้ว", len(newRewards)),
@@ -176,6 +243,95 @@ func ReleaseRewards(c *gin.Context, db *gorm.DB) {
 
 
 
+// drawResultEvents ผลรางวัลแยกตามรางวัล เรียงจากรางวัลที่ 1
+// match คือเลขที่ต้องตรง (รางวัลที่ 4 = 3 ตัวท้าย, รางวัลที่ 5 = 2 ตัวท้าย)
+func drawResultEvents(results []models.DrawResult) []gin.H {
+	byTier := map[int]gin.H{}
+	var tiers []int
+	for _, r := range results {
+		ev, ok := byTier[r.PrizeTier]
+		if !ok {
+			ev = gin.H{"prize_tier": r.PrizeTier, "prize_money": r.PrizeMoney, "draw_id": r.DrawID, "numbers": []string{}, "match": []string{}}
+			byTier[r.PrizeTier] = ev
+			tiers = append(tiers, r.PrizeTier)
+		}
+		match := r.LottoNumber
+		if len(match) == 6 && r.PrizeTier == 4 {
+			match = match[3:]
+		} else if len(match) == 6 && r.PrizeTier == 5 {
+			match = match[4:]
+		}
+		ev["numbers"] = append(ev["numbers"].([]string), r.LottoNumber)
+		ev["match"] = append(ev["match"].([]string), match)
+	}
+	sort.Ints(tiers)
+	events := make([]gin.H, 0, len(tiers))
+	for _, t := range tiers {
+		events = append(events, byTier[t])
+	}
+	return events
+}
+
+// drawResultsMessage ข้อความ push ประกาศผลรางวัล (รางวัลที่ 1 และเลขท้าย 2 ตัว)
+func drawResultsMessage(results []models.DrawResult) push.Message {
+	m := push.Message{Title: "ประกาศผลสลากแล้ว", Data: map[string]string{"type": "draw_results"}}
+	var parts []string
+	for _, r := range results {
+		switch r.PrizeTier {
+		case 1:
+			parts = append(parts, "รางวัลที่ 1: "+r.LottoNumber)
+			m.Data["prize1"] = r.LottoNumber
+		case 5:
+			if len(r.LottoNumber) == 6 {
+				parts = append(parts, "เลขท้าย 2 ตัว: "+r.LottoNumber[4:])
+				m.Data["last2"] = r.LottoNumber[4:]
+			}
+		}
+	}
+	if ids := resultDrawIDs(results); len(ids) == 1 {
+		m.Data["draw_id"] = strconv.FormatUint(uint64(ids[0]), 10)
+	}
+	m.Body = strings.Join(parts, " | ")
+	if m.Body == "" {
+		m.Body = "ตรวจผลรางวัลงวดล่าสุดได้แล้ววันนี้"
+	}
+	return m
+}
+
+// resultDrawScope เงื่อนไข WHERE ของสลาก (ตาราง l = lotto) ในงวดที่อยู่ในผลรางวัล
+// ผลที่ไม่ผูกงวด (ข้อมูลแบบเก่า) ครอบคลุมสลากที่ไม่ผูกงวด
+func resultDrawScope(results []models.DrawResult) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if ids := resultDrawIDs(results); len(ids) > 0 {
+		conds = append(conds, "l.draw_id IN ?")
+		args = append(args, ids)
+	}
+	for _, r := range results {
+		if r.DrawID == nil {
+			conds = append(conds, "l.draw_id IS NULL")
+			break
+		}
+	}
+	if len(conds) == 0 {
+		return "1 = 0", nil
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// resultDrawIDs งวดที่มีอยู่ในผลรางวัล (ไม่ซ้ำ)
+func resultDrawIDs(results []models.DrawResult) []uint {
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, r := range results {
+		if r.DrawID != nil && !seen[*r.DrawID] {
+			seen[*r.DrawID] = true
+			ids = append(ids, *r.DrawID)
+		}
+	}
+	return ids
+}
+
 type CurrentRewardResponse struct {
 	PrizeTier   int     `json:"prize_tier"`
 	PrizeMoney  float64 `json:"prize_money"`
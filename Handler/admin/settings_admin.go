@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-go-project/settings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /admin/settings
+// ดูค่าตั้งระบบทั้งหมด
+func GetSettings(c *gin.Context, db *gorm.DB) {
+	all, err := settings.All(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   all,
+	})
+}
+
+type UpdateSettingRequest struct {
+	Key   string `json:"key"   binding:"required"`
+	Value string `json:"value" binding:"required"`
+}
+
+// PUT /admin/settings
+// แก้ค่าตั้งระบบ 1 ค่า (เฉพาะ key ที่ระบบรู้จัก)
+func UpdateSetting(c *gin.Context, db *gorm.DB) {
+	var req UpdateSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request body"})
+		return
+	}
+	if _, ok := settings.Defaults[req.Key]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "unknown setting key: " + req.Key})
+		return
+	}
+
+	if err := settings.Set(db, req.Key, req.Value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "บันทึกค่าเรียบร้อยแล้ว",
+		"data":    gin.H{"key": req.Key, "value": req.Value},
+	})
+}
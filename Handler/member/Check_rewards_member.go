@@ -5,7 +5,11 @@ import (
 	"net/http"
 	"strings"
 
+	"my-go-project/live"
 	"my-go-project/models" // อย่าลืมแก้ path ให้ถูกต้อง
+	"my-go-project/notify"
+	"my-go-project/syndicate"
+	"my-go-project/wallet"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -122,6 +126,28 @@ func CheckUserLotto(c *gin.Context, db *gorm.DB) {
 	})
 }
 
+// prizeForNumber หารางวัลสูงสุดของเลขนี้จากผลรางวัลปัจจุบันของงวด drawID (ต่อ 1 ใบ)
+// รางวัลที่ 1-3 ตรงทั้ง 6 หลัก (ทุกชุดของเลขนั้น), รางวัลที่ 4-5 ตรงด้วยเลขท้าย 3/2 ตัว
+// drawID = nil คือสลากที่ไม่ผูกกับงวด (ข้อมูลแบบเก่า) เทียบกับผลที่ไม่ผูกงวดเท่านั้น
+// คืน tier = 0 ถ้าไม่ถูกรางวัล
+func prizeForNumber(db *gorm.DB, lottoNumber string, drawID *uint) (int, float64, error) {
+	var r struct {
+		PrizeTier  int
+		PrizeMoney float64
+	}
+	err := db.Raw(`
+		SELECT r.prize_tier, r.prize_money
+		FROM rewards AS r
+		JOIN lotto AS lr ON lr.lotto_id = r.lotto_id
+		WHERE lr.draw_id <=> ?
+		  AND ((r.prize_tier BETWEEN 1 AND 3 AND lr.lotto_number = ?)
+		   OR (r.prize_tier = 4 AND RIGHT(lr.lotto_number, 3) = RIGHT(?, 3))
+		   OR (r.prize_tier = 5 AND RIGHT(lr.lotto_number, 2) = RIGHT(?, 2)))
+		ORDER BY r.prize_tier ASC
+		LIMIT 1`, drawID, lottoNumber, lottoNumber, lottoNumber).Scan(&r).Error
+	return r.PrizeTier, r.PrizeMoney, err
+}
+
 // ใช้ CashInRequest struct
 // ใช้ CashInRequest struct
 type CashInRequest struct {
@@ -141,110 +167,100 @@ func CashIn(c *gin.Context, db *gorm.DB) {
 	}
 
 	var lotto models.Lotto
-	result := db.Raw("SELECT lotto_id FROM lotto WHERE lotto_number = ? LIMIT 1", req.LottoNumber).Scan(&lotto)
+	result := db.Raw("SELECT lotto_id, lotto_number FROM lotto WHERE lotto_number = ? LIMIT 1", req.LottoNumber).Scan(&lotto)
 	if result.Error != nil || result.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Lotto number not found"})
 		return
 	}
 
+	// สลากเลขนี้ที่ผู้ใช้ถืออยู่ตอนนี้ (อาจมีหลายชุด รวมที่ได้รับโอนมา) พร้อมงวดและผลตรวจ
+	// รวมสลากของกลุ่ม (syndicate) ที่ผู้ใช้เป็นสมาชิกด้วย
 	type PDRow struct {
-		PDID   uint
-		CashIn string
+		PDID        uint
+		Status      string
+		CashIn      string
+		PurchaseID  uint
+		DrawID      *uint
+		SyndicateID *uint
 	}
-	var pd PDRow
+	var pds []PDRow
 	result = db.Raw(`
-		SELECT pd.pd_id, pd.cash_in
+		SELECT pd.pd_id, pd.status, pd.cash_in, pd.purchase_id, l.draw_id, p.syndicate_id
 		FROM purchases_detail AS pd
 		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
-		WHERE pd.lotto_id = ? AND p.user_id = ?
-		LIMIT 1`, lotto.LottoID, req.UserID).Scan(&pd)
-
-	if result.Error != nil || result.RowsAffected == 0 {
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		LEFT JOIN syndicate_members AS sm
+			ON sm.syndicate_id = p.syndicate_id AND sm.user_id = ? AND sm.status = 'joined'
+		WHERE l.lotto_number = ?
+		  AND ((p.syndicate_id IS NULL AND pd.owner_id = ?) OR sm.user_id IS NOT NULL)
+		ORDER BY pd.pd_id ASC`, req.UserID, req.LottoNumber, req.UserID).Scan(&pds)
+
+	if result.Error != nil || len(pds) == 0 {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You do not own this lottery ticket"})
 		return
 	}
-	if pd.CashIn == "ขึ้นเงิน" {
-		c.JSON(http.StatusConflict, gin.H{"error": "This prize has already been claimed"})
-		return
-	}
-
-	// --- 4. ตรวจสอบว่าถูกรางวัลหรือไม่ (Reworked Logic) ---
-	var reward models.Reward
-	result = db.Raw("SELECT * FROM rewards WHERE lotto_id = ? LIMIT 1", lotto.LottoID).Scan(&reward)
 
-	userNumber := req.LottoNumber
-	isWinner := false
+	// --- 4. ตรวจสอบว่าถูกรางวัลหรือไม่ ---
+	// จ่ายเฉพาะใบที่ยังไม่ขึ้นเงิน ผลตรวจเป็น "ถูก" และถูกรางวัลตามผลของงวดเดียวกับสลากใบนั้น
+	// (เลขเดียวกันของงวดอื่น หรือที่ยังรอผล จะไม่ถูกจ่าย)
+	type prize struct {
+		tier  int
+		money float64
+	}
+	prizes := map[uint]prize{} // draw_id (0 = ไม่ผูกงวด) -> รางวัลต่อใบ
+	var pdIDs []uint
+	unclaimed := 0
+	personalPrize := float64(0)
+	syndicatePrize := map[[2]uint]float64{} // {syndicate_id, purchase_id} -> เงินรางวัลของบิลกลุ่ม
+	totalPrize := float64(0)
 	prizeTier := 0
-	prizeMoney := float64(0)
-
-	// Case 1: รางวัลตรง (รางวัลที่ 1–3)
-	if result.Error == nil && result.RowsAffected > 0 {
-		// ตรวจสอบให้แน่ใจว่าเป็นรางวัลประเภทเลขตรง 6 หลัก
-		if reward.PrizeTier >= 1 && reward.PrizeTier <= 3 {
-			isWinner = true
-			prizeTier = reward.PrizeTier
-			prizeMoney = reward.PrizeMoney
+	prizeMoney := float64(0) // รางวัลต่อใบของรางวัลสูงสุดที่พบ
+	for _, pd := range pds {
+		if pd.CashIn == "ขึ้นเงิน" {
+			continue
 		}
-	}
-
-	// Case 2: รางวัลเลขท้าย (รางวัลที่ 4–5)
-	if !isWinner {
-		var allRewards []models.Reward
-		if err := db.Preload("Lotto").Find(&allRewards).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reward numbers"})
-			return
+		unclaimed++
+		if pd.Status != "ถูก" {
+			continue
 		}
-
-		// ตัวแปรสำหรับเก็บรางวัลเลขท้ายโดยเฉพาะ
-		var prize4Numbers []string
-		var prize4Money float64
-		var prize5Number string
-		var prize5Money float64
-
-		for _, r := range allRewards {
-			switch r.PrizeTier {
-			case 4: // รางวัลเลขท้าย 3 ตัว
-				if r.Lotto != nil && len(r.Lotto.LottoNumber) == 6 {
-					prize4Numbers = append(prize4Numbers, r.Lotto.LottoNumber[3:])
-					if prize4Money == 0 {
-						prize4Money = r.PrizeMoney
-					}
-				}
-			case 5: // รางวัลเลขท้าย 2 ตัว
-				if r.Lotto != nil && len(r.Lotto.LottoNumber) == 6 {
-					prize5Number = r.Lotto.LottoNumber[4:]
-					prize5Money = r.PrizeMoney
-				}
-			}
+		key := uint(0)
+		if pd.DrawID != nil {
+			key = *pd.DrawID
 		}
-
-		// ตรวจรางวัลที่ 4 (เลขท้าย 3 ตัว)
-		if len(prize4Numbers) > 0 {
-			for _, p4num := range prize4Numbers {
-				if strings.HasSuffix(userNumber, p4num) {
-					isWinner = true
-					prizeTier = 4
-					prizeMoney = prize4Money // ใช้เงินรางวัลของ Tier 4
-					break
-				}
+		pz, ok := prizes[key]
+		if !ok {
+			tier, money, err := prizeForNumber(db, req.LottoNumber, pd.DrawID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reward numbers"})
+				return
 			}
+			pz = prize{tier: tier, money: money}
+			prizes[key] = pz
 		}
-
-		// ตรวจรางวัลที่ 5 (เลขท้าย 2 ตัว)
-		if !isWinner && prize5Number != "" {
-			if strings.HasSuffix(userNumber, prize5Number) {
-				isWinner = true
-				prizeTier = 5
-				prizeMoney = prize5Money // ใช้เงินรางวัลของ Tier 5
-			}
+		if pz.tier == 0 {
+			continue
+		}
+		pdIDs = append(pdIDs, pd.PDID)
+		totalPrize += pz.money
+		if pd.SyndicateID != nil {
+			syndicatePrize[[2]uint{*pd.SyndicateID, pd.PurchaseID}] += pz.money
+		} else {
+			personalPrize += pz.money
+		}
+		if prizeTier == 0 || pz.tier < prizeTier {
+			prizeTier, prizeMoney = pz.tier, pz.money
 		}
 	}
-
+	if unclaimed == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This prize has already been claimed"})
+		return
+	}
 	// ถ้าไม่ถูกรางวัลเลย
-	if !isWinner {
+	if len(pdIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "This ticket is not a winning ticket"})
 		return
 	}
+	copies := len(pdIDs)
 
 	// --- 5. Transaction ---
 	tx := db.Begin()
@@ -253,30 +269,74 @@ func CashIn(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	// อัปเดต Wallet ของ User
-	err := tx.Exec("UPDATE users SET wallet = wallet + ? WHERE user_id = ?", prizeMoney, req.UserID).Error
+	// อัปเดต Wallet ของ User (เฉพาะสลากที่ซื้อเอง)
+	note := fmt.Sprintf("รางวัลที่ %d เลข %s", prizeTier, req.LottoNumber)
+	err := wallet.Credit(tx, req.UserID, personalPrize, wallet.TypePrize, nil, note)
 	if err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user wallet"})
 		return
 	}
 
+	// สลากของกลุ่ม: แบ่งเงินรางวัลให้สมาชิกตามสัดส่วนเงินสมทบที่บันทึกไว้ตอนซื้อบิลนั้น
+	syndicatePayouts := map[uint][]syndicate.Payout{}
+	for key, amount := range syndicatePrize {
+		sid := key[0]
+		payouts, err := syndicate.PayPrize(tx, sid, key[1], amount, note)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pay syndicate members: " + err.Error()})
+			return
+		}
+		syndicatePayouts[sid] = append(syndicatePayouts[sid], payouts...)
+	}
+
 	// อัปเดต purchases_detail.cash_in = 'ขึ้นเงิน'
-	err = tx.Exec("UPDATE purchases_detail SET cash_in = ? WHERE pd_id = ?", "ขึ้นเงิน", pd.PDID).Error
-	if err != nil {
+	res := tx.Exec("UPDATE purchases_detail SET cash_in = ? WHERE pd_id IN ? AND cash_in <> ?", "ขึ้นเงิน", pdIDs, "ขึ้นเงิน")
+	if res.Error != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update purchase detail status"})
 		return
 	}
+	if res.RowsAffected != int64(copies) { // มีคำขออื่นขึ้นเงินไปพร้อมกัน
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This prize has already been claimed"})
+		return
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
 		return
 	}
 
+	notify.PublishQuiet(db, req.UserID, notify.TypePrize,
+		"ขึ้นเงินรางวัลสำเร็จ",
+		fmt.Sprintf("รางวัลที่ %d เลข %s จำนวน %d ใบ รวม %.2f บาท", prizeTier, req.LottoNumber, copies, totalPrize),
+		map[string]any{"lotto_number": req.LottoNumber, "prize_tier": prizeTier, "prize_money": totalPrize, "pd_ids": pdIDs})
+	live.TicketStatus(req.UserID, "claimed", map[string]any{"lotto_number": req.LottoNumber, "pd_ids": pdIDs, "prize_money": totalPrize})
+
+	// สมาชิกกลุ่มได้ส่วนแบ่งเข้ากระเป๋า
+	for sid, payouts := range syndicatePayouts {
+		for _, p := range payouts {
+			if p.UserID == req.UserID || p.Amount <= 0 {
+				continue
+			}
+			notify.PublishQuiet(db, p.UserID, notify.TypePrize,
+				"ได้รับส่วนแบ่งเงินรางวัล",
+				fmt.Sprintf("%s (กลุ่ม #%d) ได้รับ %.2f บาท", note, sid, p.Amount),
+				map[string]any{"syndicate_id": sid, "lotto_number": req.LottoNumber, "amount": p.Amount})
+		}
+	}
+
 	// --- 6. Response สำเร็จ ---
-	c.JSON(http.StatusOK, gin.H{
-		"message":     fmt.Sprintf("Prize claimed successfully! (Tier %d)", prizeTier),
-		"prize_money": prizeMoney,
-	})
+	resp := gin.H{
+		"message":        fmt.Sprintf("Prize claimed successfully! (Tier %d)", prizeTier),
+		"prize_money":    totalPrize,
+		"prize_per_copy": prizeMoney,
+		"copies":         copies,
+	}
+	if len(syndicatePayouts) > 0 {
+		resp["syndicate_payouts"] = syndicatePayouts
+	}
+	c.JSON(http.StatusOK, resp)
 }
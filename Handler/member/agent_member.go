@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"my-go-project/agent"
+	"my-go-project/models"
+	"my-go-project/paging"
+	"my-go-project/purchase"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AgentBuyRequest struct {
+	CustomerID uint        `json:"customer_id" binding:"required"` // ลูกค้าที่จะเป็นเจ้าของสลาก
+	LottoIDs   []uint      `json:"lotto_ids"`
+	Numbers    []BuyNumber `json:"numbers,omitempty" binding:"dive"`
+	PromoCode  string      `json:"promo_code,omitempty"`
+}
+
+// agentIDParam อ่าน :agent_id (ตอบ error ให้แล้วถ้าไม่ถูกต้อง)
+func agentIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("agent_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid agent_id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// POST /agents/:agent_id/purchases
+// ตัวแทนซื้อสลากแทนลูกค้า (จ่ายจาก wallet ตัวแทน ลูกค้าเป็นเจ้าของสลาก) ได้ค่าคอมตามอัตราของตัวแทน
+// ซื้อได้ทั้งสลากที่แบ่งให้ตัวแทนคนนี้และสลากทั่วไป
+func AgentPurchase(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentIDParam(c)
+	if !ok {
+		return
+	}
+	var req AgentBuyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.LottoIDs) == 0 && len(req.Numbers) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request"})
+		return
+	}
+
+	buy := purchase.Request{UserID: req.CustomerID, LottoIDs: req.LottoIDs, PromoCode: req.PromoCode, AgentID: &agentID}
+	for _, n := range req.Numbers {
+		buy.Numbers = append(buy.Numbers, purchase.Number{LottoNumber: n.LottoNumber, Quantity: n.Quantity})
+	}
+
+	res, err := purchase.Buy(db, buy)
+	if err == nil {
+		notifyPurchase(db, req.CustomerID, res)
+	}
+	respondPurchase(c, res, err)
+}
+
+// GET /agents/:agent_id/inventory?limit=100&cursor=
+// สลากที่แบ่งให้ตัวแทนและยังขายไม่ได้ เรียงตามเลข
+func AgentInventory(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentIDParam(c)
+	if !ok {
+		return
+	}
+	page, err := paging.FromQuery(c, 100, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	const from = `
+		FROM agent_allocations AS a
+		JOIN lotto AS l ON l.lotto_id = a.lotto_id
+		WHERE a.agent_id = ? AND l.status = 'sell'`
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*)"+from, agentID).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := "SELECT l.*" + from
+	args := []interface{}{agentID}
+	if cond, condArgs := page.After("l.lotto_number", "l.lotto_id", false); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY l.lotto_number ASC, l.lotto_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var items []models.Lotto
+	if err := db.Raw(sql, args...).Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(items))
+	items = items[:n]
+	next := ""
+	if more {
+		last := items[n-1]
+		next = paging.Cursor{Value: last.LottoNumber, ID: last.LottoID}.Encode()
+	}
+	paging.Respond(c, items, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+// GET /agents/:agent_id/statements?draw_id=3
+// สรุปยอดขายและค่าคอมมิชชันรายงวด (ไม่ส่ง draw_id = ทุกงวด)
+func AgentStatements(c *gin.Context, db *gorm.DB) {
+	agentID, ok := agentIDParam(c)
+	if !ok {
+		return
+	}
+	var drawID *uint
+	if s := c.Query("draw_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid draw_id"})
+			return
+		}
+		d := uint(id)
+		drawID = &d
+	}
+
+	statements, err := agent.Statements(db, agentID, drawID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   statements,
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"my-go-project/push"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DeviceRequest struct {
+	UserID   uint   `json:"user_id"  binding:"required"`
+	Token    string `json:"token"    binding:"required,max=512"` // FCM registration token จากแอป
+	Platform string `json:"platform"`                            // android (ค่าเริ่มต้น) / ios / web
+}
+
+// POST /devices
+// ลงทะเบียนเครื่องรับ push (เรียกทุกครั้งที่เปิดแอปหรือ token เปลี่ยน)
+// ผลรางวัลทุกงวดส่งผ่าน topic "draw_results" ให้แอปสมัครเองฝั่ง client
+func RegisterDevice(c *gin.Context, db *gorm.DB) {
+	var req DeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var exists int64
+	if err := db.Raw("SELECT COUNT(*) FROM users WHERE user_id = ?", req.UserID).Scan(&exists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	device, err := push.Register(db, req.UserID, req.Token, req.Platform)
+	if errors.Is(err, push.ErrInvalidPlatform) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   device,
+		"topics": []string{push.TopicDrawResults},
+	})
+}
+
+// DELETE /devices
+// ยกเลิกการรับ push ของเครื่องนี้ (ออกจากระบบ)
+func UnregisterDevice(c *gin.Context, db *gorm.DB) {
+	var req DeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request"})
+		return
+	}
+
+	removed, err := push.Unregister(db, req.UserID, req.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "device not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ยกเลิกการแจ้งเตือนของเครื่องนี้แล้ว",
+	})
+}
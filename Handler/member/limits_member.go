@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/limits"
+	"my-go-project/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /users/limits?user_id=5
+// ดูวงเงินซื้อของตัวเอง ยอดที่ใช้ไปแล้ว และสถานะพักการซื้อ
+func GetLimits(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	uid := uint(userID)
+	now := time.Now()
+
+	var user models.User
+	result := db.Raw("SELECT user_id, excluded_until FROM users WHERE user_id = ?", uid).Scan(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	userLimits, err := limits.Load(db, uid, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	spentToday, err := limits.SpentSince(db, uid, limits.StartOfDay(now))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	spentWeek, err := limits.SpentSince(db, uid, limits.StartOfWeek(now))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	spent := map[string]*float64{limits.Daily: &spentToday, limits.Weekly: &spentWeek, limits.Draw: nil}
+
+	data := make([]gin.H, 0, len(limits.Periods))
+	for _, period := range limits.Periods {
+		item := gin.H{"period": period, "amount": nil, "pending_amount": nil, "pending_at": nil}
+		if l := userLimits[period]; l != nil {
+			item["amount"] = l.Amount
+			item["pending_amount"] = l.PendingAmount
+			item["pending_at"] = l.PendingAt
+		}
+		effective, source := limits.Effective(db, userLimits, period)
+		item["effective"] = effective
+		item["source"] = source
+		item["spent"] = spent[period]
+		data = append(data, item)
+	}
+
+	var excludedUntil *time.Time
+	if user.ExcludedUntil != nil && user.ExcludedUntil.After(now) {
+		excludedUntil = user.ExcludedUntil
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "success",
+		"data":           data,
+		"excluded_until": excludedUntil,
+	})
+}
+
+type SetLimitsRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+	Limits []struct {
+		Period string   `json:"period" binding:"required,oneof=daily weekly draw"`
+		Amount *float64 `json:"amount" binding:"omitempty,gt=0"` // null = ไม่จำกัด
+	} `json:"limits" binding:"required,min=1,dive"`
+}
+
+// PUT /users/limits
+// ตั้งวงเงินซื้อ ลดได้ทันที แต่เพิ่ม/ยกเลิกต้องรอ cool-down
+func SetLimits(c *gin.Context, db *gorm.DB) {
+	var req SetLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var user models.User
+	result := db.Raw("SELECT user_id FROM users WHERE user_id = ?", req.UserID).Scan(&user)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	now := time.Now()
+	var saved []*models.UserLimit
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, l := range req.Limits {
+			row, err := limits.Set(tx, req.UserID, l.Period, l.Amount, now)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, row)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   saved,
+	})
+}
+
+type SelfExcludeRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+	Days   int  `json:"days"    binding:"required,min=1,max=3650"`
+}
+
+// POST /users/self-exclusion
+// พักการซื้อตามจำนวนวัน ยืดเวลาได้แต่ย่นเวลาไม่ได้
+func SelfExclude(c *gin.Context, db *gorm.DB) {
+	var req SelfExcludeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	until := time.Now().AddDate(0, 0, req.Days)
+	result := db.Exec(`
+		UPDATE users
+		SET excluded_until = ?
+		WHERE user_id = ? AND (excluded_until IS NULL OR excluded_until < ?)`, until, req.UserID, until)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+
+	var user models.User
+	if err := db.Raw("SELECT user_id, excluded_until FROM users WHERE user_id = ?", req.UserID).Scan(&user).Error; err != nil || user.UserID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "success",
+		"message":        "พักการซื้อเรียบร้อยแล้ว",
+		"excluded_until": user.ExcludedUntil,
+	})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/live"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ช่วงเวลาส่ง heartbeat กัน proxy ตัด connection ที่เงียบนาน
+const liveHeartbeat = 25 * time.Second
+
+type liveReward struct {
+	DrawID      *uint   `json:"draw_id"`
+	PrizeTier   int     `json:"prize_tier"`
+	PrizeMoney  float64 `json:"prize_money"`
+	LottoNumber string  `json:"lotto_number"`
+}
+
+// GET /live?user_id=5
+// Server-Sent Events: ผลรางวัลทีละรางวัลเมื่อการประกาศบันทึกสำเร็จ, จำนวนสลากคงเหลือ และ (ถ้าส่ง user_id) สถานะสลาก/ข้อความของผู้ใช้
+// เริ่มด้วย event "snapshot" (ผลรางวัลปัจจุบัน + สลากคงเหลือ) ต่อใหม่ด้วย header Last-Event-ID เพื่อรับเหตุการณ์ที่พลาด
+func LiveStream(c *gin.Context, db *gorm.DB) {
+	var userID uint
+	if s := c.Query("user_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+			return
+		}
+		userID = uint(id)
+	}
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if lastID == 0 {
+		lastID, _ = strconv.ParseUint(c.Query("last_event_id"), 10, 64)
+	}
+
+	// subscribe ก่อนอ่าน snapshot: event ที่เกิดระหว่างอ่านจะรออยู่ในคิว ไม่หายไปช่วงรอยต่อ
+	client := live.Default.Subscribe(userID, lastID)
+	defer live.Default.Unsubscribe(client)
+
+	var rewards []liveReward
+	if err := db.Raw(`
+		SELECT l.draw_id, r.prize_tier, r.prize_money, l.lotto_number
+		FROM rewards AS r
+		JOIN lotto AS l ON l.lotto_id = r.lotto_id
+		ORDER BY r.prize_tier ASC, l.lotto_number ASC`).Scan(&rewards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	inventory, err := live.Inventory(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // ปิด buffer ของ nginx
+	w.WriteHeader(http.StatusOK)
+
+	// retry: ให้ browser ต่อใหม่ภายใน 3 วินาทีเมื่อหลุด
+	fmt.Fprint(w, "retry: 3000\n\n")
+	writeLiveEvent(w, 0, "snapshot", gin.H{"rewards": rewards, "inventory": inventory})
+	w.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-client.Events:
+			if !ok {
+				return // อ่านไม่ทันจนถูกตัด client จะต่อใหม่พร้อม Last-Event-ID
+			}
+			writeLiveEvent(w, e.ID, e.Type, e.Data)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// writeLiveEvent เขียน 1 event ในรูปแบบ SSE (data เป็น JSON บรรทัดเดียว)
+func writeLiveEvent(w gin.ResponseWriter, id uint64, event string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b = []byte("null")
+	}
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
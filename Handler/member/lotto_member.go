@@ -2,52 +2,115 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"my-go-project/models"
+	"my-go-project/auspicious"
+	"my-go-project/sampler"
+	"my-go-project/stats"
 )
 
 
 
 
 
+// GET /lotto/lucky?recommend=hot
+// สุ่มสลากที่ยังซื้อได้ 3 ใบ ถ้าส่ง recommend=hot|cold จะสุ่มจากใบที่เลขท้าย 2 ตัว
+// เป็นเลขร้อน (ออกบ่อย) หรือเลขเย็น (ไม่ออกนาน) ตามสถิติผลรางวัลย้อนหลัง
 func LottoLucky(c *gin.Context, db *gorm.DB) {
 
 	const luckyLottoCount = 3
+	const recommendPool = 10 // ใช้เลขท้าย 10 อันดับแรกจากสถิติ
+
+	// เฉพาะใบที่ซื้อได้จริง (ไม่ติดจอง ไม่ได้แบ่งให้ตัวแทน และงวดยังเปิดขาย)
+	where, args := sampler.Buyable(time.Now())
+	var recommended []string
+
+	if mode := c.Query("recommend"); mode != "" {
+		if mode != "hot" && mode != "cold" {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "recommend must be hot or cold"})
+			return
+		}
+		report, err := stats.Get(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		// ยังไม่มีผลรางวัลย้อนหลัง → สุ่มปกติ
+		if report.Draws > 0 {
+			list := report.Last2.Hot
+			if mode == "cold" {
+				list = report.Last2.Cold
+			}
+			var conds []string
+			for _, n := range stats.Top(list, recommendPool) {
+				recommended = append(recommended, n.Number)
+				conds = append(conds, "lotto_number_rev LIKE ?")
+				args = append(args, string([]byte{n.Number[1], n.Number[0]})+"%")
+			}
+			where += " AND (" + strings.Join(conds, " OR ") + ")"
+		}
+	}
 
-	const sql = "SELECT * FROM lotto WHERE status = ? ORDER BY RAND() LIMIT ?"
-
-	var items []models.Lotto
-	if err := db.Raw(sql, "sell", luckyLottoCount).Scan(&items).Error; err != nil {
+	items, err := sampler.Lottos(db, luckyLottoCount, where, args...)
+	if err != nil {
 		// จัดการ Error กรณีที่การ query ล้มเหลว (เหมือนเดิม)
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"total":  len(items),
-		"data":   items,
+		"status":      "success",
+		"total":       len(items),
+		"data":        items,
+		"recommended": recommended, // เลขท้ายที่ใช้เลือก (null = สุ่มปกติ)
 	})
 }
 
+// GET /lotto/Auspicious?birth_date=1995-04-12&day=จันทร์&name=สมชาย&dream=ฝันเห็นงูใหญ่&limit=3
+// คำนวณเลขมงคล แล้วคืนสลากที่ยังขายได้ที่ใกล้เคียงที่สุด (ไม่ส่งข้อมูลเลย = เลขมงคลประจำวันนี้)
 func LottoAuspicious(c *gin.Context, db *gorm.DB) {
 
-	const luckyLottoCount = 3
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "3"))
+	if limit <= 0 || limit > 20 {
+		limit = 3
+	}
 
-	const sql = "SELECT * FROM lotto WHERE status = ? ORDER BY RAND() LIMIT ?"
+	in := auspicious.Input{
+		Day:   c.Query("day"),
+		Name:  c.Query("name"),
+		Dream: c.Query("dream"),
+		Now:   time.Now(),
+	}
+	if s := c.Query("birth_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "birth_date must be YYYY-MM-DD"})
+			return
+		}
+		in.BirthDate = &d
+	}
+
+	candidates, err := auspicious.Compute(in)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 
-	var items []models.Lotto
-	if err := db.Raw(sql, "sell", luckyLottoCount).Scan(&items).Error; err != nil {
+	items, err := auspicious.FindAvailable(db, candidates, limit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"total":  len(items),
-		"data":   items,
+		"status":     "success",
+		"total":      len(items),
+		"data":       items,
+		"candidates": candidates,
 	})
 }
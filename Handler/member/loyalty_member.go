@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-go-project/loyalty"
+	"my-go-project/models"
+	"my-go-project/paging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /users/points?user_id=5
+// แต้มสะสมคงเหลือ ระดับสมาชิก สิทธิประโยชน์ และแต้มที่ต้องสะสมอีกเพื่อเลื่อนระดับ
+func GetPoints(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	account, err := loyalty.Get(db, uint(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   account,
+		"tiers":  loyalty.Tiers(db),
+	})
+}
+
+// GET /users/points/history?user_id=5&limit=50&cursor=...
+// ประวัติการได้/ใช้แต้ม (ใหม่สุดก่อน)
+func PointsHistory(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM point_transactions WHERE user_id = ?", userID).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := "SELECT * FROM point_transactions WHERE user_id = ?"
+	args := []interface{}{userID}
+	if cond, condArgs := page.After("ptx_id", "ptx_id", true); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY ptx_id DESC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var rows []models.PointTransaction
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].PTxID}.Encode()
+	}
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/limits"
+	"my-go-project/market"
+	"my-go-project/numquery"
+	"my-go-project/paging"
+	"my-go-project/wallet"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// marketError แปลง error ของตลาดขายต่อเป็น response
+func marketError(c *gin.Context, err error) {
+	var limitErr *limits.Error
+	switch {
+	case errors.Is(err, market.ErrListingMissing):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, market.ErrNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "code": limitErr.Code, "message": limitErr.Message, "detail": limitErr})
+	case errors.Is(err, market.ErrPriceTooHigh), errors.Is(err, market.ErrBuyOwnListing), errors.Is(err, wallet.ErrInsufficientFunds):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, market.ErrNotListable), errors.Is(err, market.ErrAlreadyListed),
+		errors.Is(err, market.ErrPastCutoff), errors.Is(err, market.ErrListingClosed):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+	}
+}
+
+func listingIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("listing_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid listing_id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GET /market/listings?number=89$&draw_id=3&limit=100&cursor=...
+// ประกาศขายต่อที่ยังเปิดอยู่ (ที่เลยเวลาปิดตลาดแล้วไม่แสดง แม้ตัวยกเลิกเบื้องหลังยังไม่ได้ทำงาน)
+func ListMarketListings(c *gin.Context, db *gorm.DB) {
+	type Row struct {
+		ListingID   uint       `json:"listing_id"`
+		PDID        uint       `json:"pd_id"`
+		LottoNumber string     `json:"lotto_number"`
+		FacePrice   float64    `json:"face_price"`
+		Price       float64    `json:"price"`
+		Seller      string     `json:"seller"`
+		DrawID      *uint      `json:"draw_id"`
+		DrawDate    *time.Time `json:"draw_date"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+	page, err := paging.FromQuery(c, 100, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	from := `
+		FROM ticket_listings AS tl
+		JOIN purchases_detail AS pd ON pd.pd_id = tl.pd_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		JOIN users AS u ON u.user_id = tl.seller_id
+		LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+		WHERE tl.status = 'active'`
+	cutoff, args := market.BeforeCutoffSQL(db, time.Now())
+	from += " AND " + cutoff
+	if number := c.Query("number"); number != "" {
+		query, err := numquery.Parse(number, "l.")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		from += " AND " + query.Where
+		args = append(args, query.Args...)
+	}
+	if drawID, err := strconv.ParseUint(c.Query("draw_id"), 10, 64); err == nil && drawID > 0 {
+		from += " AND l.draw_id = ?"
+		args = append(args, drawID)
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*)"+from, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if cond, condArgs := page.After("tl.price", "tl.listing_id", false); cond != "" {
+		from += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql := `
+		SELECT tl.listing_id, tl.pd_id, l.lotto_number, l.price AS face_price, tl.price,
+		       u.username AS seller, l.draw_id, d.draw_date, tl.created_at` + from + `
+		ORDER BY tl.price ASC, tl.listing_id ASC LIMIT ?`
+	args = append(args, page.Limit+1)
+
+	var rows []Row
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		last := rows[n-1]
+		next = paging.Cursor{Value: strconv.FormatFloat(last.Price, 'f', -1, 64), ID: last.ListingID}.Encode()
+	}
+
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+type CreateListingRequest struct {
+	UserID uint    `json:"user_id" binding:"required"`
+	PDID   uint    `json:"pd_id"   binding:"required"`
+	Price  float64 `json:"price"   binding:"required,gt=0"`
+}
+
+// POST /market/listings
+// ประกาศขายต่อสลากที่ตัวเองถือ (ราคาไม่เกินเพดานที่ admin ตั้ง)
+func CreateMarketListing(c *gin.Context, db *gorm.DB) {
+	var req CreateListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var listingID uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		l, err := market.Create(tx, req.UserID, req.PDID, req.Price, time.Now())
+		if err != nil {
+			return err
+		}
+		listingID = l.ListingID
+		return nil
+	})
+	if err != nil {
+		marketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"listing_id": listingID,
+	})
+}
+
+type BuyListingRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// POST /market/listings/:listing_id/buy
+// ซื้อสลากจากประกาศขายต่อ
+func BuyMarketListing(c *gin.Context, db *gorm.DB) {
+	listingID, ok := listingIDParam(c)
+	if !ok {
+		return
+	}
+	var req BuyListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	// market.Buy ตรวจเวลาปิดตลาดของใบนั้นเอง ประกาศที่เลยเวลาจะได้ ErrPastCutoff
+	listing, err := market.Buy(db, req.UserID, listingID, time.Now())
+	if err != nil {
+		marketError(c, err)
+		return
+	}
+
+	var balance float64
+	db.Raw("SELECT wallet FROM users WHERE user_id = ?", req.UserID).Scan(&balance)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ซื้อสลากเรียบร้อยแล้ว",
+		"data":    listing,
+		"wallet":  balance,
+	})
+}
+
+// DELETE /market/listings/:listing_id?user_id=5
+// ผู้ขายยกเลิกประกาศ
+func CancelMarketListing(c *gin.Context, db *gorm.DB) {
+	listingID, ok := listingIDParam(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	if err := market.Cancel(db, uint(userID), listingID, time.Now()); err != nil {
+		marketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ยกเลิกประกาศขายแล้ว",
+	})
+}
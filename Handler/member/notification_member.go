@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/paging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MarkReadRequest struct {
+	UserID          uint   `json:"user_id" binding:"required"`
+	NotificationIDs []uint `json:"notification_ids"` // ว่าง = อ่านทั้งหมด
+}
+
+// GET /notifications?user_id=5&unread=1&limit=20&cursor=
+// กล่องข้อความแจ้งเตือน ใหม่สุดก่อน (unread=1 = เฉพาะที่ยังไม่อ่าน)
+func ListNotifications(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	page, err := paging.FromQuery(c, 20, 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	where := "user_id = ?"
+	args := []interface{}{userID}
+	if c.Query("unread") == "1" || c.Query("unread") == "true" {
+		where += " AND read_at IS NULL"
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM notifications WHERE "+where, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := "SELECT * FROM notifications WHERE " + where
+	if cond, condArgs := page.After("notification_id", "notification_id", true); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY notification_id DESC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var rows []models.Notification
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	unread, err := notify.UnreadCount(db, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].NotificationID}.Encode()
+	}
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, gin.H{"unread": unread})
+}
+
+// GET /notifications/unread-count?user_id=5
+// จำนวนข้อความที่ยังไม่อ่าน (สำหรับตัวเลขบนไอคอนกระดิ่ง)
+func UnreadNotificationCount(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	unread, err := notify.UnreadCount(db, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"unread": unread,
+	})
+}
+
+// POST /notifications/read
+// ทำเครื่องหมายว่าอ่านแล้ว (ไม่ส่ง notification_ids = อ่านทั้งหมด)
+func MarkNotificationsRead(c *gin.Context, db *gorm.DB) {
+	var req MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request"})
+		return
+	}
+
+	marked, err := notify.MarkRead(db, req.UserID, req.NotificationIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	unread, err := notify.UnreadCount(db, req.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"marked": marked,
+		"unread": unread,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/pricing"
+	"my-go-project/promotion"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /pricing/preview?lotto_ids=1,2,3&user_id=5&promo_code=LUCKY10
+// ดูราคาขายจริงของสลากก่อนซื้อ (ราคาหน้าตั๋ว + กฎราคาที่ใช้ + ส่วนลดโปรโมชัน ถ้าส่ง user_id)
+// ราคาจริงคิดใหม่อีกครั้งตอนซื้อ
+func PricePreview(c *gin.Context, db *gorm.DB) {
+	var ids []uint
+	for _, s := range strings.Split(c.Query("lotto_ids"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid lotto_ids"})
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 || len(ids) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "lotto_ids is required (1-100 ids)"})
+		return
+	}
+
+	var lottos []models.Lotto
+	if err := db.Where("lotto_id IN ? AND status = ?", ids, "sell").Order("lotto_id ASC").Find(&lottos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	quotes, err := pricing.QuoteLottos(db, lottos, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	data := make([]pricing.Quote, 0, len(lottos))
+	var total, baseTotal float64
+	for _, l := range lottos {
+		q := quotes[l.LottoID]
+		data = append(data, q)
+		total += q.Price
+		baseTotal += q.BasePrice
+	}
+	notAvailable := []uint{}
+	for _, id := range ids {
+		if _, ok := quotes[id]; !ok {
+			notAvailable = append(notAvailable, id)
+		}
+	}
+
+	resp := gin.H{
+		"status":        "success",
+		"data":          data,
+		"subtotal":      total,
+		"base_total":    baseTotal,
+		"discount":      0.0,
+		"total_price":   total,
+		"not_available": notAvailable, // ขายไปแล้ว/ไม่มีอยู่
+	}
+
+	// ส่วนลดโปรโมชัน (ต้องรู้ผู้ซื้อเพื่อนับจำนวนครั้งที่ใช้ไปแล้ว)
+	if uid, _ := strconv.ParseUint(c.Query("user_id"), 10, 64); uid > 0 && len(data) > 0 {
+		prices := make([]float64, 0, len(data))
+		for _, q := range data {
+			prices = append(prices, q.Price)
+		}
+		tx := db.Begin()
+		promo, err := promotion.Apply(tx, uint(uid), c.Query("promo_code"), prices, time.Now())
+		tx.Rollback() // แค่ดูราคา ไม่บันทึกอะไร
+		var promoErr *promotion.Error
+		switch {
+		case errors.As(err, &promoErr):
+			resp["promo_error"] = promoErr
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		case promo != nil:
+			resp["promotion"] = promo
+			resp["discount"] = promo.Discount
+			resp["total_price"] = math.Round((total-promo.Discount)*100) / 100
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
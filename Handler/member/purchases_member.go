@@ -3,11 +3,19 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
 
-	"my-go-project/models"
+	"my-go-project/agent"
+	"my-go-project/limits"
+	"my-go-project/live"
+	"my-go-project/loyalty"
+	"my-go-project/notify"
+	"my-go-project/paging"
+	"my-go-project/promotion"
+	"my-go-project/purchase"
+	"my-go-project/syndicate"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,159 +23,139 @@ import (
 
 // ---------- Request Models ----------
 type BuyRequest struct {
-	UserID      uint     `json:"user_id"  binding:"required"`
-	LottoIDs    []uint   `json:"lotto_ids" binding:"required,min=1"`
-	ClientTotal *float64 `json:"client_total,omitempty"` // (optional) ส่งมาเทียบได้ แต่เซิร์ฟเวอร์คำนวณเองเสมอ
+	UserID      uint        `json:"user_id"  binding:"required"`
+	LottoIDs    []uint      `json:"lotto_ids"`                        // เลือกใบเอง
+	Numbers     []BuyNumber `json:"numbers,omitempty" binding:"dive"` // หรือซื้อตามเลข + จำนวนใบ
+	ClientTotal *float64    `json:"client_total,omitempty"`           // (optional) ส่งมาเทียบได้ แต่เซิร์ฟเวอร์คำนวณเองเสมอ
+	SyndicateID *uint       `json:"syndicate_id,omitempty"`           // (optional) ซื้อในนามกลุ่ม จ่ายจากเงินกองกลาง
+	PromoCode   string      `json:"promo_code,omitempty"`             // (optional) โค้ดส่วนลด
+	Points      int         `json:"points,omitempty" binding:"min=0"` // (optional) ใช้แต้มสะสมจ่ายบางส่วน
 }
 
-// ---------- ซื้อจริง (INSERT ทั้งบิล) ----------
-var errNotAvailable = errors.New("some tickets are not available")
+// ซื้อเลขเดียวกันหลายใบ (หลายชุด)
+type BuyNumber struct {
+	LottoNumber string `json:"lotto_number" binding:"required"`
+	Quantity    int    `json:"quantity"     binding:"required,min=1"`
+}
 
+// ---------- ซื้อจริง (INSERT ทั้งบิล) ----------
+// ตรรกะการซื้ออยู่ที่ purchase.Buy (ใช้ร่วมกับการซื้ออัตโนมัติ)
 func CreatePurchase(c *gin.Context, db *gorm.DB) {
 	// --- ส่วนของการรับและตรวจสอบ Input  ---
 	var req BuyRequest
-	if err := c.ShouldBindJSON(&req); err != nil || len(req.LottoIDs) == 0 {
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.LottoIDs) == 0 && len(req.Numbers) == 0) {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request"})
 		return
 	}
 
-	var user models.User
-	// ตรวจสอบว่ามีผู้ใช้นี้จริง
-	if err := db.Raw("SELECT * FROM users WHERE user_id = ?", req.UserID).Scan(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "user not found"})
-		return
+	buy := purchase.Request{UserID: req.UserID, LottoIDs: req.LottoIDs, SyndicateID: req.SyndicateID, PromoCode: req.PromoCode, Points: req.Points}
+	for _, n := range req.Numbers {
+		buy.Numbers = append(buy.Numbers, purchase.Number{LottoNumber: n.LottoNumber, Quantity: n.Quantity})
 	}
 
-	//ส่วนของการตัด ID ซ้ำ  ---
-	idset := map[uint]struct{}{}
-	uniq := make([]uint, 0, len(req.LottoIDs))
-	for _, id := range req.LottoIDs {
-		if id == 0 {
-			continue
-		}
-		if _, ok := idset[id]; !ok {
-			idset[id] = struct{}{}
-			uniq = append(uniq, id)
-		}
-	}
-	if len(uniq) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "no lotto ids"})
-		return
+	res, err := purchase.Buy(db, buy)
+	if err == nil {
+		notifyPurchase(db, req.UserID, res)
 	}
+	respondPurchase(c, res, err)
+}
+
+// notifyPurchase แจ้งเจ้าของสลากว่าซื้อสำเร็จ (กล่องข้อความ + stream) และส่งใบเสร็จทางอีเมล (เบื้องหลัง)
+func notifyPurchase(db *gorm.DB, userID uint, res *purchase.Result) {
+	notify.PublishQuiet(db, userID, notify.TypePurchase,
+		"ซื้อสลากสำเร็จ",
+		fmt.Sprintf("ซื้อสลาก %d ใบ ยอดชำระ %.2f บาท (คำสั่งซื้อ #%d)", len(res.Items), res.TotalPrice, res.PurchaseID),
+		map[string]any{"purchase_id": res.PurchaseID})
+	live.TicketStatus(userID, "purchased", map[string]any{"purchase_id": res.PurchaseID, "items": res.Items})
+	live.InventoryChanged()
+	go emailReceipt(db, res.PurchaseID, userID)
+}
 
-	// --- ตัวแปรสำหรับตอบกลับ  ---
+// respondPurchase ตอบผลของ purchase.Buy (ใช้ร่วมกับการซื้อผ่านตัวแทน)
+func respondPurchase(c *gin.Context, res *purchase.Result, err error) {
+	// --- ส่วนของการตอบกลับ  ---
 	var (
-		purchaseID   uint
-		totalPrice   float64
-		respItems    []map[string]any //รายการสลากที่ซื้อสำเร็จ
-		notAvailable []uint           //id ที่ไม่สามารถซื้อได้ (ถูกคนอื่นซื้อแล้ว)
+		limitErr     *limits.Error
+		promoErr     *promotion.Error
+		redeemErr    *loyalty.RedeemLimitError
+		notEnough    *purchase.NotEnoughError
+		notAvailable *purchase.NotAvailableError
 	)
-
-	// ---------- เริ่ม Transaction ----------
-	//ใช้ Transaction เพื่อให้ทุกขั้นตอน (ตรวจสอบ, insert, update, หักเงิน) ต้องสำเร็จพร้อมกัน
-	//ถ้ามี error → rollback ย้อนกลับทั้งหมด
-	err := db.Transaction(func(tx *gorm.DB) error {
-
-		var lottos []models.Lotto
-		lockSQL := "SELECT * FROM lotto WHERE lotto_id IN (?) AND status = ? ORDER BY lotto_id ASC FOR UPDATE"
-		if err := tx.Raw(lockSQL, uniq, "sell").Scan(&lottos).Error; err != nil {
-			return err
-		}
-
-		// --- ตรวจสอบว่าสลากครบไหม  ---
-		if len(lottos) != len(uniq) {
-			found := make(map[uint]struct{}, len(lottos))
-			for _, l := range lottos {
-				found[l.LottoID] = struct{}{}
-			}
-			for _, id := range uniq {
-				if _, ok := found[id]; !ok {
-					notAvailable = append(notAvailable, id)
-				}
-			}
-			return errNotAvailable
-		}
-
-		// --- รวมราคา และเตรียม response ---
-		for _, l := range lottos {
-			totalPrice += l.Price
-			respItems = append(respItems, map[string]any{
-				"lotto_id":     l.LottoID,
-				"lotto_number": l.LottoNumber,
-				"price":        l.Price,
-			})
-		}
-		sort.Slice(respItems, func(i, j int) bool {
-			return respItems[i]["lotto_id"].(uint) < respItems[j]["lotto_id"].(uint)
+	switch {
+	case err == nil:
+	case errors.Is(err, purchase.ErrNoLotto):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "no lotto ids"})
+		return
+	case errors.Is(err, purchase.ErrUserNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "user not found"})
+		return
+	case errors.Is(err, purchase.ErrDrawClosed):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "draw closed"})
+		return
+	case errors.Is(err, syndicate.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, syndicate.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, syndicate.ErrInsufficientPool):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"code":    limitErr.Code,
+			"message": limitErr.Message,
+			"detail":  limitErr,
 		})
-
-		//  สร้างหัวบิล (ใช้ Create เพื่อให้ได้ PurchaseID กลับมา)
-		p := models.Purchase{
-			UserID:     req.UserID,
-			TotalPrice: totalPrice,
-		}
-		if err := tx.Create(&p).Error; err != nil {
-			return err
-		}
-		purchaseID = p.PurchaseID // GORM จะใส่ ID ที่เพิ่งสร้างให้เราอัตโนมัติ
-
-		//  สร้างรายละเอียดบิล 
-		details := make([]models.PurchaseDetail, 0, len(lottos))
-		for _, l := range lottos {
-			details = append(details, models.PurchaseDetail{
-				PurchaseID: purchaseID,
-				LottoID:    l.LottoID,
-			})
-		}
-		if err := tx.Create(&details).Error; err != nil {
-			return err
-		}
-
-		// เปลี่ยนสถานะลอตเตอรี่เป็น "sold"
-		updateStatusSQL := "UPDATE lotto SET status = ? WHERE lotto_id IN (?)"
-		if err := tx.Exec(updateStatusSQL, "sold", uniq).Error; err != nil {
-			return err
-		}
-
-		//  หักเงินในกระเป๋า (wallet)
-		if user.Wallet < totalPrice {
-			return errors.New("ยอดเงินในกระเป๋าไม่เพียงพอ")
-		}
-		updateWalletSQL := "UPDATE users SET wallet = wallet - ? WHERE user_id = ?"
-		if err := tx.Exec(updateWalletSQL, totalPrice, req.UserID).Error; err != nil {
-			return err
-		}
-
-		//  ดึงยอดเงินในกระเป๋าใหม่หลังหักเงิน
-		selectWalletSQL := "SELECT wallet FROM users WHERE user_id = ?"
-		if err := tx.Raw(selectWalletSQL, req.UserID).Scan(&user).Error; err != nil {
-			return err
-		}
-
-		return nil // Commit Transaction
-	})
-	// ---------- จบ Transaction ----------
-
-	// --- ส่วนของการตอบกลับ  ---
-	if errors.Is(err, errNotAvailable) {
+		return
+	case errors.Is(err, agent.ErrNotAgent), errors.Is(err, agent.ErrInactive):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, purchase.ErrPointsWithSyndicate), errors.Is(err, purchase.ErrAgentOptions), errors.Is(err, loyalty.ErrInsufficientPoints):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.As(err, &redeemErr):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": redeemErr.Error(), "detail": redeemErr})
+		return
+	case errors.As(err, &promoErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    promoErr.Code,
+			"message": promoErr.Message,
+		})
+		return
+	case errors.As(err, &notEnough):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":     "error",
+			"message":    "not enough copies of some numbers",
+			"not_enough": notEnough.Numbers,
+		})
+		return
+	case errors.As(err, &notAvailable):
 		c.JSON(http.StatusConflict, gin.H{
 			"status":        "error",
 			"message":       "some tickets are not available",
-			"not_available": notAvailable,
+			"not_available": notAvailable.LottoIDs,
 		})
 		return
-	}
-	if err != nil {
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":      "success",
-		"purchase_id": purchaseID,
-		"total_price": totalPrice,
-		"items":       respItems,
-		"wallet":      user.Wallet,
+		"status":        "success",
+		"purchase_id":   res.PurchaseID,
+		"subtotal":      res.Subtotal,
+		"discount":      res.Discount,
+		"promotion":     res.Promotion,
+		"points_used":   res.PointsUsed,
+		"points_value":  res.PointsValue,
+		"points_earned": res.PointsEarned,
+		"total_price":   res.TotalPrice,
+		"items":         res.Items,
+		"wallet":        res.Wallet,
 	})
 }
 
@@ -187,38 +175,64 @@ func ListPurchasedLottosByUser(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
+	page, err := paging.FromQuery(c, 100, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
 	// กำหนด struct สำหรับรับข้อมูล
 	type Row struct {
-		LottoID   uint   `json:"lotto_id"`
-		LottoName string `json:"lotto_name"`
-		Status    string `json:"status"`
+		PDID      uint    `json:"pd_id"`
+		LottoID   uint    `json:"lotto_id"`
+		LottoName string  `json:"lotto_name"`
+		Status    string  `json:"status"`
+		Price     float64 `json:"price"` // ราคาที่ซื้อจริง
 	}
 	var rows []Row
 
-	const sql = `
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM purchases_detail WHERE owner_id = ?", uid).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := `
 		SELECT
+			pd.pd_id,
 			l.lotto_id,
 			l.lotto_number AS lotto_name,
-			pd.status
+			pd.status,
+			COALESCE(pd.price, l.price) AS price
 		FROM
 			purchases_detail AS pd
-		JOIN purchases p ON p.purchase_id = pd.purchase_id
 		JOIN lotto l ON l.lotto_id = pd.lotto_id
 		WHERE
-			p.user_id = ?
+			pd.owner_id = ?`
+	args := []interface{}{uid}
+	if cond, condArgs := page.After("pd.pd_id", "pd.pd_id", false); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += `
 		ORDER BY
-			pd.pd_id ASC`
+			pd.pd_id ASC
+		LIMIT ?`
+	args = append(args, page.Limit+1)
 
 	// Execute คำสั่ง SQL และ Scan ผลลัพธ์ลงใน slice `rows`
-	if err := db.Raw(sql, uid).Scan(&rows).Error; err != nil {
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].PDID}.Encode()
+	}
+
 	// --- ส่วนของการตอบกลับ  ---
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"total":  len(rows),
-		"data":   rows,
-	})
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
 }
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/mailer"
+	"my-go-project/models"
+	"my-go-project/ticket"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// แถวสลากที่ใช้สร้างใบเสร็จ/รูปสลาก
+type ticketRow struct {
+	PDID        uint
+	PurchaseID  uint
+	VerifyCode  string
+	UserID      uint
+	LottoNumber string
+	Price       float64
+	DrawID      *uint
+	DrawDate    *time.Time
+}
+
+const ticketRowSQL = `
+	SELECT
+		pd.pd_id,
+		pd.purchase_id,
+		COALESCE(pd.verify_code, '') AS verify_code, -- รหัสที่เซ็นไว้ตอนซื้อ
+		p.user_id,
+		l.lotto_number,
+		COALESCE(pd.price, l.price) AS price, -- ราคาที่ขายจริง
+		l.draw_id,
+		d.draw_date
+	FROM purchases_detail AS pd
+	JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+	JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+	LEFT JOIN draws AS d ON d.draw_id = l.draw_id`
+
+func (r ticketRow) info() ticket.Info {
+	return ticket.Info{
+		PDID:        r.PDID,
+		LottoNumber: r.LottoNumber,
+		DrawDate:    r.DrawDate,
+		Price:       r.Price,
+		Code:        r.VerifyCode,
+	}
+}
+
+// GET /purchases/:purchase_id/receipt?user_id=5
+// ดาวน์โหลดใบเสร็จการซื้อเป็น PDF
+func DownloadReceipt(c *gin.Context, db *gorm.DB) {
+	purchaseID, err := strconv.ParseUint(c.Param("purchase_id"), 10, 64)
+	if err != nil || purchaseID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid purchase_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	receipt, err := loadReceipt(db, uint(purchaseID), uint(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "purchase not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	pdf, err := ticket.RenderReceiptPDF(*receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to render receipt: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%d.pdf"`, receipt.PurchaseID))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// loadReceipt ข้อมูลใบเสร็จของการซื้อที่ผู้ใช้เป็นเจ้าของ (ไม่พบ = gorm.ErrRecordNotFound)
+func loadReceipt(db *gorm.DB, purchaseID, userID uint) (*ticket.Receipt, error) {
+	var purchase models.Purchase
+	result := db.Raw("SELECT * FROM purchases WHERE purchase_id = ? AND user_id = ?", purchaseID, userID).Scan(&purchase)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var user models.User
+	if err := db.Raw("SELECT username, email FROM users WHERE user_id = ?", userID).Scan(&user).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []ticketRow
+	if err := db.Raw(ticketRowSQL+" WHERE pd.purchase_id = ? ORDER BY pd.pd_id ASC", purchaseID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	receipt := ticket.Receipt{
+		PurchaseID:  purchase.PurchaseID,
+		Username:    user.Username,
+		Email:       user.Email,
+		CreatedAt:   purchase.CreatedAt,
+		TotalPrice:  purchase.TotalPrice,
+		Discount:    purchase.Discount,
+		PointsUsed:  purchase.PointsUsed,
+		PointsValue: purchase.PointsValue,
+	}
+	// ใบเสร็จใช้ฟอนต์ภาษาอังกฤษ จึงแสดงโค้ดแทนชื่อโปร
+	if purchase.PromotionID != nil {
+		var promo models.Promotion
+		if err := db.Raw("SELECT code FROM promotions WHERE promotion_id = ?", *purchase.PromotionID).Scan(&promo).Error; err != nil {
+			return nil, err
+		}
+		receipt.Promotion = fmt.Sprintf("promotion #%d", *purchase.PromotionID)
+		if promo.Code != nil {
+			receipt.Promotion = *promo.Code
+		}
+	}
+	for _, r := range rows {
+		receipt.Items = append(receipt.Items, r.info())
+	}
+	return &receipt, nil
+}
+
+// emailReceipt ส่งใบเสร็จทางอีเมล (แนบ PDF) ให้เจ้าของคำสั่งซื้อ
+func emailReceipt(db *gorm.DB, purchaseID, userID uint) {
+	if !mailer.Enabled() {
+		return
+	}
+	receipt, err := loadReceipt(db, purchaseID, userID)
+	if err != nil {
+		log.Printf("mailer: failed to load receipt %d: %v", purchaseID, err)
+		return
+	}
+	pdf, err := ticket.RenderReceiptPDF(*receipt)
+	if err != nil {
+		log.Printf("mailer: failed to render receipt %d: %v", purchaseID, err)
+		return
+	}
+
+	data := &mailer.ReceiptData{
+		Username:    receipt.Username,
+		PurchaseID:  receipt.PurchaseID,
+		CreatedAt:   receipt.CreatedAt,
+		Subtotal:    receipt.TotalPrice + receipt.Discount + receipt.PointsValue,
+		Discount:    receipt.Discount,
+		Promotion:   receipt.Promotion,
+		PointsValue: receipt.PointsValue,
+		Total:       receipt.TotalPrice,
+	}
+	for _, it := range receipt.Items {
+		data.Items = append(data.Items, mailer.ReceiptItem{LottoNumber: it.LottoNumber, DrawDate: it.DrawDate, Price: it.Price})
+	}
+	mailer.ToUserQuiet(db, userID, mailer.TemplateReceipt, data, mailer.Attachment{
+		Name:        fmt.Sprintf("receipt-%d.pdf", receipt.PurchaseID),
+		ContentType: "application/pdf",
+		Data:        pdf,
+	})
+}
+
+// GET /tickets/:pd_id/image?user_id=5
+// ดาวน์โหลดรูปสลาก 1 ใบเป็น PNG
+func DownloadTicketImage(c *gin.Context, db *gorm.DB) {
+	pdID, err := strconv.ParseUint(c.Param("pd_id"), 10, 64)
+	if err != nil || pdID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid pd_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	var row ticketRow
+	result := db.Raw(ticketRowSQL+" WHERE pd.pd_id = ? AND pd.owner_id = ? LIMIT 1", pdID, userID).Scan(&row)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "ticket not found"})
+		return
+	}
+
+	img, err := ticket.RenderPNG(row.info())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "failed to render ticket: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d.png"`, row.PDID))
+	c.Data(http.StatusOK, "image/png", img)
+}
@@ -2,103 +2,140 @@ package handlers
 
 import (
 	"net/http"
-	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"my-go-project/models"
+	"my-go-project/numquery"
+	"my-go-project/paging"
+	"my-go-project/sampler"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// GET /lotto/search?q=^1 89$ !4&status=sell&limit=200&cursor=
+// q ใช้ภาษาค้นเลขของ numquery (number= เป็นชื่อเดิม ยังใช้ได้)
 func SearchLottoByNumber(c *gin.Context, db *gorm.DB) {
-	
-	numberQuery := c.Query("number")
+
+	input := c.Query("q")
+	if input == "" {
+		input = c.Query("number")
+	}
 	status := c.Query("status")
-	limitStr := c.DefaultQuery("limit", "200")
 
-	limit, _ := strconv.Atoi(limitStr)
-	if limit <= 0 || limit > 1000 {
-		limit = 200
+	page, err := paging.FromQuery(c, 200, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
 	}
 
-	isDigits := regexp.MustCompile(`^\d{1,6}$`).MatchString
-	if !isDigits(numberQuery) {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "number ต้องเป็นตัวเลข 1-6 หลัก"})
+	query, err := numquery.Parse(input, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	var args []interface{}
 
-	sql := "SELECT * FROM lotto"
-
 	var whereClauses []string // เก็บเงื่อนไขแต่ละอัน
 
-	// เพิ่มเงื่อนไขการค้นหาด้วย `number` 
-	whereClauses = append(whereClauses, "lotto_number LIKE ?")
-	args = append(args, "%"+numberQuery+"%")
+	// เงื่อนไขจากคำค้นเลข
+	whereClauses = append(whereClauses, query.Where)
+	args = append(args, query.Args...)
 
 	// เพิ่มเงื่อนไขการค้นหาด้วย `status` (ถ้ามี)
 	if status == "sell" || status == "sold" {
-		whereClauses = append(whereClauses, "LOWER(TRIM(status)) = ?")
+		whereClauses = append(whereClauses, "status = ?")
 		args = append(args, status)
 	}
 
+	where := " WHERE " + strings.Join(whereClauses, " AND ")
 
-	if len(whereClauses) > 0 {
-		sql += " WHERE " + strings.Join(whereClauses, " AND ")
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM lotto"+where, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
 	}
 
-	sql += " ORDER BY lotto_id ASC LIMIT ?"
-	args = append(args, limit)
+	sql := "SELECT * FROM lotto" + where
+	if cond, condArgs := page.After("lotto_number", "lotto_id", false); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY lotto_number ASC, lotto_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
 
-	
 	var items []models.Lotto
 	if err := db.Raw(sql, args...).Scan(&items).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
+	n, more := page.Trim(len(items))
+	items = items[:n]
+
+	// จำนวนใบที่เหลือของแต่ละเลข (เลขเดียวกันขายได้หลายชุด)
+	type Copies struct {
+		LottoNumber string `json:"lotto_number"`
+		Available   int    `json:"available"`
+		Total       int    `json:"total"`
+	}
+	copies := []Copies{}
+	if len(items) > 0 {
+		seen := make(map[string]struct{}, len(items))
+		numbers := make([]string, 0, len(items))
+		for _, it := range items {
+			if _, ok := seen[it.LottoNumber]; !ok {
+				seen[it.LottoNumber] = struct{}{}
+				numbers = append(numbers, it.LottoNumber)
+			}
+		}
+		const copiesSQL = `
+			SELECT lotto_number, SUM(status = 'sell') AS available, COUNT(*) AS total
+			FROM lotto
+			WHERE lotto_number IN ?
+			GROUP BY lotto_number
+			ORDER BY lotto_number ASC`
+		if err := db.Raw(copiesSQL, numbers).Scan(&copies).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"count":  len(items),
-		"data":   items,
+	next := ""
+	if more {
+		last := items[n-1]
+		next = paging.Cursor{Value: last.LottoNumber, ID: last.LottoID}.Encode()
+	}
+	paging.Respond(c, items, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, gin.H{
+		"copies": copies,
 	})
 }
 
 func RandomLotto(c *gin.Context, db *gorm.DB) {
 	sellOnly := c.DefaultQuery("sell_only", "true") // กำหนดค่าเริ่มต้นเป็น true
 
-
+	var where string
 	var args []interface{}
-	sql := "SELECT * FROM lotto"
-
 	if sellOnly == "true" {
-		sql += " WHERE LOWER(TRIM(status)) = ?"
-		args = append(args, "sell")
+		// เฉพาะใบที่ซื้อได้จริง (ไม่ติดจอง ไม่ได้แบ่งให้ตัวแทน และงวดยังเปิดขาย)
+		where, args = sampler.Buyable(time.Now())
 	}
 
-	//  เพิ่มส่วนท้ายของ Query เพื่อสุ่มและจำกัดแค่ 1 แถว
-	sql += " ORDER BY RAND() LIMIT 1"
-
-
-	var item models.Lotto
-	if err := db.Raw(sql, args...).Scan(&item).Error; err != nil {
-		// การจัดการ Error ยังคงเหมือนเดิม
-		if err == gorm.ErrRecordNotFound {
-			// ถ้าไม่พบข้อมูลเลย ให้ส่งค่า data เป็น null กลับไป
-			c.JSON(http.StatusOK, gin.H{"status": "success", "data": nil})
-			return
-		}
-		// หากเกิด Error อื่นๆ
+	// สุ่ม 1 ใบผ่าน index (ไม่ใช้ ORDER BY RAND() ที่ต้องเรียงทั้งตาราง)
+	items, err := sampler.Lottos(db, 1, where, args...)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
-
+	if len(items) == 0 {
+		// ถ้าไม่พบข้อมูลเลย ให้ส่งค่า data เป็น null กลับไป
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": nil})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   item,
+		"data":   items[0],
 	})
 }
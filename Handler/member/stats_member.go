@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"my-go-project/stats"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /stats/numbers?top=10
+// เลขร้อน/เลขเย็นจากผลรางวัลย้อนหลัง (เลขท้าย 2 ตัว, 3 ตัว และเลขแต่ละหลักของรางวัลที่ 1)
+func NumberStats(c *gin.Context, db *gorm.DB) {
+	top, _ := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if top <= 0 || top > 100 {
+		top = 10
+	}
+
+	report, err := stats.Get(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"draws":        report.Draws,
+			"last_draw_at": report.LastDrawAt,
+			"last2": stats.Table{
+				Hot:  stats.Top(report.Last2.Hot, top),
+				Cold: stats.Top(report.Last2.Cold, top),
+			},
+			"last3": stats.Table{
+				Hot:  stats.Top(report.Last3.Hot, top),
+				Cold: stats.Top(report.Last3.Cold, top),
+			},
+			"positions":   report.Positions,
+			"computed_at": report.ComputedAt,
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"my-go-project/models"
+	"my-go-project/paging"
+	"my-go-project/subscription"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /subscriptions?user_id=5&limit=50&cursor=
+// รายการเลขที่ตั้งซื้ออัตโนมัติ
+func ListSubscriptions(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM subscriptions WHERE user_id = ? AND active = ?", userID, true).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := "SELECT * FROM subscriptions WHERE user_id = ? AND active = ?"
+	args := []interface{}{userID, true}
+	if cond, condArgs := page.After("subscription_id", "subscription_id", false); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY subscription_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var subs []models.Subscription
+	if err := db.Raw(sql, args...).Scan(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(subs))
+	subs = subs[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: subs[n-1].SubscriptionID}.Encode()
+	}
+	paging.Respond(c, subs, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+type CreateSubscriptionRequest struct {
+	UserID   uint    `json:"user_id"   binding:"required"`
+	Pattern  string  `json:"pattern"   binding:"required"` // เช่น "123456" หรือ "xxxx89"
+	Quantity int     `json:"quantity"  binding:"required,min=1,max=100"`
+	MaxPrice float64 `json:"max_price" binding:"required,gt=0"` // ราคาสูงสุดต่อใบ
+}
+
+// POST /subscriptions
+// ตั้งซื้อเลข/รูปแบบเลขอัตโนมัติทุกงวด
+func CreateSubscription(c *gin.Context, db *gorm.DB) {
+	var req CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	pattern, ok := subscription.NormalizePattern(req.Pattern)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "pattern ต้องเป็น 6 หลัก ประกอบด้วย 0-9 หรือ x"})
+		return
+	}
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM users WHERE user_id = ?", req.UserID).Scan(&count).Error; err != nil || count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	sub := models.Subscription{
+		UserID:   req.UserID,
+		Pattern:  pattern,
+		Quantity: req.Quantity,
+		MaxPrice: req.MaxPrice,
+		Active:   true,
+	}
+	if err := db.Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   sub,
+	})
+}
+
+// DELETE /subscriptions/:subscription_id?user_id=5
+// ยกเลิกการซื้ออัตโนมัติ
+func CancelSubscription(c *gin.Context, db *gorm.DB) {
+	subID, err := strconv.ParseUint(c.Param("subscription_id"), 10, 64)
+	if err != nil || subID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid subscription_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	result := db.Exec("UPDATE subscriptions SET active = ? WHERE subscription_id = ? AND user_id = ?", false, subID, userID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ยกเลิกการซื้ออัตโนมัติแล้ว",
+	})
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"my-go-project/models"
+	"my-go-project/paging"
+	"my-go-project/syndicate"
+	"my-go-project/wallet"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func syndicateIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("syndicate_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid syndicate_id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+type CreateSyndicateRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Name   string `json:"name"    binding:"required,max=255"`
+}
+
+// POST /syndicates
+// สร้างกลุ่มซื้อสลาก ผู้สร้างเป็นสมาชิกอัตโนมัติ
+func CreateSyndicate(c *gin.Context, db *gorm.DB) {
+	var req CreateSyndicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	s := models.Syndicate{Name: strings.TrimSpace(req.Name), CreatorID: req.UserID}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.SyndicateMember{
+			SyndicateID: s.SyndicateID,
+			UserID:      req.UserID,
+			Status:      "joined",
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   s,
+	})
+}
+
+// GET /syndicates?user_id=5&limit=50&cursor=
+// กลุ่มที่ผู้ใช้เป็นสมาชิกหรือได้รับคำเชิญ ใหม่สุดก่อน
+func ListSyndicates(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	type Row struct {
+		SyndicateID  uint    `json:"syndicate_id"`
+		Name         string  `json:"name"`
+		CreatorID    uint    `json:"creator_id"`
+		Pool         float64 `json:"pool"`
+		MemberStatus string  `json:"member_status"`
+		Contribution float64 `json:"contribution"`
+	}
+	const from = `
+		FROM syndicate_members AS sm
+		JOIN syndicates AS s ON s.syndicate_id = sm.syndicate_id
+		WHERE sm.user_id = ? AND sm.status <> 'declined'`
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*)"+from, userID).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := `
+		SELECT s.syndicate_id, s.name, s.creator_id, s.pool,
+		       sm.status AS member_status, sm.contribution` + from
+	args := []interface{}{userID}
+	if cond, condArgs := page.After("s.syndicate_id", "s.syndicate_id", true); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY s.syndicate_id DESC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var rows []Row
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].SyndicateID}.Encode()
+	}
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
+
+// GET /syndicates/:syndicate_id?user_id=5
+// รายละเอียดกลุ่ม: สมาชิก สัดส่วน และสลากที่กลุ่มถือ
+func GetSyndicate(c *gin.Context, db *gorm.DB) {
+	sid, ok := syndicateIDParam(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	isMember, err := syndicate.IsMember(db, sid, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": syndicate.ErrNotMember.Error()})
+		return
+	}
+
+	var s models.Syndicate
+	if err := db.Raw("SELECT * FROM syndicates WHERE syndicate_id = ?", sid).Scan(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	type Member struct {
+		UserID       uint    `json:"user_id"`
+		Username     string  `json:"username"`
+		Status       string  `json:"status"`
+		Contribution float64 `json:"contribution"`
+		SharePercent float64 `json:"share_percent"`
+	}
+	var members []Member
+	if err := db.Raw(`
+		SELECT sm.user_id, u.username, sm.status, sm.contribution
+		FROM syndicate_members AS sm
+		JOIN users AS u ON u.user_id = sm.user_id
+		WHERE sm.syndicate_id = ?
+		ORDER BY sm.contribution DESC, sm.user_id ASC`, sid).Scan(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	total := 0.0
+	for _, m := range members {
+		if m.Status == "joined" {
+			total += m.Contribution
+		}
+	}
+	for i := range members {
+		if members[i].Status == "joined" && total > 0 {
+			members[i].SharePercent = members[i].Contribution / total * 100
+		}
+	}
+
+	type Ticket struct {
+		PDID        uint   `json:"pd_id"`
+		LottoNumber string `json:"lotto_number"`
+		Status      string `json:"status"`
+		CashIn      string `json:"cash_in"`
+	}
+	var tickets []Ticket
+	if err := db.Raw(`
+		SELECT pd.pd_id, l.lotto_number, pd.status, pd.cash_in
+		FROM purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		WHERE p.syndicate_id = ?
+		ORDER BY pd.pd_id ASC`, sid).Scan(&tickets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"syndicate": s,
+			"members":   members,
+			"tickets":   tickets,
+		},
+	})
+}
+
+type InviteSyndicateRequest struct {
+	UserID  uint   `json:"user_id" binding:"required"` // ผู้สร้างกลุ่ม
+	Invitee string `json:"invitee" binding:"required"` // email หรือ username
+}
+
+// POST /syndicates/:syndicate_id/invite
+// ผู้สร้างกลุ่มเชิญสมาชิกด้วย email หรือ username
+func InviteSyndicateMember(c *gin.Context, db *gorm.DB) {
+	sid, ok := syndicateIDParam(c)
+	if !ok {
+		return
+	}
+	var req InviteSyndicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var s models.Syndicate
+	result := db.Raw("SELECT * FROM syndicates WHERE syndicate_id = ?", sid).Scan(&s)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": syndicate.ErrNotFound.Error()})
+		return
+	}
+	if s.CreatorID != req.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "only the creator can invite members"})
+		return
+	}
+
+	var invitee models.User
+	result = db.Raw("SELECT user_id, username FROM users WHERE email = ? OR username = ? LIMIT 1", req.Invitee, req.Invitee).Scan(&invitee)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "invitee not found"})
+		return
+	}
+
+	// เชิญซ้ำได้ถ้าเคยปฏิเสธ ส่วนคนที่เข้าร่วมแล้วไม่เปลี่ยน
+	if err := db.Exec(`
+		INSERT INTO syndicate_members (syndicate_id, user_id, status, contribution, created_at)
+		VALUES (?, ?, 'invited', 0, NOW())
+		ON DUPLICATE KEY UPDATE status = IF(status = 'declined', 'invited', status)`, sid, invitee.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ส่งคำเชิญให้ " + invitee.Username + " แล้ว",
+	})
+}
+
+type JoinSyndicateRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+	Accept bool `json:"accept"`
+}
+
+// POST /syndicates/:syndicate_id/join
+// ตอบรับ/ปฏิเสธคำเชิญ
+func JoinSyndicate(c *gin.Context, db *gorm.DB) {
+	sid, ok := syndicateIDParam(c)
+	if !ok {
+		return
+	}
+	var req JoinSyndicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	status := "declined"
+	if req.Accept {
+		status = "joined"
+	}
+	result := db.Exec("UPDATE syndicate_members SET status = ? WHERE syndicate_id = ? AND user_id = ? AND status = 'invited'", status, sid, req.UserID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "no pending invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"member_status": status,
+	})
+}
+
+type ContributeRequest struct {
+	UserID uint    `json:"user_id" binding:"required"`
+	Amount float64 `json:"amount"  binding:"required,gt=0"`
+}
+
+// POST /syndicates/:syndicate_id/contribute
+// โอนเงินจาก wallet เข้ากองกลางของกลุ่ม (เพิ่มสัดส่วนรางวัลของตัวเอง)
+func ContributeSyndicate(c *gin.Context, db *gorm.DB) {
+	sid, ok := syndicateIDParam(c)
+	if !ok {
+		return
+	}
+	var req ContributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return syndicate.Contribute(tx, sid, req.UserID, req.Amount)
+	})
+	switch {
+	case errors.Is(err, syndicate.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, wallet.ErrInsufficientFunds):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var pool float64
+	db.Raw("SELECT pool FROM syndicates WHERE syndicate_id = ?", sid).Scan(&pool)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "สมทบเงินเข้ากลุ่มเรียบร้อยแล้ว",
+		"pool":    pool,
+	})
+}
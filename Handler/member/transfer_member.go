@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/live"
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/paging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransferTicketRequest struct {
+	UserID uint   `json:"user_id" binding:"required"` // เจ้าของปัจจุบัน
+	To     string `json:"to"      binding:"required"` // email หรือ username ของผู้รับ
+}
+
+var (
+	errTicketNotOwned   = errors.New("you do not own this ticket")
+	errTicketSettled    = errors.New("ticket can no longer be transferred (draw settled or prize claimed)")
+	errTicketSyndicate  = errors.New("syndicate tickets cannot be transferred")
+	errTransferToSelf   = errors.New("cannot transfer a ticket to yourself")
+	errRecipientMissing = errors.New("recipient not found")
+	errTicketListed     = errors.New("ticket is listed on the marketplace, cancel the listing first")
+)
+
+// POST /tickets/:pd_id/transfer
+// โอน/ให้สลากที่ยังไม่ออกรางวัลแก่ผู้ใช้อื่น
+func TransferTicket(c *gin.Context, db *gorm.DB) {
+	pdID, err := strconv.ParseUint(c.Param("pd_id"), 10, 64)
+	if err != nil || pdID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid pd_id"})
+		return
+	}
+	var req TransferTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	var (
+		recipient   models.User
+		lottoNumber string
+		transfer    models.TicketTransfer
+	)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Raw("SELECT user_id, username FROM users WHERE email = ? OR username = ? LIMIT 1", req.To, req.To).Scan(&recipient)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRecipientMissing
+		}
+		if recipient.UserID == req.UserID {
+			return errTransferToSelf
+		}
+
+		// ล็อกสลากใบนี้ไว้ระหว่างโอน
+		var row struct {
+			OwnerID     *uint
+			Status      string
+			CashIn      string
+			SyndicateID *uint
+			LottoNumber string
+			DrawStatus  *string
+		}
+		result = tx.Raw(`
+			SELECT pd.owner_id, pd.status, pd.cash_in, p.syndicate_id, l.lotto_number, d.status AS draw_status
+			FROM purchases_detail AS pd
+			JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+			JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+			LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+			WHERE pd.pd_id = ?
+			FOR UPDATE`, pdID).Scan(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || row.OwnerID == nil || *row.OwnerID != req.UserID {
+			return errTicketNotOwned
+		}
+		if row.SyndicateID != nil {
+			return errTicketSyndicate
+		}
+		// ออกรางวัลแล้ว (ผลสลากไม่ใช่ "ยัง" หรืองวดปิดขาย/ประกาศผลแล้ว) หรือขึ้นเงินแล้ว ห้ามโอน
+		if row.Status != "ยัง" || row.CashIn == "ขึ้นเงิน" || (row.DrawStatus != nil && *row.DrawStatus != "open") {
+			return errTicketSettled
+		}
+		lottoNumber = row.LottoNumber
+
+		// สลากที่ประกาศขายต่ออยู่ ต้องยกเลิกประกาศก่อน
+		var listed int64
+		if err := tx.Raw("SELECT COUNT(*) FROM ticket_listings WHERE pd_id = ? AND status = 'active'", pdID).Scan(&listed).Error; err != nil {
+			return err
+		}
+		if listed > 0 {
+			return errTicketListed
+		}
+
+		if err := tx.Exec("UPDATE purchases_detail SET owner_id = ? WHERE pd_id = ?", recipient.UserID, pdID).Error; err != nil {
+			return err
+		}
+		transfer = models.TicketTransfer{PDID: uint(pdID), FromUserID: req.UserID, ToUserID: recipient.UserID}
+		return tx.Create(&transfer).Error
+	})
+
+	switch {
+	case errors.Is(err, errRecipientMissing):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, errTicketNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, errTransferToSelf), errors.Is(err, errTicketSyndicate):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	case errors.Is(err, errTicketSettled), errors.Is(err, errTicketListed):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	notify.PublishQuiet(db, recipient.UserID, notify.TypeTransfer,
+		"คุณได้รับสลาก",
+		fmt.Sprintf("คุณได้รับโอนสลากเลข %s", lottoNumber),
+		map[string]any{"pd_id": pdID, "from_user_id": req.UserID})
+	live.TicketStatus(req.UserID, "transferred", map[string]any{"pd_id": pdID, "to_user_id": recipient.UserID})
+	live.TicketStatus(recipient.UserID, "received", map[string]any{"pd_id": pdID, "from_user_id": req.UserID, "lotto_number": lottoNumber})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "โอนสลากให้ " + recipient.Username + " เรียบร้อยแล้ว",
+		"data":    transfer,
+	})
+}
+
+// GET /tickets/:pd_id/transfers?user_id=5&limit=50&cursor=
+// ประวัติการโอนของสลากใบนี้ (ดูได้เฉพาะเจ้าของปัจจุบันหรือผู้ที่เคยถือ)
+func ListTicketTransfers(c *gin.Context, db *gorm.DB) {
+	pdID, err := strconv.ParseUint(c.Param("pd_id"), 10, 64)
+	if err != nil || pdID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid pd_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+	page, err := paging.FromQuery(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var allowed int64
+	if err := db.Raw(`
+		SELECT COUNT(*) FROM purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		WHERE pd.pd_id = ? AND (pd.owner_id = ? OR p.user_id = ? OR EXISTS (
+			SELECT 1 FROM ticket_transfers AS t WHERE t.pd_id = pd.pd_id AND (t.from_user_id = ? OR t.to_user_id = ?)))`,
+		pdID, userID, userID, userID, userID).Scan(&allowed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if allowed == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": errTicketNotOwned.Error()})
+		return
+	}
+
+	type Row struct {
+		TransferID   uint      `json:"transfer_id"`
+		FromUsername string    `json:"from_username"`
+		ToUsername   string    `json:"to_username"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ticket_transfers WHERE pd_id = ?", pdID).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sql := `
+		SELECT t.transfer_id, fu.username AS from_username, tu.username AS to_username, t.created_at
+		FROM ticket_transfers AS t
+		JOIN users AS fu ON fu.user_id = t.from_user_id
+		JOIN users AS tu ON tu.user_id = t.to_user_id
+		WHERE t.pd_id = ?`
+	args := []interface{}{pdID}
+	if cond, condArgs := page.After("t.transfer_id", "t.transfer_id", false); cond != "" {
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	sql += " ORDER BY t.transfer_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var rows []Row
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n, more := page.Trim(len(rows))
+	rows = rows[:n]
+	next := ""
+	if more {
+		next = paging.Cursor{ID: rows[n-1].TransferID}.Encode()
+	}
+	paging.Respond(c, rows, paging.Page{Count: n, Total: total, Limit: page.Limit, NextCursor: next}, nil)
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"my-go-project/mailer"
 	"my-go-project/models"
+	"my-go-project/referral"
 	"net/http"
 	"strconv"
 
@@ -12,7 +15,7 @@ import (
 
 // RegisterHandler รับคำขอสมัครสมาชิก
 func RegisterHandler(c *gin.Context, db *gorm.DB) {
-	var json models.User
+	var json RegisterRequest
 	if err := c.ShouldBindJSON(&json); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -24,25 +27,84 @@ func RegisterHandler(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	
-	sql := "INSERT INTO users (username, email, password, wallet) VALUES (?, ?, ?, ?)"
-	result := db.Exec(sql, json.Username, json.Email, string(encryptedPassword), json.Wallet)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// สร้างผู้ใช้ + โค้ดชวนเพื่อน + ผูกกับผู้ชวน ใน transaction เดียว
+	var code string
+	var ref *models.Referral
+	var userID uint
+	err = db.Transaction(func(tx *gorm.DB) error {
+		sql := "INSERT INTO users (username, email, password, wallet, language) VALUES (?, ?, ?, ?, ?)"
+		if err := tx.Exec(sql, json.Username, json.Email, string(encryptedPassword), json.Wallet, mailer.NormalizeLang(json.Language)).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw("SELECT LAST_INSERT_ID()").Scan(&userID).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if code, err = referral.AssignCode(tx, userID); err != nil {
+			return err
+		}
+		if json.ReferralCode != "" {
+			ref, err = referral.Attach(tx, userID, json.Email, json.ReferralCode, json.DeviceID)
+		}
+		return err
+	})
+	if errors.Is(err, referral.ErrCodeNotFound) || errors.Is(err, referral.ErrSelfReferral) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "User creation failed: " + err.Error()})
 		return
 	}
 
-	if result.RowsAffected > 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "ok",
-			"message": "User successfully created",
-		})
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "User creation failed",
-		})
+	mailer.ToUserQuiet(db, userID, mailer.TemplateWelcome, &mailer.WelcomeData{Username: json.Username, ReferralCode: code})
+
+	resp := gin.H{
+		"status":        "ok",
+		"message":       "User successfully created",
+		"referral_code": code,
+	}
+	if ref != nil {
+		resp["referral_status"] = ref.Status // rejected = อีเมล/อุปกรณ์นี้เคยถูกชวนแล้ว ไม่ได้โบนัส
 	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RegisterRequest ข้อมูลสมัครสมาชิก (referral_code, device_id, language ไม่บังคับ)
+type RegisterRequest struct {
+	Username     string  `json:"username"`
+	Email        string  `json:"email"`
+	Password     string  `json:"password"`
+	Wallet       float64 `json:"wallet"`
+	ReferralCode string  `json:"referral_code"` // โค้ดชวนเพื่อนของผู้ชวน
+	DeviceID     string  `json:"device_id"`     // รหัสเครื่อง ใช้กันสมัครซ้ำเพื่อรับโบนัส
+	Language     string  `json:"language"`      // ภาษาของอีเมล th (ค่าเริ่มต้น) / en
+}
+
+// GET /users/referral?user_id=5
+// โค้ดชวนเพื่อนของผู้ใช้ และสรุปจำนวนเพื่อนที่ชวน/โบนัสที่ได้
+func ReferralSummary(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	summary, err := referral.Get(db, uint(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   summary,
+	})
 }
 
 // LoginHandler รับคำขอล็อกอิน 
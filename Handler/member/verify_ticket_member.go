@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"my-go-project/ticket"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ปิดบังชื่อผู้ใช้ เหลือตัวแรกกับตัวสุดท้าย เช่น "somchai" -> "s*****i"
+func maskName(s string) string {
+	r := []rune(s)
+	if len(r) <= 2 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[0]) + strings.Repeat("*", len(r)-2) + string(r[len(r)-1])
+}
+
+// ปิดบังอีเมล เช่น "somchai@gmail.com" -> "s***@gmail.com"
+func maskEmail(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return maskName(s)
+	}
+	return string([]rune(s)[0]) + "***" + s[at:]
+}
+
+// GET /tickets/verify?code=...
+// ตรวจสอบว่าสลากเป็นของจริงหรือไม่ (สาธารณะ ไม่ต้องล็อกอิน) สำหรับร้านค้าพาร์ทเนอร์
+func VerifyTicket(c *gin.Context, db *gorm.DB) {
+	code := strings.TrimSpace(c.Query("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "code is required"})
+		return
+	}
+
+	// 1. ตรวจลายเซ็นก่อน ไม่ต้องแตะ DB ถ้ารหัสถูกปลอม
+	claims, err := ticket.Verify(code)
+	if errors.Is(err, ticket.ErrNoSecret) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"status": "success", "genuine": false, "message": "รหัสสลากไม่ถูกต้อง"})
+		return
+	}
+
+	// 2. ตรวจว่ารหัสนี้ยังผูกกับสลากใบนั้นจริง
+	type Row struct {
+		PDID        uint
+		LottoNumber string
+		Status      string
+		CashIn      string
+		VerifyCode  *string
+		Username    string
+		Email       string
+		DrawID      *uint
+		DrawDate    *time.Time
+		DrawStatus  *string
+	}
+	var row Row
+	result := db.Raw(`
+		SELECT
+			pd.pd_id, l.lotto_number, pd.status, pd.cash_in, pd.verify_code,
+			u.username, u.email,
+			l.draw_id, d.draw_date, d.status AS draw_status
+		FROM purchases_detail AS pd
+		JOIN users AS u ON u.user_id = pd.owner_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+		WHERE pd.pd_id = ?
+		LIMIT 1`, claims.PDID).Scan(&row)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 || row.VerifyCode == nil || *row.VerifyCode != code || row.LottoNumber != claims.LottoNumber {
+		c.JSON(http.StatusOK, gin.H{"status": "success", "genuine": false, "message": "ไม่พบสลากที่ตรงกับรหัสนี้"})
+		return
+	}
+
+	// 3. ผลรางวัล (ถ้าถูก)
+	var prize gin.H
+	if row.Status == "ถูก" {
+		tier, money, err := prizeForNumber(db, row.LottoNumber, row.DrawID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		if tier > 0 {
+			prize = gin.H{"prize_tier": tier, "prize_money": money}
+		}
+	}
+
+	var draw gin.H
+	if row.DrawID != nil && row.DrawDate != nil {
+		draw = gin.H{
+			"draw_id":   *row.DrawID,
+			"draw_date": row.DrawDate.Format("2006-01-02"),
+			"status":    row.DrawStatus,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"genuine": true,
+		"data": gin.H{
+			"pd_id":        row.PDID,
+			"lotto_number": row.LottoNumber,
+			"owner": gin.H{
+				"username": maskName(row.Username),
+				"email":    maskEmail(row.Email),
+			},
+			"draw":    draw,
+			"result":  row.Status, // ยัง / ถูก / ไม่ถูก
+			"cash_in": row.CashIn, // ซื้อ / ขึ้นเงิน
+			"prize":   prize,
+		},
+	})
+}
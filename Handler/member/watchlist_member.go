@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/subscription"
+	"my-go-project/watch"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /watchlist?user_id=5
+// เลขที่ติดตามอยู่ และสลากที่ระบบจองให้ (ยังไม่หมดเวลา)
+func ListWatchlist(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	var watches []models.Watch
+	if err := db.Raw("SELECT * FROM watchlist WHERE user_id = ? AND active = ? ORDER BY watch_id ASC", userID, true).Scan(&watches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	type Reserved struct {
+		ReservationID uint      `json:"reservation_id"`
+		WatchID       *uint     `json:"watch_id"`
+		LottoID       uint      `json:"lotto_id"`
+		LottoNumber   string    `json:"lotto_number"`
+		Price         float64   `json:"price"`
+		ExpiresAt     time.Time `json:"expires_at"`
+	}
+	var reservations []Reserved
+	const reservedSQL = `
+		SELECT r.reservation_id, r.watch_id, r.lotto_id, l.lotto_number, l.price, r.expires_at
+		FROM lotto_reservations AS r
+		JOIN lotto AS l ON l.lotto_id = r.lotto_id
+		WHERE r.user_id = ? AND r.expires_at > ? AND l.status = 'sell'
+		ORDER BY r.expires_at ASC`
+	if err := db.Raw(reservedSQL, userID, time.Now()).Scan(&reservations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"total":        len(watches),
+		"data":         watches,
+		"reservations": reservations,
+	})
+}
+
+type AddWatchRequest struct {
+	UserID      uint   `json:"user_id"  binding:"required"`
+	Pattern     string `json:"pattern"  binding:"required"` // เช่น "123456" หรือ "xxxx89"
+	AutoReserve bool   `json:"auto_reserve"`
+}
+
+// POST /watchlist
+// ติดตามเลข/รูปแบบเลข แจ้งเตือนเมื่อมีสลากว่าง
+func AddWatch(c *gin.Context, db *gorm.DB) {
+	var req AddWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid request: " + err.Error()})
+		return
+	}
+
+	pattern, ok := subscription.NormalizePattern(req.Pattern)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "pattern ต้องเป็น 6 หลัก ประกอบด้วย 0-9 หรือ x"})
+		return
+	}
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM users WHERE user_id = ?", req.UserID).Scan(&count).Error; err != nil || count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+	if err := db.Raw("SELECT COUNT(*) FROM watchlist WHERE user_id = ? AND pattern = ? AND active = ?", req.UserID, pattern, true).Scan(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "pattern is already on the watchlist"})
+		return
+	}
+
+	w := models.Watch{
+		UserID:      req.UserID,
+		Pattern:     pattern,
+		AutoReserve: req.AutoReserve,
+		Active:      true,
+	}
+	if err := db.Create(&w).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   w,
+	})
+}
+
+// DELETE /watchlist/:watch_id?user_id=5
+// เลิกติดตามเลข
+func RemoveWatch(c *gin.Context, db *gorm.DB) {
+	watchID, err := strconv.ParseUint(c.Param("watch_id"), 10, 64)
+	if err != nil || watchID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid watch_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	if err := watch.Remove(db, uint(userID), uint(watchID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "watch not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "เลิกติดตามเลขแล้ว",
+	})
+}
+
+// DELETE /reservations/:reservation_id?user_id=5
+// ปล่อยสลากที่ระบบจองให้ (ผู้ติดตามคนอื่นจะได้รับแจ้ง)
+func CancelReservation(c *gin.Context, db *gorm.DB) {
+	reservationID, err := strconv.ParseUint(c.Param("reservation_id"), 10, 64)
+	if err != nil || reservationID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid reservation_id"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid user_id"})
+		return
+	}
+
+	if err := watch.Cancel(db, uint(userID), uint(reservationID)); err != nil {
+		if errors.Is(err, watch.ErrReservationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "ยกเลิกการจองแล้ว",
+	})
+}
@@ -0,0 +1,226 @@
+// Package agent ตัวแทนขาย: สลากที่แบ่งให้ตัวแทน, ค่าคอมมิชชันจากยอดขาย และสรุปยอดรายงวด
+//
+// ตัวแทนซื้อแทนลูกค้า (ลูกค้าเป็นเจ้าของสลาก ตัวแทนจ่ายเงินจาก wallet ของตัวเอง)
+// ค่าคอมมิชชันสะสมไว้ (accrued) แล้วจ่ายเข้า wallet ตอน admin ปิดยอดงวด
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/wallet"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusAccrued = "accrued"
+	StatusPaid    = "paid"
+)
+
+var (
+	ErrNotAgent     = errors.New("user is not an agent")
+	ErrInactive     = errors.New("agent account is inactive")
+	ErrInvalidRate  = errors.New("commission_rate must be between 0 and 100")
+	ErrNothingToPay = errors.New("no unpaid commission for this draw")
+)
+
+// NotAllocatedSQL เงื่อนไข WHERE ตัดสลากที่แบ่งให้ตัวแทนคนอื่น (ต้องใช้ชื่อตาราง lotto)
+// พารามิเตอร์: agent_id ของผู้ซื้อ (0 = ไม่ใช่ตัวแทน ตัดสลากที่แบ่งให้ตัวแทนทั้งหมด)
+const NotAllocatedSQL = `NOT EXISTS (
+	SELECT 1 FROM agent_allocations AS a
+	WHERE a.lotto_id = lotto.lotto_id AND a.agent_id <> ?)`
+
+// Lock อ่านและล็อกข้อมูลตัวแทน (ใช้ภายใน transaction ของการซื้อ)
+func Lock(tx *gorm.DB, agentID uint) (*models.Agent, error) {
+	var a models.Agent
+	found := tx.Raw(`
+		SELECT a.* FROM agents AS a
+		JOIN users AS u ON u.user_id = a.agent_id AND u.role = 'agent'
+		WHERE a.agent_id = ? FOR UPDATE`, agentID).Scan(&a)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, ErrNotAgent
+	}
+	if !a.Active {
+		return nil, ErrInactive
+	}
+	return &a, nil
+}
+
+// Upsert ตั้งผู้ใช้เป็นตัวแทน (หรือแก้อัตราค่าคอม/สถานะ)
+func Upsert(db *gorm.DB, userID uint, rate float64, active bool) (*models.Agent, error) {
+	if rate < 0 || rate > 100 {
+		return nil, ErrInvalidRate
+	}
+	a := models.Agent{AgentID: userID, CommissionRate: rate, Active: active}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("UPDATE users SET role = 'agent' WHERE user_id = ? AND role <> 'admin'", userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		var role string
+		if err := tx.Raw("SELECT role FROM users WHERE user_id = ?", userID).Scan(&role).Error; err != nil {
+			return err
+		}
+		if role != "agent" {
+			return ErrNotAgent // ไม่พบผู้ใช้ หรือเป็น admin
+		}
+		return tx.Exec(`
+			INSERT INTO agents (agent_id, commission_rate, active, created_at) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE commission_rate = VALUES(commission_rate), active = VALUES(active)`,
+			userID, rate, active, time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Allocate แบ่งสลากให้ตัวแทน เฉพาะใบที่ยังขายได้ ไม่มีคนจอง และยังไม่ได้แบ่งให้ใคร
+// คืน lotto_id ที่แบ่งได้ และใบที่แบ่งไม่ได้
+func Allocate(db *gorm.DB, agentID uint, lottoIDs []uint) (allocated, unavailable []uint, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Lock(tx, agentID); err != nil {
+			return err
+		}
+		if err := tx.Raw(`
+			SELECT lotto_id FROM lotto
+			WHERE lotto_id IN ? AND status = 'sell'
+			  AND NOT EXISTS (SELECT 1 FROM agent_allocations AS a WHERE a.lotto_id = lotto.lotto_id)
+			  AND NOT EXISTS (SELECT 1 FROM lotto_reservations AS r WHERE r.lotto_id = lotto.lotto_id AND r.expires_at > ?)
+			ORDER BY lotto_id ASC FOR UPDATE`, lottoIDs, time.Now()).Scan(&allocated).Error; err != nil {
+			return err
+		}
+		if len(allocated) == 0 {
+			return nil
+		}
+		rows := make([]models.AgentAllocation, 0, len(allocated))
+		for _, id := range allocated {
+			rows = append(rows, models.AgentAllocation{AgentID: agentID, LottoID: id})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	ok := make(map[uint]bool, len(allocated))
+	for _, id := range allocated {
+		ok[id] = true
+	}
+	for _, id := range lottoIDs {
+		if !ok[id] {
+			unavailable = append(unavailable, id)
+		}
+	}
+	return allocated, unavailable, nil
+}
+
+// Release คืนสลากที่แบ่งให้ตัวแทนกลับไปขายทั่วไป (lottoIDs ว่าง = คืนทั้งหมด)
+func Release(db *gorm.DB, agentID uint, lottoIDs []uint) (int64, error) {
+	q := db.Where("agent_id = ?", agentID)
+	if len(lottoIDs) > 0 {
+		q = q.Where("lotto_id IN ?", lottoIDs)
+	}
+	result := q.Delete(&models.AgentAllocation{})
+	return result.RowsAffected, result.Error
+}
+
+// Accrue บันทึกค่าคอมมิชชันของบิลที่ตัวแทนขาย แยกตามงวด (salesByDraw key 0 = ไม่ระบุงวด)
+// เรียกภายใน transaction ของการซื้อ
+func Accrue(tx *gorm.DB, a *models.Agent, purchaseID, customerID uint, salesByDraw map[uint]float64, ticketsByDraw map[uint]int) error {
+	for drawID, sales := range salesByDraw {
+		c := models.AgentCommission{
+			AgentID:    a.AgentID,
+			PurchaseID: purchaseID,
+			CustomerID: customerID,
+			Tickets:    ticketsByDraw[drawID],
+			Sales:      sales,
+			Rate:       a.CommissionRate,
+			Amount:     math.Round(sales*a.CommissionRate) / 100,
+			Status:     StatusAccrued,
+		}
+		if drawID != 0 {
+			id := drawID
+			c.DrawID = &id
+		}
+		if err := tx.Create(&c).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Statement สรุปยอดขายและค่าคอมของตัวแทนใน 1 งวด
+type Statement struct {
+	AgentID    uint       `json:"agent_id"`
+	DrawID     *uint      `json:"draw_id"`
+	DrawDate   *time.Time `json:"draw_date"`
+	Purchases  int        `json:"purchases"`
+	Customers  int        `json:"customers"`
+	Tickets    int        `json:"tickets"`
+	Sales      float64    `json:"sales"`
+	Commission float64    `json:"commission"`
+	Unpaid     float64    `json:"unpaid"`
+	Paid       float64    `json:"paid"`
+}
+
+// Statements สรุปรายงวดของตัวแทน (drawID nil = ทุกงวด ใหม่สุดก่อน)
+func Statements(db *gorm.DB, agentID uint, drawID *uint) ([]Statement, error) {
+	sql := `
+		SELECT c.agent_id, c.draw_id, d.draw_date,
+		       COUNT(DISTINCT c.purchase_id) AS purchases,
+		       COUNT(DISTINCT c.customer_id) AS customers,
+		       SUM(c.tickets) AS tickets,
+		       SUM(c.sales) AS sales,
+		       SUM(c.amount) AS commission,
+		       SUM(CASE WHEN c.status = 'accrued' THEN c.amount ELSE 0 END) AS unpaid,
+		       SUM(CASE WHEN c.status = 'paid' THEN c.amount ELSE 0 END) AS paid
+		FROM agent_commissions AS c
+		LEFT JOIN draws AS d ON d.draw_id = c.draw_id
+		WHERE c.agent_id = ?`
+	args := []interface{}{agentID}
+	if drawID != nil {
+		sql += " AND c.draw_id = ?"
+		args = append(args, *drawID)
+	}
+	sql += " GROUP BY c.agent_id, c.draw_id, d.draw_date ORDER BY d.draw_date DESC, c.draw_id DESC"
+
+	statements := []Statement{}
+	err := db.Raw(sql, args...).Scan(&statements).Error
+	return statements, err
+}
+
+// Settle จ่ายค่าคอมที่ค้างของงวด drawID เข้า wallet ตัวแทน (drawID 0 = ค่าคอมของสลากที่ไม่ผูกงวด)
+func Settle(db *gorm.DB, agentID, drawID uint) (float64, error) {
+	var draw *uint
+	note := "ค่าคอมมิชชันสลากที่ไม่ระบุงวด"
+	if drawID != 0 {
+		draw = &drawID
+		note = fmt.Sprintf("ค่าคอมมิชชันงวด #%d", drawID)
+	}
+	var amount float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Raw("SELECT commission_id FROM agent_commissions WHERE agent_id = ? AND draw_id <=> ? AND status = ? FOR UPDATE",
+			agentID, draw, StatusAccrued).Scan(&ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return ErrNothingToPay
+		}
+		if err := tx.Raw("SELECT COALESCE(SUM(amount), 0) FROM agent_commissions WHERE commission_id IN ?", ids).Scan(&amount).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE agent_commissions SET status = ?, paid_at = ? WHERE commission_id IN ?", StatusPaid, time.Now(), ids).Error; err != nil {
+			return err
+		}
+		return wallet.Credit(tx, agentID, amount, wallet.TypeAgentCommission, draw, note)
+	})
+	return amount, err
+}
@@ -0,0 +1,257 @@
+// Package auspicious คำนวณเลขมงคลจากวันเกิด วันประจำตัว ชื่อ และความฝัน
+// แล้วจับคู่กับสลากที่ยังขายได้ เรียงตามความใกล้เคียง
+//
+// ตารางเลขประจำวัน ค่าตัวอักษร (เลขศาสตร์) และตำราเลขเด็ดจากความฝัน อยู่ใน data.json
+package auspicious
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//go:embed data.json
+var rawData []byte
+
+type table struct {
+	Days    map[string]int      `json:"days"`
+	Letters map[string]string   `json:"letters"`
+	Dreams  map[string][]string `json:"dreams"`
+}
+
+var (
+	days        map[string]int
+	dreams      map[string][]string
+	dreamKeys   []string // เรียงคำยาวก่อน ("งูใหญ่" ก่อน "งู")
+	letterValue = map[rune]int{}
+)
+
+func init() {
+	var t table
+	if err := json.Unmarshal(rawData, &t); err != nil {
+		panic("auspicious: invalid data.json: " + err.Error())
+	}
+	days, dreams = t.Days, t.Dreams
+	for v, letters := range t.Letters {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			panic("auspicious: invalid letter value " + v)
+		}
+		for _, r := range letters {
+			letterValue[r] = n
+		}
+	}
+	for k := range dreams {
+		dreamKeys = append(dreamKeys, k)
+	}
+	sort.Slice(dreamKeys, func(i, j int) bool {
+		if li, lj := len([]rune(dreamKeys[i])), len([]rune(dreamKeys[j])); li != lj {
+			return li > lj
+		}
+		return dreamKeys[i] < dreamKeys[j]
+	})
+}
+
+// Input ข้อมูลที่ใช้หาเลขมงคล (ใส่อย่างน้อย 1 อย่าง ถ้าไม่ใส่เลยใช้วันนี้)
+type Input struct {
+	BirthDate *time.Time
+	Day       string // วันประจำตัว เช่น "จันทร์" หรือ "monday"
+	Name      string
+	Dream     string // ข้อความเล่าความฝัน หาคำในตำราเลขเด็ดจากข้อความนี้
+	Now       time.Time
+}
+
+// Source ที่มาของเลข (แสดงให้ผู้ใช้เห็นว่าได้เลขนี้มาจากไหน)
+type Source struct {
+	From    string   `json:"from"`
+	Detail  string   `json:"detail"`
+	Numbers []string `json:"numbers"`
+}
+
+// Candidates เลขที่คำนวณได้ เรียงจากคะแนนมากไปน้อย
+type Candidates struct {
+	Digits  []int    `json:"digits"`
+	Pairs   []string `json:"pairs"`
+	Sources []Source `json:"sources"`
+
+	digitScore [10]float64
+	pairScore  map[string]float64
+}
+
+// UnknownDayError วันประจำตัวที่ไม่รู้จัก
+type UnknownDayError struct{ Day string }
+
+func (e *UnknownDayError) Error() string { return fmt.Sprintf("unknown day %q", e.Day) }
+
+// น้ำหนักของแต่ละที่มา (ความฝันมีน้ำหนักมากสุดตามความเชื่อ)
+const (
+	weightDream = 3.0
+	weightDay   = 2.0
+	weightBirth = 1.5
+	weightName  = 1.5
+)
+
+// Compute คำนวณเลขมงคลจาก Input
+func Compute(in Input) (*Candidates, error) {
+	c := &Candidates{pairScore: map[string]float64{}}
+
+	if in.Day != "" {
+		d, ok := days[strings.ToLower(strings.TrimSpace(in.Day))]
+		if !ok {
+			return nil, &UnknownDayError{Day: in.Day}
+		}
+		c.add("day", in.Day, weightDay, strconv.Itoa(d))
+	}
+
+	if in.BirthDate != nil {
+		b := *in.BirthDate
+		be := b.Year() + 543 // พ.ศ.
+		c.add("birth_date", b.Format("2006-01-02"), weightBirth,
+			fmt.Sprintf("%02d", b.Day()),
+			fmt.Sprintf("%02d", int(b.Month())),
+			fmt.Sprintf("%02d", be%100),
+			strconv.Itoa(reduce(digitSum(b.Format("20060102")))),
+		)
+		if in.Day == "" {
+			d := weekdayNumber(b.Weekday())
+			c.add("day", "วันเกิด "+thaiWeekday(b.Weekday()), weightDay, strconv.Itoa(d))
+		}
+	}
+
+	if name := strings.TrimSpace(in.Name); name != "" {
+		total := 0
+		for _, r := range name {
+			total += letterValue[unicode.ToUpper(r)]
+		}
+		if total > 0 {
+			nums := []string{strconv.Itoa(reduce(total))}
+			if total >= 10 {
+				nums = append(nums, fmt.Sprintf("%02d", total%100))
+			}
+			c.add("name", fmt.Sprintf("%s (ผลรวม %d)", name, total), weightName, nums...)
+		}
+	}
+
+	if dream := strings.TrimSpace(in.Dream); dream != "" {
+		rest := strings.ToLower(dream)
+		for _, k := range dreamKeys {
+			if strings.Contains(rest, k) {
+				c.add("dream", k, weightDream, dreams[k]...)
+				rest = strings.ReplaceAll(rest, k, " ")
+			}
+		}
+	}
+
+	// ไม่มีข้อมูลที่ใช้ได้เลย → เลขมงคลประจำวันนี้
+	if len(c.Sources) == 0 {
+		now := in.Now
+		c.add("today", now.Format("2006-01-02")+" วัน"+thaiWeekday(now.Weekday()), weightDay,
+			strconv.Itoa(weekdayNumber(now.Weekday())),
+			fmt.Sprintf("%02d", now.Day()),
+		)
+	}
+
+	c.rank()
+	return c, nil
+}
+
+// add ให้คะแนนเลข: เลขหลักเดียวได้คะแนนเต็ม เลข 2 หลักได้คะแนนคู่ และแต่ละหลักได้ครึ่งหนึ่ง
+func (c *Candidates) add(from, detail string, weight float64, numbers ...string) {
+	c.Sources = append(c.Sources, Source{From: from, Detail: detail, Numbers: numbers})
+	for _, n := range numbers {
+		switch len(n) {
+		case 1:
+			c.digitScore[n[0]-'0'] += weight
+		case 2:
+			c.pairScore[n] += weight
+			c.digitScore[n[0]-'0'] += weight / 2
+			c.digitScore[n[1]-'0'] += weight / 2
+		}
+	}
+}
+
+func (c *Candidates) rank() {
+	for d := 0; d < 10; d++ {
+		if c.digitScore[d] > 0 {
+			c.Digits = append(c.Digits, d)
+		}
+	}
+	sort.SliceStable(c.Digits, func(i, j int) bool {
+		return c.digitScore[c.Digits[i]] > c.digitScore[c.Digits[j]]
+	})
+
+	// เติมคู่เลขจากหลักที่ได้คะแนนสูงสุด 3 หลัก (ถ้ายังไม่มีคู่นั้น)
+	top := c.Digits
+	if len(top) > 3 {
+		top = top[:3]
+	}
+	for _, a := range top {
+		for _, b := range top {
+			p := fmt.Sprintf("%d%d", a, b)
+			if _, ok := c.pairScore[p]; !ok {
+				c.pairScore[p] = (c.digitScore[a] + c.digitScore[b]) / 8
+			}
+		}
+	}
+
+	for p := range c.pairScore {
+		c.Pairs = append(c.Pairs, p)
+	}
+	sort.Slice(c.Pairs, func(i, j int) bool {
+		si, sj := c.pairScore[c.Pairs[i]], c.pairScore[c.Pairs[j]]
+		if si != sj {
+			return si > sj
+		}
+		return c.Pairs[i] < c.Pairs[j]
+	})
+}
+
+// Score ความใกล้เคียงของเลขสลากกับเลขมงคล
+// เลขท้าย 2 ตัวตรงคู่มงคลได้คะแนนมากสุด รองลงมาคือคู่เลขที่อยู่ติดกันตรงไหนก็ได้ และเลขแต่ละหลัก
+func (c *Candidates) Score(number string) float64 {
+	if len(number) < 2 {
+		return 0
+	}
+	score := 3 * c.pairScore[number[len(number)-2:]]
+	for i := 0; i+2 <= len(number)-2; i++ {
+		score += c.pairScore[number[i:i+2]]
+	}
+	for i := 0; i < len(number); i++ {
+		if d := number[i]; d >= '0' && d <= '9' {
+			score += c.digitScore[d-'0'] / 4
+		}
+	}
+	return score
+}
+
+func digitSum(s string) int {
+	sum := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sum += int(r - '0')
+		}
+	}
+	return sum
+}
+
+// reduce รวมหลักจนเหลือหลักเดียว (เลขศาสตร์)
+func reduce(n int) int {
+	for n >= 10 {
+		n = digitSum(strconv.Itoa(n))
+	}
+	return n
+}
+
+// weekdayNumber เลขประจำวันเกิด อาทิตย์ = 1 ... เสาร์ = 7
+func weekdayNumber(d time.Weekday) int {
+	return int(d) + 1
+}
+
+func thaiWeekday(d time.Weekday) string {
+	return [...]string{"อาทิตย์", "จันทร์", "อังคาร", "พุธ", "พฤหัสบดี", "ศุกร์", "เสาร์"}[d]
+}
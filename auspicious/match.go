@@ -0,0 +1,130 @@
+package auspicious
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"my-go-project/sampler"
+
+	"gorm.io/gorm"
+)
+
+// Match สลากที่ยังซื้อได้ 1 เลขของ 1 งวด พร้อมคะแนนความใกล้เคียง
+type Match struct {
+	LottoID     uint    `json:"lotto_id"` // ใบแรกของเลขนี้ในงวดนี้ที่ยังซื้อได้
+	LottoNumber string  `json:"lotto_number"`
+	Price       float64 `json:"price"` // ราคาของใบ lotto_id
+	DrawID      *uint   `json:"draw_id"`
+	Available   int     `json:"available"` // จำนวนใบที่ยังซื้อได้ของเลขนี้ในงวดนี้
+	Score       float64 `json:"score"`
+}
+
+// maxPairs จำนวนคู่เลขมงคลที่ใช้ค้นเลขท้าย / maxScan จำนวนแถวสูงสุดที่อ่านมาให้คะแนนต่อรอบ
+const (
+	maxPairs = 8
+	maxScan  = 500
+)
+
+// FindAvailable หาสลากที่ยังซื้อได้ที่ใกล้เคียงเลขมงคลที่สุดไม่เกิน limit รายการ (เลข + งวด)
+// ค้นจากเลขท้ายที่ตรงคู่มงคลก่อน (ใช้ index ของเลขกลับด้าน) ถ้ายังไม่พอจึงค้นเลขที่มีคู่มงคลอยู่ตรงไหนก็ได้
+// แต่ละรอบเรียงตามคู่มงคลที่ตรง (คู่ที่คะแนนสูงก่อน) ก่อนตัดที่ maxScan
+func FindAvailable(db *gorm.DB, c *Candidates, limit int) ([]Match, error) {
+	pairs := c.Pairs
+	if len(pairs) > maxPairs {
+		pairs = pairs[:maxPairs]
+	}
+	if len(pairs) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	type key struct {
+		draw   uint // 0 = ไม่ระบุงวด
+		number string
+	}
+	found := map[key]*Match{}
+	buyable, buyableArgs := sampler.Buyable(time.Now())
+
+	// conds[i] = เงื่อนไขของคู่ pairs[i] (1 placeholder ต่อเงื่อนไข)
+	collect := func(conds []string, args []interface{}) error {
+		where := "(" + strings.Join(conds, " OR ") + ")"
+		rank := "CASE"
+		for i, cond := range conds {
+			rank += fmt.Sprintf(" WHEN %s THEN %d", cond, i)
+		}
+		rank += fmt.Sprintf(" ELSE %d END", len(conds))
+
+		var rows []Match
+		sql := `
+			SELECT g.lotto_id, g.lotto_number, l.price, g.draw_id, g.available
+			FROM (
+				SELECT MIN(lotto_id) AS lotto_id, lotto_number, draw_id, COUNT(*) AS available, MIN(` + rank + `) AS match_rank
+				FROM lotto
+				WHERE ` + buyable + ` AND ` + where + `
+				GROUP BY draw_id, lotto_number
+				ORDER BY match_rank ASC, lotto_number ASC, draw_id ASC
+				LIMIT ?
+			) AS g
+			JOIN lotto AS l ON l.lotto_id = g.lotto_id`
+		sqlArgs := append([]interface{}{}, args...) // rank
+		sqlArgs = append(sqlArgs, buyableArgs...)
+		sqlArgs = append(sqlArgs, args...) // where
+		sqlArgs = append(sqlArgs, maxScan)
+		if err := db.Raw(sql, sqlArgs...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			k := key{number: rows[i].LottoNumber}
+			if rows[i].DrawID != nil {
+				k.draw = *rows[i].DrawID
+			}
+			if _, ok := found[k]; !ok {
+				rows[i].Score = c.Score(rows[i].LottoNumber)
+				found[k] = &rows[i]
+			}
+		}
+		return nil
+	}
+
+	// 1) เลขท้าย 2 ตัวตรงคู่มงคล
+	var conds []string
+	var args []interface{}
+	for _, p := range pairs {
+		conds = append(conds, "lotto_number_rev LIKE ?")
+		args = append(args, string([]byte{p[1], p[0]})+"%")
+	}
+	if err := collect(conds, args); err != nil {
+		return nil, err
+	}
+
+	// 2) ยังไม่พอ → มีคู่มงคลอยู่ตรงไหนก็ได้
+	if len(found) < limit {
+		conds, args = nil, nil
+		for _, p := range pairs {
+			conds = append(conds, "lotto_number LIKE ?")
+			args = append(args, "%"+p+"%")
+		}
+		if err := collect(conds, args); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]Match, 0, len(found))
+	for _, m := range found {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if out[i].LottoNumber != out[j].LottoNumber {
+			return out[i].LottoNumber < out[j].LottoNumber
+		}
+		return out[i].LottoID < out[j].LottoID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"strings"
+
+	"my-go-project/models"
+	"my-go-project/referral"
+	"my-go-project/ticket"
+
+	"gorm.io/gorm"
+)
+
+// Migrate สร้างตารางใหม่ และเพิ่มคอลัมน์ที่ยังไม่มีในตารางเดิม
+// ตารางเดิม (users, lotto, purchases, purchases_detail, rewards) ถูกสร้างไว้แล้วใน DB
+// จึงเพิ่มเฉพาะคอลัมน์ ไม่ให้ AutoMigrate ไปแก้ชนิดคอลัมน์ที่มีอยู่
+func Migrate(db *gorm.DB) error {
+	// ตารางใหม่
+	if err := db.AutoMigrate(
+		&models.Draw{},
+		&models.Setting{},
+		&models.UserLimit{},
+		&models.Notification{},
+		&models.Subscription{},
+		&models.SubscriptionRun{},
+		&models.WalletTransaction{},
+		&models.Syndicate{},
+		&models.SyndicateMember{},
+		&models.SyndicateShare{},
+		&models.TicketTransfer{},
+		&models.Listing{},
+		&models.Watch{},
+		&models.Reservation{},
+		&models.DrawResult{},
+		&models.PriceRule{},
+		&models.Promotion{},
+		&models.PromotionUse{},
+		&models.Referral{},
+		&models.PointTransaction{},
+		&models.Agent{},
+		&models.AgentAllocation{},
+		&models.AgentCommission{},
+		&models.DeviceToken{},
+	); err != nil {
+		return err
+	}
+
+	// คอลัมน์ใหม่ในตารางเดิม
+	columns := []struct {
+		model any
+		field string
+	}{
+		{&models.User{}, "ExcludedUntil"},
+		{&models.Lotto{}, "DrawID"},
+		{&models.Lotto{}, "SetNo"},
+		{&models.Purchase{}, "CreatedAt"},
+		{&models.Purchase{}, "SyndicateID"},
+		{&models.PurchaseDetail{}, "VerifyCode"},
+		{&models.PurchaseDetail{}, "OwnerID"},
+		{&models.PurchaseDetail{}, "Price"},
+		{&models.Purchase{}, "Discount"},
+		{&models.Purchase{}, "PromotionID"},
+		{&models.User{}, "ReferralCode"},
+		{&models.User{}, "Points"},
+		{&models.User{}, "PointsEarned"},
+		{&models.User{}, "Language"},
+		{&models.Purchase{}, "PointsUsed"},
+		{&models.Purchase{}, "PointsValue"},
+		{&models.Purchase{}, "AgentID"},
+	}
+	for _, col := range columns {
+		if db.Migrator().HasColumn(col.model, col.field) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(col.model, col.field); err != nil {
+			return err
+		}
+	}
+
+	// คอลัมน์เดิมที่ต้องเปลี่ยนชนิด (ตรวจจากชนิดปัจจุบันว่ายังไม่มีค่า want)
+	altered := []struct {
+		model  any
+		field  string
+		column string
+		want   string
+	}{
+		{&models.User{}, "Role", "role", "'agent'"},
+	}
+	for _, col := range altered {
+		types, err := db.Migrator().ColumnTypes(col.model)
+		if err != nil {
+			return err
+		}
+		current := ""
+		for _, t := range types {
+			if t.Name() == col.column {
+				current, _ = t.ColumnType()
+			}
+		}
+		if strings.Contains(current, col.want) {
+			continue
+		}
+		if err := db.Migrator().AlterColumn(col.model, col.field); err != nil {
+			return err
+		}
+	}
+
+	// คอลัมน์ที่ MySQL คำนวณจากเลขสลาก (ใช้ค้นหาเลขท้าย/ผลรวมหลัก ผ่าน index)
+	generated := []struct {
+		model  any
+		column string
+		ddl    string
+	}{
+		{&models.Lotto{}, "lotto_number_rev", "ALTER TABLE lotto ADD COLUMN lotto_number_rev VARCHAR(6) GENERATED ALWAYS AS (REVERSE(lotto_number)) STORED"},
+		{&models.Lotto{}, "digit_sum", `ALTER TABLE lotto ADD COLUMN digit_sum SMALLINT GENERATED ALWAYS AS (
+			ASCII(SUBSTRING(lotto_number, 1, 1)) + ASCII(SUBSTRING(lotto_number, 2, 1)) + ASCII(SUBSTRING(lotto_number, 3, 1)) +
+			ASCII(SUBSTRING(lotto_number, 4, 1)) + ASCII(SUBSTRING(lotto_number, 5, 1)) + ASCII(SUBSTRING(lotto_number, 6, 1)) - 6 * 48) STORED`},
+	}
+	for _, col := range generated {
+		if db.Migrator().HasColumn(col.model, col.column) {
+			continue
+		}
+		if err := db.Exec(col.ddl).Error; err != nil {
+			return err
+		}
+	}
+
+	// index ของคอลัมน์ใหม่ (ชื่อตาม tag ใน models)
+	indexes := []struct {
+		model any
+		name  string
+	}{
+		{&models.PurchaseDetail{}, "idx_pd_verify_code"},
+		{&models.Lotto{}, "idx_lotto_draw_number_set"},
+		{&models.Purchase{}, "idx_purchases_syndicate_id"},
+		{&models.PurchaseDetail{}, "idx_purchases_detail_owner_id"},
+		{&models.Lotto{}, "idx_lotto_number"},
+		{&models.Lotto{}, "idx_lotto_number_rev"},
+		{&models.Lotto{}, "idx_lotto_digit_sum"},
+		{&models.Lotto{}, "idx_lotto_price"},
+		{&models.Lotto{}, "idx_lotto_status"},
+		{&models.Purchase{}, "idx_purchases_promotion_id"},
+		{&models.User{}, "idx_users_referral_code"},
+		{&models.Purchase{}, "idx_purchases_agent_id"},
+	}
+	for _, idx := range indexes {
+		if db.Migrator().HasIndex(idx.model, idx.name) {
+			continue
+		}
+		if err := db.Migrator().CreateIndex(idx.model, idx.name); err != nil {
+			return err
+		}
+	}
+
+	if err := backfillOwners(db); err != nil {
+		return err
+	}
+	if err := backfillDetailPrices(db); err != nil {
+		return err
+	}
+	if err := backfillSyndicateShares(db); err != nil {
+		return err
+	}
+	if err := backfillReferralCodes(db); err != nil {
+		return err
+	}
+	return backfillVerifyCodes(db)
+}
+
+// backfillOwners สลากที่ขายไปก่อนมีคอลัมน์ owner_id เจ้าของคือผู้ซื้อ
+func backfillOwners(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		SET pd.owner_id = p.user_id
+		WHERE pd.owner_id IS NULL`).Error
+}
+
+// backfillDetailPrices สลากที่ขายไปก่อนมีกฎราคา ขายตามราคาหน้าตั๋ว
+func backfillDetailPrices(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE purchases_detail AS pd
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		SET pd.price = l.price
+		WHERE pd.price IS NULL`).Error
+}
+
+// backfillSyndicateShares บิลกลุ่มที่ซื้อก่อนมีการบันทึกสัดส่วน ใช้ยอดสมทบปัจจุบันของสมาชิก
+func backfillSyndicateShares(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO syndicate_shares (purchase_id, user_id, contribution)
+		SELECT p.purchase_id, sm.user_id, sm.contribution
+		FROM purchases AS p
+		JOIN syndicate_members AS sm
+			ON sm.syndicate_id = p.syndicate_id AND sm.status = 'joined' AND sm.contribution > 0
+		WHERE p.syndicate_id IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM syndicate_shares AS ss WHERE ss.purchase_id = p.purchase_id)`).Error
+}
+
+// backfillReferralCodes ผู้ใช้ที่สมัครก่อนมีระบบชวนเพื่อนยังไม่มีโค้ด
+func backfillReferralCodes(db *gorm.DB) error {
+	var ids []uint
+	if err := db.Raw("SELECT user_id FROM users WHERE referral_code IS NULL").Scan(&ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := referral.AssignCode(db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillVerifyCodes ใส่รหัสยืนยันให้สลากที่ขายไปก่อนมีคอลัมน์ verify_code
+func backfillVerifyCodes(db *gorm.DB) error {
+	type row struct {
+		PDID        uint
+		LottoNumber string
+		DrawID      *uint
+	}
+	var rows []row
+	if err := db.Raw(`
+		SELECT pd.pd_id, l.lotto_number, l.draw_id
+		FROM purchases_detail AS pd
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		WHERE pd.verify_code IS NULL`).Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, r := range rows {
+		code, err := ticket.Code(r.PDID, r.LottoNumber, r.DrawID)
+		if err != nil {
+			return err
+		}
+		if err := db.Exec("UPDATE purchases_detail SET verify_code = ? WHERE pd_id = ?", code, r.PDID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Package generator สร้างเลขสลาก 6 หลักไม่ซ้ำกันตามกลยุทธ์ที่เลือก
+// และไม่ซ้ำกับเลขที่มีอยู่แล้วในงวด
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// Space จำนวนเลข 6 หลักทั้งหมด (000000-999999)
+const Space = 1_000_000
+
+// MaxCount จำนวนเลขสูงสุดที่สร้างได้ต่อครั้ง
+const MaxCount = 200_000
+
+// กลยุทธ์การสร้างเลข
+const (
+	StrategyRandom     = "random"     // สุ่มทั่วทั้งช่วง
+	StrategySequential = "sequential" // สุ่มเลือกช่วง แล้วเรียงเลขติดกันทีละช่วง (block)
+)
+
+var (
+	ErrInvalidCount    = fmt.Errorf("count must be between 1 and %d", MaxCount)
+	ErrUnknownStrategy = errors.New("strategy must be random or sequential")
+	ErrInvalidBlock    = errors.New("block_size must be between 1 and 10000")
+	ErrNotEnough       = errors.New("not enough free numbers for the requested count")
+	ErrCannotBalance   = errors.New("could not balance digits with the given exclusions, try fewer numbers or balance=false")
+)
+
+// PatternError รูปแบบเลขที่ต้องการตัดออกไม่ถูกต้อง
+type PatternError struct{ Pattern string }
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("invalid exclude pattern %q (use 1-5 digits or 6 characters of 0-9/x)", e.Pattern)
+}
+
+// Options ตัวเลือกการสร้างเลข
+type Options struct {
+	Count     int
+	Strategy  string   // random (ค่าเริ่มต้น) / sequential
+	BlockSize int      // ขนาดช่วงเลขติดกัน สำหรับ sequential (ค่าเริ่มต้น 100)
+	Exclude   []string // "4" = ไม่มีเลข 4 ติดอยู่เลย, "13" = ไม่มี 13 ติดกัน, "xxxx13" = ตรงหลัก
+	Balance   bool     // ให้แต่ละหลักมีเลข 0-9 จำนวนเท่า ๆ กัน (เฉพาะ random)
+}
+
+// Validate ตรวจตัวเลือกก่อนเริ่มสร้าง (Generate ตรวจซ้ำอีกครั้งอยู่แล้ว)
+func (opts Options) Validate() error {
+	if opts.Count <= 0 || opts.Count > MaxCount {
+		return ErrInvalidCount
+	}
+	switch opts.Strategy {
+	case "", StrategyRandom:
+	case StrategySequential:
+		if opts.BlockSize < 0 || opts.BlockSize > 10_000 {
+			return ErrInvalidBlock
+		}
+	default:
+		return ErrUnknownStrategy
+	}
+	_, _, err := compile(opts.Exclude)
+	return err
+}
+
+// Generate สร้างเลขตาม opts โดยไม่ใช้เลขที่ taken คืนค่า true
+func Generate(opts Options, taken func(n int) bool) ([]string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	excluded, banned, err := compile(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	free := func(n int) bool { return !taken(n) && !excluded(format(n)) }
+
+	var nums []int
+	switch opts.Strategy {
+	case "", StrategyRandom:
+		if opts.Balance {
+			nums, err = balanced(opts.Count, banned, free)
+		} else {
+			nums, err = random(opts.Count, free)
+		}
+	case StrategySequential:
+		size := opts.BlockSize
+		if size == 0 {
+			size = 100
+		}
+		if size < 1 || size > 10_000 {
+			return nil, ErrInvalidBlock
+		}
+		nums, err = sequential(opts.Count, size, free)
+	default:
+		return nil, ErrUnknownStrategy
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, len(nums))
+	for i, n := range nums {
+		out[i] = format(n)
+	}
+	return out, nil
+}
+
+func format(n int) string { return fmt.Sprintf("%06d", n) }
+
+// random สุ่มแบบตัดตัวซ้ำทิ้ง ถ้าต้องการเกินครึ่งของเลขที่ว่างจะไล่เลขว่างทั้งหมดแล้วสลับแทน
+func random(count int, free func(int) bool) ([]int, error) {
+	chosen := make(map[int]struct{}, count)
+	out := make([]int, 0, count)
+	for tries := 0; len(out) < count && tries < count*4; tries++ {
+		n := rand.IntN(Space)
+		if _, dup := chosen[n]; dup || !free(n) {
+			continue
+		}
+		chosen[n] = struct{}{}
+		out = append(out, n)
+	}
+	if len(out) == count {
+		return out, nil
+	}
+
+	// เลขว่างเหลือน้อย → สุ่มจากรายการเลขว่างที่เหลือทั้งหมด
+	var pool []int
+	for n := 0; n < Space; n++ {
+		if _, dup := chosen[n]; !dup && free(n) {
+			pool = append(pool, n)
+		}
+	}
+	need := count - len(out)
+	if len(pool) < need {
+		return nil, ErrNotEnough
+	}
+	for i := 0; i < need; i++ {
+		j := i + rand.IntN(len(pool)-i)
+		pool[i], pool[j] = pool[j], pool[i]
+		out = append(out, pool[i])
+	}
+	return out, nil
+}
+
+// sequential สุ่มลำดับของช่วงเลข แล้วเติมเลขว่างในแต่ละช่วงเรียงกันจนครบ
+func sequential(count, size int, free func(int) bool) ([]int, error) {
+	blocks := rand.Perm((Space + size - 1) / size)
+	out := make([]int, 0, count)
+	for _, b := range blocks {
+		for n := b * size; n < (b+1)*size && n < Space; n++ {
+			if free(n) {
+				out = append(out, n)
+				if len(out) == count {
+					return out, nil
+				}
+			}
+		}
+	}
+	return nil, ErrNotEnough
+}
+
+// balanced สร้างเลขที่แต่ละหลักมี 0-9 จำนวนเท่า ๆ กัน (ต่างกันไม่เกิน 1)
+// ไม่นับเลขที่ถูกตัดออกทั้งหลัก เช่น exclude "4" หรือ "xxxxx0"
+// สร้างทีละหลักเป็นสำรับที่สลับแล้ว ประกอบเป็นเลข จากนั้นแก้เลขที่ซ้ำ/ใช้ไม่ได้
+// โดยสลับหลักเดียวกันกับเลขอื่น (การสลับไม่ทำให้จำนวนของแต่ละหลักเปลี่ยน)
+func balanced(count int, banned [6][10]bool, free func(int) bool) ([]int, error) {
+	var digits [6][]int
+	for p := 0; p < 6; p++ {
+		var allowed []int
+		for d := 0; d < 10; d++ {
+			if !banned[p][d] {
+				allowed = append(allowed, d)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, ErrNotEnough
+		}
+		col := make([]int, count)
+		for i := range col {
+			col[i] = allowed[i%len(allowed)]
+		}
+		rand.Shuffle(count, func(i, j int) { col[i], col[j] = col[j], col[i] })
+		digits[p] = col
+	}
+	value := func(i int) int {
+		n := 0
+		for p := 0; p < 6; p++ {
+			n = n*10 + digits[p][i]
+		}
+		return n
+	}
+
+	owner := make(map[int]int, count) // เลข -> แถวที่ใช้เลขนี้
+	var bad []int
+	for i := 0; i < count; i++ {
+		n := value(i)
+		if _, dup := owner[n]; dup || !free(n) {
+			bad = append(bad, i)
+			continue
+		}
+		owner[n] = i
+	}
+
+	owned := func(n int) bool { _, ok := owner[n]; return ok }
+	for tries := 0; len(bad) > 0; tries++ {
+		if tries > count*50+1000 {
+			return nil, ErrCannotBalance
+		}
+		i := bad[len(bad)-1]
+		if n := value(i); free(n) && !owned(n) { // ถูกแก้ไปแล้วระหว่างสลับกับแถวอื่น
+			owner[n] = i
+			bad = bad[:len(bad)-1]
+			continue
+		}
+
+		j, p := rand.IntN(count), rand.IntN(6)
+		if i == j || digits[p][i] == digits[p][j] {
+			continue
+		}
+		oldJ := value(j)
+		jValid := owned(oldJ) && owner[oldJ] == j
+		if jValid {
+			delete(owner, oldJ)
+		}
+		digits[p][i], digits[p][j] = digits[p][j], digits[p][i]
+		newI, newJ := value(i), value(j)
+
+		// แถว j ที่ใช้ได้อยู่แล้วต้องยังใช้ได้หลังสลับ ส่วนแถว i ยอมให้เปลี่ยนไปเรื่อย ๆ จนกว่าจะใช้ได้
+		// (เลขที่ผิดหลายจุด เช่น 113713 กับ exclude "13" แก้ด้วยการสลับครั้งเดียวไม่ได้)
+		if jValid && (newJ == newI || !free(newJ) || owned(newJ)) {
+			digits[p][i], digits[p][j] = digits[p][j], digits[p][i] // ย้อนกลับ
+			owner[oldJ] = j
+			continue
+		}
+		if jValid {
+			owner[newJ] = j
+		}
+		if free(newI) && !owned(newI) {
+			owner[newI] = i
+			bad = bad[:len(bad)-1]
+		}
+	}
+
+	out := make([]int, count)
+	for i := range out {
+		out[i] = value(i)
+	}
+	return out, nil
+}
+
+// compile แปลงรูปแบบที่ต้องการตัดออกเป็นฟังก์ชันตรวจเลข
+// banned[p][d] = เลข d ใช้ที่หลัก p ไม่ได้เลย (เช่น "4" ห้ามทุกหลัก, "xxxxx0" ห้าม 0 ที่หลักสุดท้าย)
+func compile(patterns []string) (func(string) bool, [6][10]bool, error) {
+	var banned [6][10]bool
+	type rule struct {
+		positional bool
+		text       string
+	}
+	var rules []rule
+	for _, p := range patterns {
+		switch {
+		case len(p) == 6 && isPattern(p):
+			rules = append(rules, rule{positional: true, text: p})
+			fixed, pos := 0, 0
+			for i := 0; i < 6; i++ {
+				if p[i] >= '0' && p[i] <= '9' {
+					fixed, pos = fixed+1, i
+				}
+			}
+			if fixed == 1 {
+				banned[pos][p[pos]-'0'] = true
+			}
+		case len(p) >= 1 && len(p) <= 5 && isDigits(p):
+			rules = append(rules, rule{text: p})
+			if len(p) == 1 {
+				for i := 0; i < 6; i++ {
+					banned[i][p[0]-'0'] = true
+				}
+			}
+		default:
+			return nil, banned, &PatternError{Pattern: p}
+		}
+	}
+	return func(n string) bool {
+		for _, r := range rules {
+			if r.positional && matchPositional(r.text, n) {
+				return true
+			}
+			if !r.positional && contains(n, r.text) {
+				return true
+			}
+		}
+		return false
+	}, banned, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isPattern(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= '0' && s[i] <= '9') && s[i] != 'x' && s[i] != 'X' && s[i] != '*' {
+			return false
+		}
+	}
+	return true
+}
+
+func matchPositional(pattern, n string) bool {
+	for i := 0; i < 6; i++ {
+		if c := pattern[i]; c >= '0' && c <= '9' && c != n[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(n, sub string) bool {
+	for i := 0; i+len(sub) <= len(n); i++ {
+		if n[i:i+len(sub)] == sub {
+			return true
+		}
+	}
+	return false
+}
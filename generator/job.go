@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// สถานะของงานสร้างสลาก
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Request งานสร้างสลากของงวด 1 ครั้ง
+type Request struct {
+	DrawID    *uint
+	Options   Options
+	Price     float64
+	Sets      int // จำนวนชุดต่อเลข
+	CreatedBy *uint
+	ChunkSize int // จำนวนแถว (เลข × ชุด) ต่อการ INSERT 1 ครั้ง ไม่เกิน MaxChunkRows
+}
+
+// Job ความคืบหน้าของงานสร้างสลาก (ดูได้ระหว่างทำงาน)
+type Job struct {
+	JobID      uint64     `json:"job_id"`
+	DrawID     *uint      `json:"draw_id"`
+	Requested  int        `json:"requested"` // จำนวนเลขที่ขอ
+	Generated  int        `json:"generated"` // จำนวนเลขที่สร้างได้
+	Inserted   int        `json:"inserted"`  // จำนวนเลขที่บันทึกแล้ว
+	Rows       int        `json:"rows"`      // จำนวนใบที่บันทึกแล้ว (เลข × ชุด)
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+const keepJobs = 20 // เก็บประวัติงานล่าสุดไว้กี่งาน
+
+// MaxChunkRows จำนวนแถวสูงสุดต่อ INSERT 1 ครั้ง (กันคำสั่งใหญ่เกินและ placeholder เกินขีดของ MySQL)
+const MaxChunkRows = 10000
+
+var (
+	runMu  sync.Mutex // สร้างทีละงาน เลขที่ตรวจว่าไม่ซ้ำจะได้ไม่ชนกับงานอื่น
+	jobsMu sync.Mutex
+	jobs   = map[uint64]*Job{}
+	nextID uint64
+)
+
+// Get ดูความคืบหน้าของงาน
+func Get(id uint64) (Job, bool) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	j, ok := jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+func update(j *Job, fn func(j *Job)) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	fn(j)
+}
+
+// Start เริ่มงานสร้างสลากเบื้องหลัง คืน Job สำหรับติดตามความคืบหน้า
+// afterInsert ถูกเรียกเมื่อบันทึกครบ พร้อม lotto_id ทั้งหมดที่เพิ่ม (เช่น ซื้ออัตโนมัติ/แจ้งผู้ติดตามเลข)
+func Start(db *gorm.DB, req Request, afterInsert func(lottoIDs []uint)) (Job, error) {
+	if err := req.Options.Validate(); err != nil {
+		return Job{}, err
+	}
+	if req.Price <= 0 {
+		req.Price = 80
+	}
+	if req.Sets <= 0 {
+		req.Sets = 1
+	}
+	if req.ChunkSize <= 0 {
+		req.ChunkSize = 1000
+	}
+	if req.ChunkSize > MaxChunkRows {
+		req.ChunkSize = MaxChunkRows
+	}
+
+	jobsMu.Lock()
+	nextID++
+	j := &Job{JobID: nextID, DrawID: req.DrawID, Requested: req.Options.Count, Status: JobRunning, StartedAt: time.Now()}
+	jobs[j.JobID] = j
+	for id := range jobs {
+		if id+keepJobs <= j.JobID {
+			delete(jobs, id)
+		}
+	}
+	snapshot := *j
+	jobsMu.Unlock()
+
+	go func() {
+		ids, err := run(db, req, j)
+		now := time.Now()
+		update(j, func(j *Job) {
+			j.FinishedAt = &now
+			if err != nil {
+				j.Status, j.Error = JobFailed, err.Error()
+			} else {
+				j.Status = JobDone
+			}
+		})
+		if err != nil {
+			log.Printf("generator: job %d failed: %v", j.JobID, err)
+		}
+		if len(ids) > 0 && afterInsert != nil {
+			afterInsert(ids)
+		}
+	}()
+	return snapshot, nil
+}
+
+func run(db *gorm.DB, req Request, j *Job) ([]uint, error) {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	// เลขที่มีอยู่แล้วในงวดนี้ (1 bit ต่อเลข)
+	taken := make([]bool, Space)
+	rows, err := db.Raw("SELECT DISTINCT lotto_number FROM lotto WHERE draw_id <=> ?", req.DrawID).Rows()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if v, err := strconv.Atoi(n); err == nil && len(n) == 6 && v >= 0 && v < Space {
+			taken[v] = true
+		}
+	}
+	rows.Close()
+
+	numbers, err := Generate(req.Options, func(n int) bool { return taken[n] })
+	if err != nil {
+		return nil, err
+	}
+	update(j, func(j *Job) { j.Generated = len(numbers) })
+
+	// บันทึกทีละก้อน แต่ละก้อนเป็น transaction ของตัวเอง (ก้อนที่สำเร็จแล้วจะไม่ถูกย้อนกลับ)
+	// ขนาดก้อนนับเป็นแถว: 1 เลขมี req.Sets แถว
+	perChunk := req.ChunkSize / req.Sets
+	if perChunk < 1 {
+		perChunk = 1
+	}
+	var ids []uint
+	for start := 0; start < len(numbers); start += perChunk {
+		end := start + perChunk
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+		chunk := numbers[start:end]
+
+		var chunkIDs []uint
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var sql strings.Builder
+			sql.WriteString("INSERT INTO lotto (lotto_number, status, price, created_by, draw_id, set_no) VALUES ")
+			args := make([]interface{}, 0, len(chunk)*req.Sets*6)
+			for i, n := range chunk {
+				for setNo := 1; setNo <= req.Sets; setNo++ {
+					if i > 0 || setNo > 1 {
+						sql.WriteString(", ")
+					}
+					sql.WriteString("(?, ?, ?, ?, ?, ?)")
+					args = append(args, n, "sell", req.Price, req.CreatedBy, req.DrawID, setNo)
+				}
+			}
+			if err := tx.Exec(sql.String(), args...).Error; err != nil {
+				return err
+			}
+			return tx.Raw("SELECT lotto_id FROM lotto WHERE lotto_number IN ? AND draw_id <=> ?", chunk, req.DrawID).Scan(&chunkIDs).Error
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, chunkIDs...)
+		update(j, func(j *Job) {
+			j.Inserted += len(chunk)
+			j.Rows += len(chunkIDs)
+		})
+	}
+	return ids, nil
+}
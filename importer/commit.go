@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// chunkSize จำนวนแถวต่อการ INSERT / ตรวจซ้ำ 1 ครั้ง
+const chunkSize = 500
+
+// DrawSummary จำนวนสลากในไฟล์แยกตามงวด
+type DrawSummary struct {
+	DrawID   *uint  `json:"draw_id"`
+	DrawDate string `json:"draw_date,omitempty"`
+	Tickets  int    `json:"tickets"`
+}
+
+// Validate ตรวจแถวที่อ่านได้กับฐานข้อมูล: แปลงวันที่งวดเป็น draw_id, งวดต้องมีอยู่และยังเปิดขาย,
+// เลข+ชุดต้องไม่ซ้ำกันในไฟล์และไม่ซ้ำกับที่มีอยู่แล้วในงวดนั้น
+// แถวที่ไม่ผ่านจะถูกย้ายจาก res.Rows ไปเป็น res.Errors
+func Validate(tx *gorm.DB, res *Result) ([]DrawSummary, error) {
+	type drawRow struct {
+		DrawID   uint
+		DrawDate time.Time
+		Status   string
+	}
+	byID := map[uint]drawRow{}
+	byDate := map[string]drawRow{}
+	// ไฟล์ใหญ่มักมีงวดเดียวกันทุกแถว ส่งแต่ละงวดเข้า query ครั้งเดียว
+	idSet := map[uint]bool{}
+	dateSet := map[string]bool{}
+	var ids []uint
+	var dates []string
+	for _, r := range res.Rows {
+		if r.DrawID != nil {
+			if !idSet[*r.DrawID] {
+				idSet[*r.DrawID] = true
+				ids = append(ids, *r.DrawID)
+			}
+		} else if r.DrawDate != nil {
+			value := r.DrawDate.Format("2006-01-02")
+			if !dateSet[value] {
+				dateSet[value] = true
+				dates = append(dates, value)
+			}
+		}
+	}
+	if len(ids) > 0 || len(dates) > 0 {
+		var draws []drawRow
+		if err := tx.Raw("SELECT draw_id, draw_date, status FROM draws WHERE draw_id IN ? OR draw_date IN ?",
+			append(ids, 0), append(dates, "")).Scan(&draws).Error; err != nil {
+			return nil, err
+		}
+		for _, d := range draws {
+			byID[d.DrawID] = d
+			byDate[d.DrawDate.Format("2006-01-02")] = d
+		}
+	}
+
+	type key struct {
+		draw   uint // 0 = ไม่ระบุงวด
+		number string
+		set    int
+	}
+	seen := map[key]int{} // key → บรรทัดแรกที่พบ
+	valid := res.Rows[:0]
+	for _, r := range res.Rows {
+		var d drawRow
+		var found bool
+		switch {
+		case r.DrawID != nil:
+			d, found = byID[*r.DrawID]
+			if !found {
+				res.fail(r.Line, "draw", fmt.Sprint(*r.DrawID), "draw not found")
+				continue
+			}
+		case r.DrawDate != nil:
+			value := r.DrawDate.Format("2006-01-02")
+			d, found = byDate[value]
+			if !found {
+				res.fail(r.Line, "draw", value, "draw not found")
+				continue
+			}
+			id := d.DrawID
+			r.DrawID = &id
+		}
+		if found && d.Status != "open" {
+			res.fail(r.Line, "draw", d.DrawDate.Format("2006-01-02"), "draw is "+d.Status)
+			continue
+		}
+
+		k := key{number: r.LottoNumber, set: r.SetNo}
+		if r.DrawID != nil {
+			k.draw = *r.DrawID
+		}
+		if first, dup := seen[k]; dup {
+			res.fail(r.Line, "number", r.LottoNumber, fmt.Sprintf("duplicate of line %d (same number and set)", first))
+			continue
+		}
+		seen[k] = r.Line
+		valid = append(valid, r)
+	}
+	res.Rows = valid
+
+	// ตรวจซ้ำกับสลากที่มีอยู่แล้ว (แยกตามงวด ทีละก้อน)
+	groups, order := groupByDraw(res.Rows)
+	exists := map[key]bool{}
+	for _, g := range order {
+		rows := groups[g]
+		for start := 0; start < len(rows); start += chunkSize {
+			end := min(start+chunkSize, len(rows))
+			numbers := make([]string, 0, end-start)
+			for _, r := range rows[start:end] {
+				numbers = append(numbers, r.LottoNumber)
+			}
+			var found []struct {
+				LottoNumber string
+				SetNo       int
+			}
+			if err := tx.Raw("SELECT lotto_number, set_no FROM lotto WHERE draw_id <=> ? AND lotto_number IN ? FOR UPDATE",
+				rows[0].DrawID, numbers).Scan(&found).Error; err != nil {
+				return nil, err
+			}
+			for _, f := range found {
+				exists[key{g, f.LottoNumber, f.SetNo}] = true
+			}
+		}
+	}
+	valid = res.Rows[:0]
+	for _, r := range res.Rows {
+		k := key{number: r.LottoNumber, set: r.SetNo}
+		if r.DrawID != nil {
+			k.draw = *r.DrawID
+		}
+		if exists[k] {
+			res.fail(r.Line, "number", r.LottoNumber, fmt.Sprintf("set %d already exists in this draw", r.SetNo))
+			continue
+		}
+		valid = append(valid, r)
+	}
+	res.Rows = valid
+
+	groups, order = groupByDraw(res.Rows)
+	summary := make([]DrawSummary, 0, len(order))
+	for _, g := range order {
+		s := DrawSummary{DrawID: groups[g][0].DrawID, Tickets: len(groups[g])}
+		if d, ok := byID[g]; ok {
+			s.DrawDate = d.DrawDate.Format("2006-01-02")
+		}
+		summary = append(summary, s)
+	}
+	return summary, nil
+}
+
+// Insert บันทึกทุกแถวใน tx (ผู้เรียกเป็นคน commit) คืน lotto_id ที่สร้างแยกตามงวด
+func Insert(tx *gorm.DB, rows []Row, createdBy *uint) (map[uint][]uint, error) {
+	groups, order := groupByDraw(rows)
+	out := make(map[uint][]uint, len(order))
+	for _, g := range order {
+		drawRows := groups[g]
+		for start := 0; start < len(drawRows); start += chunkSize {
+			chunk := drawRows[start:min(start+chunkSize, len(drawRows))]
+
+			var sql strings.Builder
+			sql.WriteString("INSERT INTO lotto (lotto_number, status, price, created_by, draw_id, set_no) VALUES ")
+			args := make([]interface{}, 0, len(chunk)*6)
+			keys := make([][]interface{}, 0, len(chunk))
+			for i, r := range chunk {
+				if i > 0 {
+					sql.WriteString(", ")
+				}
+				sql.WriteString("(?, ?, ?, ?, ?, ?)")
+				args = append(args, r.LottoNumber, "sell", r.Price, createdBy, r.DrawID, r.SetNo)
+				keys = append(keys, []interface{}{r.LottoNumber, r.SetNo})
+			}
+			if err := tx.Exec(sql.String(), args...).Error; err != nil {
+				return nil, err
+			}
+
+			var ids []uint
+			if err := tx.Raw("SELECT lotto_id FROM lotto WHERE draw_id <=> ? AND (lotto_number, set_no) IN ?",
+				chunk[0].DrawID, keys).Scan(&ids).Error; err != nil {
+				return nil, err
+			}
+			out[g] = append(out[g], ids...)
+		}
+	}
+	return out, nil
+}
+
+// groupByDraw แบ่งแถวตามงวด (0 = ไม่ระบุงวด) คงลำดับงวดตามที่พบในไฟล์
+func groupByDraw(rows []Row) (map[uint][]Row, []uint) {
+	groups := map[uint][]Row{}
+	var order []uint
+	for _, r := range rows {
+		var g uint
+		if r.DrawID != nil {
+			g = *r.DrawID
+		}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], r)
+	}
+	return groups, order
+}
@@ -0,0 +1,288 @@
+// Package importer นำเข้าสลากจากไฟล์ CSV / XLSX ของผู้จัดจำหน่าย
+//
+// อ่านไฟล์ทีละแถว (ไม่โหลดทั้งไฟล์เป็นตาราง) ตรวจทุกแถวและรายงานข้อผิดพลาดพร้อมเลขบรรทัด
+// ถ้ามีแถวผิดแม้แถวเดียวจะไม่บันทึกอะไรเลย (ทั้งไฟล์สำเร็จพร้อมกันใน transaction เดียว)
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// รูปแบบไฟล์ที่รับ
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const (
+	MaxRows   = 200_000 // จำนวนแถวสูงสุดต่อไฟล์
+	MaxErrors = 100     // รายงานข้อผิดพลาดไม่เกินกี่แถว (นับทั้งหมดไว้ใน ErrorCount)
+)
+
+var (
+	ErrUnknownFormat = errors.New("file must be .csv or .xlsx")
+	ErrNoHeader      = errors.New("header row must contain a number column (number / lotto_number / เลข)")
+	ErrEmptyFile     = errors.New("file has no data rows")
+	ErrTooManyRows   = fmt.Errorf("file has more than %d rows", MaxRows)
+)
+
+// RowError ข้อผิดพลาดของ 1 แถว (Line นับจาก 1 รวมแถวหัวตาราง เหมือนที่เห็นใน Excel)
+type RowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// Row แถวที่อ่านและแปลงแล้ว (1 แถว = สลาก 1 ใบ คือเลข + ชุดที่)
+type Row struct {
+	Line        int
+	LottoNumber string
+	SetNo       int
+	Price       float64
+	DrawID      *uint      // จากคอลัมน์ draw ที่เป็นตัวเลข หรือค่าเริ่มต้น
+	DrawDate    *time.Time // จากคอลัมน์ draw ที่เป็นวันที่ (แปลงเป็น DrawID ตอนตรวจกับ DB)
+}
+
+// Defaults ค่าที่ใช้เมื่อไฟล์ไม่มีคอลัมน์นั้นหรือเว้นว่าง
+type Defaults struct {
+	DrawID *uint
+	Price  float64
+}
+
+// Result ผลการอ่านไฟล์
+type Result struct {
+	Rows       []Row
+	Errors     []RowError
+	ErrorCount int
+}
+
+func (r *Result) fail(line int, field, value, msg string) {
+	r.ErrorCount++
+	if len(r.Errors) < MaxErrors {
+		r.Errors = append(r.Errors, RowError{Line: line, Field: field, Value: value, Message: msg})
+	}
+}
+
+// FormatOf เดารูปแบบจากนามสกุลไฟล์
+func FormatOf(filename string) (string, error) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(name, ".xlsx"):
+		return FormatXLSX, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// rowReader อ่านไฟล์ทีละแถว
+type rowReader interface {
+	Next() ([]string, error) // คืน io.EOF เมื่อหมด
+	Line() int               // บรรทัดของแถวล่าสุดในไฟล์
+	Close() error
+}
+
+type csvReader struct{ r *csv.Reader }
+
+func (c csvReader) Next() ([]string, error) { return c.r.Read() }
+func (c csvReader) Line() int {
+	line, _ := c.r.FieldPos(0)
+	return line
+}
+func (c csvReader) Close() error { return nil }
+
+type xlsxReader struct {
+	f    *excelize.File
+	rows *excelize.Rows
+	line int
+}
+
+func (x *xlsxReader) Next() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	x.line++
+	return x.rows.Columns()
+}
+
+func (x *xlsxReader) Line() int { return x.line }
+
+func (x *xlsxReader) Close() error {
+	x.rows.Close()
+	return x.f.Close()
+}
+
+func open(r io.Reader, format string) (rowReader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		return csvReader{cr}, nil
+	case FormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := f.Rows(f.GetSheetName(0)) // ใช้ชีตแรก
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &xlsxReader{f: f, rows: rows}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ชื่อคอลัมน์ที่รับ (ไม่สนตัวพิมพ์เล็ก/ใหญ่)
+var headerNames = map[string]string{
+	"number": "number", "lotto_number": "number", "เลข": "number", "เลขสลาก": "number",
+	"set": "set", "sets": "set", "set_no": "set", "ชุด": "set", "ชุดที่": "set",
+	"price": "price", "ราคา": "price",
+	"draw": "draw", "draw_id": "draw", "draw_date": "draw", "งวด": "draw",
+}
+
+// Parse อ่านไฟล์ทีละแถวและตรวจรูปแบบของแต่ละแถว (ยังไม่ตรวจกับ DB)
+func Parse(r io.Reader, format string, def Defaults) (*Result, error) {
+	rr, err := open(r, format)
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+
+	header, err := rr.Next()
+	if err == io.EOF {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")))
+		if name, ok := headerNames[h]; ok {
+			cols[name] = i
+		}
+	}
+	if _, ok := cols["number"]; !ok {
+		return nil, ErrNoHeader
+	}
+	cell := func(rec []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	if def.Price <= 0 {
+		def.Price = 80
+	}
+
+	res := &Result{}
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				res.fail(pe.Line, "", "", pe.Err.Error())
+				continue
+			}
+			return nil, err
+		}
+		line := rr.Line()
+		if isBlank(rec) {
+			continue
+		}
+		if len(res.Rows)+res.ErrorCount >= MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		row := Row{Line: line, SetNo: 1, Price: def.Price, DrawID: def.DrawID}
+		ok := true
+
+		// Excel มักตัดเลข 0 ข้างหน้าทิ้ง (042317 → 42317) จึงเติมกลับให้ครบ 6 หลัก
+		number := cell(rec, "number")
+		if isDigits(number) && len(number) < 6 {
+			number = strings.Repeat("0", 6-len(number)) + number
+		}
+		if len(number) != 6 || !isDigits(number) {
+			res.fail(line, "number", cell(rec, "number"), "must be 6 digits")
+			ok = false
+		}
+		row.LottoNumber = number
+
+		if v := cell(rec, "set"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 100 {
+				res.fail(line, "set", v, "must be a whole number 1-100")
+				ok = false
+			}
+			row.SetNo = n
+		}
+		if v := cell(rec, "price"); v != "" {
+			f, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+			if err != nil || f <= 0 || f > 100_000 {
+				res.fail(line, "price", v, "must be a positive number")
+				ok = false
+			}
+			row.Price = f
+		}
+		if v := cell(rec, "draw"); v != "" {
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil && id > 0 {
+				d := uint(id)
+				row.DrawID = &d
+			} else if t, err := time.Parse("2006-01-02", v); err == nil {
+				row.DrawID, row.DrawDate = nil, &t
+			} else {
+				res.fail(line, "draw", v, "must be a draw_id or date YYYY-MM-DD")
+				ok = false
+			}
+		}
+
+		if ok {
+			res.Rows = append(res.Rows, row)
+		}
+	}
+
+	if len(res.Rows) == 0 && res.ErrorCount == 0 {
+		return nil, ErrEmptyFile
+	}
+	return res, nil
+}
+
+func isBlank(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
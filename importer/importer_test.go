@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{"stock.csv", FormatCSV, nil},
+		{"STOCK.XLSX", FormatXLSX, nil},
+		{"stock.xls", "", ErrUnknownFormat},
+		{"stock", "", ErrUnknownFormat},
+	}
+	for _, tt := range tests {
+		got, err := FormatOf(tt.name)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("FormatOf(%q) = %q, %v, want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	drawID := uint(7)
+	csv := "\uFEFFเลข,ชุด,Price,draw\n" +
+		"42317,2,\"1,200\",\n" + // Excel ตัด 0 ข้างหน้า
+		"123456,,,3\n" +
+		"\n" + // แถวว่างข้ามไป
+		"654321,1,,2026-11-01\n"
+	res, err := Parse(strings.NewReader(csv), FormatCSV, Defaults{DrawID: &drawID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ErrorCount != 0 {
+		t.Fatalf("unexpected errors: %+v", res.Errors)
+	}
+	if len(res.Rows) != 3 {
+		t.Fatalf("rows = %+v, want 3", res.Rows)
+	}
+
+	r := res.Rows[0]
+	if r.Line != 2 || r.LottoNumber != "042317" || r.SetNo != 2 || r.Price != 1200 || r.DrawID == nil || *r.DrawID != 7 {
+		t.Errorf("row 1 = %+v", r)
+	}
+	r = res.Rows[1]
+	if r.LottoNumber != "123456" || r.SetNo != 1 || r.Price != 80 || r.DrawID == nil || *r.DrawID != 3 {
+		t.Errorf("row 2 = %+v (defaults: set 1, price 80)", r)
+	}
+	r = res.Rows[2]
+	if r.Line != 5 || r.DrawID != nil || r.DrawDate == nil || !r.DrawDate.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("row 3 = %+v, want draw date 2026-11-01", r)
+	}
+}
+
+func TestParseReportsRowErrors(t *testing.T) {
+	csv := "number,set,price,draw\n" +
+		"12345a,1,80,\n" +
+		"123456,0,80,\n" +
+		"123456,1,-5,\n" +
+		"123456,1,80,01/11/2026\n" +
+		"111111,1,80,\n"
+	res, err := Parse(strings.NewReader(csv), FormatCSV, Defaults{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0].LottoNumber != "111111" {
+		t.Errorf("valid rows = %+v, want only 111111", res.Rows)
+	}
+	want := []struct {
+		line  int
+		field string
+	}{{2, "number"}, {3, "set"}, {4, "price"}, {5, "draw"}}
+	if res.ErrorCount != len(want) || len(res.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want %d", res.Errors, len(want))
+	}
+	for i, w := range want {
+		if res.Errors[i].Line != w.line || res.Errors[i].Field != w.field {
+			t.Errorf("error %d = %+v, want line %d field %s", i, res.Errors[i], w.line, w.field)
+		}
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"empty", "", ErrEmptyFile},
+		{"header only", "number,set\n", ErrEmptyFile},
+		{"no number column", "set,price\n1,80\n", ErrNoHeader},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.data), FormatCSV, Defaults{}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if _, err := Parse(strings.NewReader("number\n123456\n"), "xls", Defaults{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format: err = %v", err)
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	rows := [][]interface{}{
+		{"lotto_number", "set_no", "price"},
+		{"000123", 3, 90},
+		{"999999", "", ""},
+	}
+	for i, r := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Parse(&buf, FormatXLSX, Defaults{Price: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ErrorCount != 0 || len(res.Rows) != 2 {
+		t.Fatalf("rows = %+v, errors = %+v", res.Rows, res.Errors)
+	}
+	if r := res.Rows[0]; r.Line != 2 || r.LottoNumber != "000123" || r.SetNo != 3 || r.Price != 90 {
+		t.Errorf("row 1 = %+v", r)
+	}
+	if r := res.Rows[1]; r.Line != 3 || r.SetNo != 1 || r.Price != 100 {
+		t.Errorf("row 2 = %+v (defaults: set 1, price 100)", r)
+	}
+}
+
+func TestGroupByDraw(t *testing.T) {
+	d3, d5 := uint(3), uint(5)
+	rows := []Row{
+		{LottoNumber: "000001", DrawID: &d5},
+		{LottoNumber: "000002"},
+		{LottoNumber: "000003", DrawID: &d3},
+		{LottoNumber: "000004", DrawID: &d5},
+	}
+	groups, order := groupByDraw(rows)
+	if len(order) != 3 || order[0] != 5 || order[1] != 0 || order[2] != 3 {
+		t.Errorf("order = %v, want [5 0 3]", order)
+	}
+	if len(groups[5]) != 2 || groups[5][1].LottoNumber != "000004" || len(groups[0]) != 1 || len(groups[3]) != 1 {
+		t.Errorf("groups = %+v", groups)
+	}
+}
@@ -0,0 +1,231 @@
+package limits
+
+import (
+	"fmt"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ช่วงเวลาของวงเงิน
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+	Draw   = "draw"
+)
+
+var Periods = []string{Daily, Weekly, Draw}
+
+// รหัส error ที่ส่งกลับให้ client
+const (
+	CodeSelfExcluded   = "SELF_EXCLUDED"
+	CodeDailyExceeded  = "DAILY_LIMIT_EXCEEDED"
+	CodeWeeklyExceeded = "WEEKLY_LIMIT_EXCEEDED"
+	CodeDrawExceeded   = "DRAW_LIMIT_EXCEEDED"
+)
+
+// Error ซื้อเกินวงเงิน หรืออยู่ระหว่างพักการซื้อ
+type Error struct {
+	Code    string     `json:"code"`
+	Message string     `json:"message"`
+	Source  string     `json:"source,omitempty"` // user / admin (ใครเป็นคนตั้งวงเงินที่ชน)
+	Limit   float64    `json:"limit,omitempty"`
+	Spent   float64    `json:"spent,omitempty"`
+	DrawID  uint       `json:"draw_id,omitempty"`
+	Until   *time.Time `json:"until,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+var hardKeys = map[string]string{
+	Daily:  settings.HardDailyLimit,
+	Weekly: settings.HardWeeklyLimit,
+	Draw:   settings.HardDrawLimit,
+}
+
+var exceededCodes = map[string]string{
+	Daily:  CodeDailyExceeded,
+	Weekly: CodeWeeklyExceeded,
+	Draw:   CodeDrawExceeded,
+}
+
+// StartOfDay / StartOfWeek (สัปดาห์เริ่มวันจันทร์)
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func StartOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return StartOfDay(t).AddDate(0, 0, -offset)
+}
+
+// Load อ่านวงเงินของผู้ใช้ทุกช่วง และปรับค่าที่รอครบเวลา cool-down ให้มีผล
+func Load(db *gorm.DB, userID uint, now time.Time) (map[string]*models.UserLimit, error) {
+	var rows []models.UserLimit
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]*models.UserLimit, len(rows))
+	for i := range rows {
+		l := &rows[i]
+		if l.PendingAt != nil && !l.PendingAt.After(now) {
+			l.Amount, l.PendingAmount, l.PendingAt = l.PendingAmount, nil, nil
+			if err := db.Model(&models.UserLimit{}).
+				Where("user_id = ? AND period = ?", l.UserID, l.Period).
+				Updates(map[string]any{"amount": l.Amount, "pending_amount": nil, "pending_at": nil}).Error; err != nil {
+				return nil, err
+			}
+		}
+		out[l.Period] = l
+	}
+	return out, nil
+}
+
+// Set ตั้งวงเงินของผู้ใช้ 1 ช่วง (amount = nil คือยกเลิกวงเงิน)
+// ลดวงเงินมีผลทันที ส่วนเพิ่ม/ยกเลิกวงเงินต้องรอ cool-down ตาม settings
+func Set(db *gorm.DB, userID uint, period string, amount *float64, now time.Time) (*models.UserLimit, error) {
+	current, err := Load(db, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	l := current[period]
+	if l == nil {
+		l = &models.UserLimit{UserID: userID, Period: period}
+	}
+
+	// จากไม่จำกัดเป็นมีวงเงิน ก็นับเป็นการลด
+	lowering := (amount != nil && (l.Amount == nil || *amount <= *l.Amount)) ||
+		(amount == nil && l.Amount == nil)
+	if lowering {
+		l.Amount, l.PendingAmount, l.PendingAt = amount, nil, nil
+	} else {
+		hours := settings.Float(db, settings.LimitRaiseCooldown)
+		at := now.Add(time.Duration(hours * float64(time.Hour)))
+		l.PendingAmount, l.PendingAt = amount, &at
+	}
+
+	err = db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "pending_amount", "pending_at", "updated_at"}),
+	}).Create(l).Error
+	return l, err
+}
+
+// Effective วงเงินที่ใช้จริง = ค่าที่น้อยกว่าระหว่างวงเงินของผู้ใช้และของ admin (0 = ไม่จำกัด)
+func Effective(db *gorm.DB, userLimits map[string]*models.UserLimit, period string) (float64, string) {
+	return effective(userLimits[period], settings.Float(db, hardKeys[period]))
+}
+
+// effective เลือกวงเงินที่น้อยกว่าระหว่างของผู้ใช้ (l) กับของ admin (hard)
+func effective(l *models.UserLimit, hard float64) (float64, string) {
+	limit, source := 0.0, ""
+	if l != nil && l.Amount != nil {
+		limit, source = *l.Amount, "user"
+	}
+	if hard > 0 && (limit == 0 || hard < limit) {
+		limit, source = hard, "admin"
+	}
+	return limit, source
+}
+
+// SpentSince ยอดซื้อของผู้ใช้ตั้งแต่เวลา since (รวมการซื้อต่อในตลาด)
+func SpentSince(db *gorm.DB, userID uint, since time.Time) (float64, error) {
+	var spent float64
+	err := db.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(total_price), 0) FROM purchases WHERE user_id = ? AND created_at >= ?) +
+			(SELECT COALESCE(SUM(price), 0) FROM ticket_listings WHERE buyer_id = ? AND status = 'sold' AND closed_at >= ?)`,
+		userID, since, userID, since).Scan(&spent).Error
+	return spent, err
+}
+
+// SpentInDraw ยอดซื้อของผู้ใช้ในงวดนี้ (รวมการซื้อต่อในตลาด)
+func SpentInDraw(db *gorm.DB, userID, drawID uint) (float64, error) {
+	var spent float64
+	err := db.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(COALESCE(pd.price, l.price)), 0)
+			FROM purchases_detail AS pd
+			JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+			JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+			WHERE p.user_id = ? AND l.draw_id = ?) +
+			(SELECT COALESCE(SUM(tl.price), 0)
+			FROM ticket_listings AS tl
+			JOIN purchases_detail AS pd ON pd.pd_id = tl.pd_id
+			JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+			WHERE tl.buyer_id = ? AND tl.status = 'sold' AND l.draw_id = ?)`,
+		userID, drawID, userID, drawID).Scan(&spent).Error
+	return spent, err
+}
+
+// Check ตรวจว่าผู้ใช้ซื้อยอดนี้ได้หรือไม่ ใช้ภายใน transaction ของการซื้อ
+// total = ยอดรวมของบิลนี้, byDraw = ยอดของบิลนี้แยกตามงวด
+// คืน *Error ถ้าซื้อไม่ได้
+func Check(tx *gorm.DB, userID uint, total float64, byDraw map[uint]float64, now time.Time) error {
+	// ล็อกแถวผู้ใช้ เพื่อให้การซื้อพร้อมกันของคนเดียวกันนับยอดถูกต้อง
+	var user models.User
+	if err := tx.Raw("SELECT user_id, excluded_until FROM users WHERE user_id = ? FOR UPDATE", userID).Scan(&user).Error; err != nil {
+		return err
+	}
+	if user.ExcludedUntil != nil && user.ExcludedUntil.After(now) {
+		return &Error{
+			Code:    CodeSelfExcluded,
+			Message: "บัญชีนี้อยู่ระหว่างพักการซื้อ",
+			Until:   user.ExcludedUntil,
+		}
+	}
+
+	userLimits, err := Load(tx, userID, now)
+	if err != nil {
+		return err
+	}
+
+	since := map[string]time.Time{Daily: StartOfDay(now), Weekly: StartOfWeek(now)}
+	for _, period := range []string{Daily, Weekly} {
+		limit, source := Effective(tx, userLimits, period)
+		if limit <= 0 {
+			continue
+		}
+		spent, err := SpentSince(tx, userID, since[period])
+		if err != nil {
+			return err
+		}
+		if spent+total > limit {
+			return &Error{
+				Code:    exceededCodes[period],
+				Message: fmt.Sprintf("เกินวงเงินซื้อ (%s) ที่กำหนดไว้ %.2f บาท", period, limit),
+				Source:  source,
+				Limit:   limit,
+				Spent:   spent,
+			}
+		}
+	}
+
+	limit, source := Effective(tx, userLimits, Draw)
+	if limit > 0 {
+		for drawID, amount := range byDraw {
+			if drawID == 0 {
+				continue
+			}
+			spent, err := SpentInDraw(tx, userID, drawID)
+			if err != nil {
+				return err
+			}
+			if spent+amount > limit {
+				return &Error{
+					Code:    CodeDrawExceeded,
+					Message: fmt.Sprintf("เกินวงเงินซื้อต่องวดที่กำหนดไว้ %.2f บาท", limit),
+					Source:  source,
+					Limit:   limit,
+					Spent:   spent,
+					DrawID:  drawID,
+				}
+			}
+		}
+	}
+	return nil
+}
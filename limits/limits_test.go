@@ -0,0 +1,60 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"my-go-project/models"
+)
+
+func amount(v float64) *models.UserLimit { return &models.UserLimit{Amount: &v} }
+
+func TestStartOfDay(t *testing.T) {
+	bkk := time.FixedZone("ICT", 7*3600)
+	got := StartOfDay(time.Date(2026, 10, 16, 23, 59, 59, 0, bkk))
+	want := time.Date(2026, 10, 16, 0, 0, 0, 0, bkk)
+	if !got.Equal(want) || got.Location() != bkk {
+		t.Errorf("StartOfDay = %v, want %v", got, want)
+	}
+}
+
+// สัปดาห์เริ่มวันจันทร์
+func TestStartOfWeek(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	for _, day := range []time.Time{
+		time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),   // จันทร์
+		time.Date(2026, 10, 15, 13, 30, 0, 0, time.UTC), // พฤหัส
+		time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC),  // อาทิตย์
+	} {
+		if got := StartOfWeek(day); !got.Equal(monday) {
+			t.Errorf("StartOfWeek(%s) = %v, want %v", day.Weekday(), got, monday)
+		}
+	}
+	if got := StartOfWeek(time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)); !got.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("next Monday starts a new week, got %v", got)
+	}
+}
+
+func TestEffective(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *models.UserLimit
+		hard       float64
+		wantLimit  float64
+		wantSource string
+	}{
+		{"no limits", nil, 0, 0, ""},
+		{"user limit cleared", &models.UserLimit{}, 0, 0, ""},
+		{"user only", amount(500), 0, 500, "user"},
+		{"admin only", nil, 1000, 1000, "admin"},
+		{"user lower", amount(500), 1000, 500, "user"},
+		{"admin lower", amount(2000), 1000, 1000, "admin"},
+		{"equal keeps user", amount(1000), 1000, 1000, "user"},
+	}
+	for _, tt := range tests {
+		limit, source := effective(tt.user, tt.hard)
+		if limit != tt.wantLimit || source != tt.wantSource {
+			t.Errorf("%s: effective = %v, %q, want %v, %q", tt.name, limit, source, tt.wantLimit, tt.wantSource)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package live กระจายเหตุการณ์แบบ real-time (Server-Sent Events) ให้ client ที่เชื่อมต่ออยู่
+//
+// ผลรางวัล (1 event ต่อ 1 รางวัล ส่งเมื่อ ReleaseRewards บันทึกการประกาศครั้งนั้นสำเร็จ
+// การประกาศ 1 ครั้งจึงได้หลาย event ติดกัน เรียงจากรางวัลที่ 1) และจำนวนสลากคงเหลือ ส่งถึงทุกคน
+// สถานะสลากของผู้ใช้ (ซื้อ/ถูกรางวัล/ขึ้นเงิน/โอน) ส่งเฉพาะ connection ของผู้ใช้คนนั้น
+package live
+
+import (
+	"sync"
+	"time"
+)
+
+// ประเภทเหตุการณ์ (ชื่อ event ของ SSE)
+const (
+	TypeDrawResult   = "draw_result"   // ผลของรางวัล 1 รางวัล จากการประกาศที่บันทึกแล้ว
+	TypeTicketStatus = "ticket_status" // สลากของผู้ใช้เปลี่ยนสถานะ
+	TypeInventory    = "inventory"     // จำนวนสลากที่ยังขายได้ของแต่ละงวด
+	TypeNotification = "notification"  // ข้อความใหม่ในกล่องแจ้งเตือน
+)
+
+// Event เหตุการณ์ 1 รายการ UserID = 0 คือส่งถึงทุกคน
+type Event struct {
+	ID     uint64    `json:"id"`
+	Type   string    `json:"type"`
+	UserID uint      `json:"-"`
+	Data   any       `json:"data"`
+	At     time.Time `json:"at"`
+}
+
+// Client connection 1 เส้น (userID = 0 คือผู้ที่ไม่ได้ระบุตัว รับเฉพาะเหตุการณ์สาธารณะ)
+type Client struct {
+	UserID uint
+	Events chan Event // ถูกปิดเมื่อยกเลิกการสมัคร หรืออ่านไม่ทันจนคิวเต็ม
+}
+
+// ขนาดคิวต่อ connection และจำนวนเหตุการณ์ที่เก็บไว้ส่งซ้ำตอนเชื่อมต่อใหม่
+const (
+	clientBuffer = 64
+	historySize  = 200
+)
+
+// Hub กระจายเหตุการณ์ไปยังทุก connection
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	nextID  uint64
+	history []Event // เหตุการณ์ล่าสุด เรียงตาม ID
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: map[*Client]struct{}{}}
+}
+
+// Default hub ที่ใช้ทั้งระบบ
+var Default = NewHub()
+
+// Subscribe เปิด connection ใหม่ และส่งเหตุการณ์ที่พลาดไปหลัง lastID (0 = ไม่ส่งซ้ำ)
+func (h *Hub) Subscribe(userID uint, lastID uint64) *Client {
+	c := &Client{UserID: userID, Events: make(chan Event, clientBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if lastID > 0 {
+		for _, e := range h.history {
+			if e.ID > lastID && visible(e, userID) && len(c.Events) < clientBuffer {
+				c.Events <- e
+			}
+		}
+	}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Unsubscribe ปิด connection (เรียกซ้ำได้)
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.Events)
+	}
+}
+
+// Publish ส่งเหตุการณ์ไปยังทุก connection ที่มีสิทธิ์เห็น
+// connection ที่อ่านไม่ทันจนคิวเต็มจะถูกตัด (client ต่อใหม่ด้วย Last-Event-ID เพื่อรับส่วนที่พลาด)
+func (h *Hub) Publish(typ string, userID uint, data any) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	e := Event{ID: h.nextID, Type: typ, UserID: userID, Data: data, At: time.Now()}
+	h.history = append(h.history, e)
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+	}
+
+	for c := range h.clients {
+		if !visible(e, c.UserID) {
+			continue
+		}
+		select {
+		case c.Events <- e:
+		default:
+			delete(h.clients, c)
+			close(c.Events)
+		}
+	}
+	return e
+}
+
+// Count จำนวน connection ที่เปิดอยู่
+func (h *Hub) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func visible(e Event, userID uint) bool {
+	return e.UserID == 0 || e.UserID == userID
+}
+
+// Broadcast ส่งเหตุการณ์สาธารณะผ่าน Default hub
+func Broadcast(typ string, data any) {
+	Default.Publish(typ, 0, data)
+}
+
+// ToUser ส่งเหตุการณ์เฉพาะผู้ใช้ผ่าน Default hub
+func ToUser(userID uint, typ string, data any) {
+	if userID == 0 {
+		return
+	}
+	Default.Publish(typ, userID, data)
+}
+
+// TicketStatus แจ้งว่าสลากของผู้ใช้เปลี่ยนสถานะ (purchased / won / claimed / transferred / received)
+func TicketStatus(userID uint, status string, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["status"] = status
+	ToUser(userID, TypeTicketStatus, data)
+}
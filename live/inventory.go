@@ -0,0 +1,58 @@
+package live
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DrawInventory จำนวนสลากที่ยังขายได้ของงวดที่เปิดขาย
+type DrawInventory struct {
+	DrawID    uint      `json:"draw_id"`
+	DrawDate  time.Time `json:"draw_date"`
+	Available int       `json:"available"`
+}
+
+var inventoryDirty atomic.Bool
+
+// InventoryChanged บอกว่าจำนวนสลากคงเหลือเปลี่ยน (ซื้อ/เพิ่ม/ปิดการขาย)
+// ยอดใหม่จะถูกส่งรวมกันในรอบถัดไปของ StartInventory ไม่ query ทุกครั้งที่ซื้อ
+func InventoryChanged() {
+	inventoryDirty.Store(true)
+}
+
+// Inventory จำนวนสลากที่ยังขายได้ของทุกงวดที่เปิดขาย
+func Inventory(db *gorm.DB) ([]DrawInventory, error) {
+	var rows []DrawInventory
+	err := db.Raw(`
+		SELECT d.draw_id, d.draw_date, COUNT(l.lotto_id) AS available
+		FROM draws AS d
+		LEFT JOIN lotto AS l ON l.draw_id = d.draw_id AND l.status = 'sell'
+		WHERE d.status = 'open'
+		GROUP BY d.draw_id, d.draw_date
+		ORDER BY d.draw_date ASC`).Scan(&rows).Error
+	return rows, err
+}
+
+// StartInventory ส่งยอดคงเหลือให้ทุก connection ทุก interval เมื่อมีการเปลี่ยนแปลง
+// (ข้ามการ query ถ้าไม่มีใครเชื่อมต่ออยู่)
+func StartInventory(db *gorm.DB, interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			if Default.Count() == 0 || !inventoryDirty.Swap(false) {
+				continue
+			}
+			rows, err := Inventory(db)
+			if err != nil {
+				log.Printf("live: inventory failed: %v", err)
+				inventoryDirty.Store(true)
+				continue
+			}
+			Broadcast(TypeInventory, rows)
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+// Package loyalty แต้มสะสมจากการซื้อ (แยกจาก wallet) ระดับสมาชิก และการใช้แต้มจ่ายค่าสลาก
+//
+// ระดับคิดจากแต้มที่เคยได้ทั้งหมด (points_earned) ใช้แต้มไปแล้วระดับไม่ลด
+package loyalty
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/settings"
+
+	"gorm.io/gorm"
+)
+
+// ประเภทรายการใน point_transactions
+const (
+	TypeEarn   = "earn"   // ได้แต้มจากการซื้อ
+	TypeRedeem = "redeem" // ใช้แต้มจ่ายค่าสลาก
+)
+
+const (
+	TierMember = "member"
+	TierSilver = "silver"
+	TierGold   = "gold"
+)
+
+var (
+	ErrInsufficientPoints = errors.New("แต้มสะสมไม่เพียงพอ")
+	ErrInvalidPoints      = errors.New("points must be greater than 0")
+)
+
+// RedeemLimitError ใช้แต้มเกินสัดส่วนที่ระดับสมาชิกใช้ได้ต่อบิล
+type RedeemLimitError struct {
+	Tier      string `json:"tier"`
+	MaxPoints int    `json:"max_points"`
+}
+
+func (e *RedeemLimitError) Error() string {
+	return fmt.Sprintf("ระดับ %s ใช้แต้มได้สูงสุด %d แต้มสำหรับบิลนี้", e.Tier, e.MaxPoints)
+}
+
+// Tier ระดับสมาชิกและสิทธิประโยชน์
+type Tier struct {
+	Name             string  `json:"name"`
+	MinPoints        int     `json:"min_points"`         // แต้มที่เคยได้ทั้งหมดขั้นต่ำ
+	EarnMultiplier   float64 `json:"earn_multiplier"`    // ตัวคูณแต้มที่ได้
+	MaxRedeemPercent float64 `json:"max_redeem_percent"` // ใช้แต้มจ่ายได้สูงสุดกี่ % ของบิล
+}
+
+// Tiers ระดับทั้งหมด เรียงจากต่ำไปสูง (เกณฑ์แต้มอ่านจาก settings)
+func Tiers(db *gorm.DB) []Tier {
+	return []Tier{
+		{Name: TierMember, MinPoints: 0, EarnMultiplier: 1, MaxRedeemPercent: 30},
+		{Name: TierSilver, MinPoints: int(settings.Float(db, settings.LoyaltySilverAt)), EarnMultiplier: 1.25, MaxRedeemPercent: 50},
+		{Name: TierGold, MinPoints: int(settings.Float(db, settings.LoyaltyGoldAt)), EarnMultiplier: 1.5, MaxRedeemPercent: 100},
+	}
+}
+
+// TierOf ระดับของผู้ที่มีแต้มที่เคยได้ทั้งหมด earned และระดับถัดไป (nil = ระดับสูงสุดแล้ว)
+func TierOf(tiers []Tier, earned int) (Tier, *Tier) {
+	current := 0
+	for i, t := range tiers {
+		if earned >= t.MinPoints {
+			current = i
+		}
+	}
+	if current+1 < len(tiers) {
+		return tiers[current], &tiers[current+1]
+	}
+	return tiers[current], nil
+}
+
+// Account แต้มคงเหลือและระดับของผู้ใช้
+type Account struct {
+	Points       int     `json:"points"`
+	PointsEarned int     `json:"points_earned"`
+	PointValue   float64 `json:"point_value"` // 1 แต้ม = กี่บาท
+	Tier         Tier    `json:"tier"`
+	NextTier     *Tier   `json:"next_tier"`
+	ToNextTier   int     `json:"to_next_tier"`
+}
+
+// Get แต้มและระดับของผู้ใช้ (ไม่พบผู้ใช้ → gorm.ErrRecordNotFound)
+func Get(db *gorm.DB, userID uint) (*Account, error) {
+	var user models.User
+	found := db.Raw("SELECT points, points_earned FROM users WHERE user_id = ?", userID).Scan(&user)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	tier, next := TierOf(Tiers(db), user.PointsEarned)
+	a := &Account{
+		Points:       user.Points,
+		PointsEarned: user.PointsEarned,
+		PointValue:   settings.Float(db, settings.LoyaltyPointValue),
+		Tier:         tier,
+		NextTier:     next,
+	}
+	if next != nil {
+		a.ToNextTier = next.MinPoints - user.PointsEarned
+	}
+	return a, nil
+}
+
+// Quote ตรวจว่าใช้ points แต้มกับบิลยอด total ได้หรือไม่ คืนมูลค่าเป็นบาท
+// ใช้ภายใน transaction ของการซื้อ (แถวผู้ใช้ถูกล็อกไว้แล้ว)
+func Quote(tx *gorm.DB, userID uint, points int, total float64) (float64, error) {
+	if points <= 0 {
+		return 0, ErrInvalidPoints
+	}
+	a, err := Get(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if points > a.Points {
+		return 0, ErrInsufficientPoints
+	}
+	if a.PointValue <= 0 {
+		return 0, ErrInvalidPoints
+	}
+	maxPoints := int(math.Floor(total * a.Tier.MaxRedeemPercent / 100 / a.PointValue))
+	if points > maxPoints {
+		return 0, &RedeemLimitError{Tier: a.Tier.Name, MaxPoints: maxPoints}
+	}
+	return math.Round(float64(points)*a.PointValue*100) / 100, nil
+}
+
+// Redeem หักแต้มที่ใช้จ่ายบิล purchaseID (เรียกหลัง Quote ภายใน transaction เดียวกัน)
+func Redeem(tx *gorm.DB, userID uint, points int, purchaseID uint) error {
+	result := tx.Exec("UPDATE users SET points = points - ? WHERE user_id = ? AND points >= ?", points, userID, points)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientPoints
+	}
+	return record(tx, userID, -points, TypeRedeem, &purchaseID, fmt.Sprintf("ใช้แต้มจ่ายบิล #%d", purchaseID))
+}
+
+// Earn เพิ่มแต้มจากยอดที่จ่ายด้วยเงิน paid บาท (คูณตามระดับ ณ ตอนซื้อ) คืนจำนวนแต้มที่ได้
+// ถ้าเลื่อนระดับจะแจ้งเตือนผู้ใช้
+func Earn(tx *gorm.DB, userID uint, paid float64, purchaseID uint) (int, error) {
+	a, err := Get(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	points := int(math.Floor(paid * settings.Float(tx, settings.LoyaltyEarnPerBaht) * a.Tier.EarnMultiplier))
+	if points <= 0 {
+		return 0, nil
+	}
+	if err := tx.Exec("UPDATE users SET points = points + ?, points_earned = points_earned + ? WHERE user_id = ?", points, points, userID).Error; err != nil {
+		return 0, err
+	}
+	if err := record(tx, userID, points, TypeEarn, &purchaseID, fmt.Sprintf("แต้มจากบิล #%d", purchaseID)); err != nil {
+		return 0, err
+	}
+
+	if a.NextTier != nil && a.PointsEarned+points >= a.NextTier.MinPoints {
+		tier, _ := TierOf(Tiers(tx), a.PointsEarned+points)
+		notify.PublishQuiet(tx, userID, notify.TypeLoyalty,
+			"เลื่อนระดับสมาชิก",
+			fmt.Sprintf("ยินดีด้วย! คุณเป็นสมาชิกระดับ %s แล้ว ได้แต้ม x%.2f และใช้แต้มจ่ายได้ถึง %.0f%% ของบิล", tier.Name, tier.EarnMultiplier, tier.MaxRedeemPercent),
+			map[string]any{"tier": tier.Name})
+	}
+	return points, nil
+}
+
+func record(tx *gorm.DB, userID uint, points int, typ string, refID *uint, note string) error {
+	var balance int
+	if err := tx.Raw("SELECT points FROM users WHERE user_id = ?", userID).Scan(&balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.PointTransaction{
+		UserID:       userID,
+		Points:       points,
+		BalanceAfter: balance,
+		Type:         typ,
+		RefID:        refID,
+		Note:         note,
+	}).Error
+}
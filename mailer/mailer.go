@@ -0,0 +1,174 @@
+// Package mailer ส่งอีเมล (สมัครสมาชิก ใบเสร็จ ถูกรางวัล) ผ่าน SMTP แบบไม่รอผล
+//
+// อีเมลเข้าคิวในหน่วยความจำ worker จะส่งและลองใหม่เมื่อผิดพลาด
+// ไม่ได้ตั้ง SMTP_HOST = ไม่ส่งอีเมล (แค่ log) ทดสอบ local ได้ด้วย SMTP catcher เช่น MailHog
+// (SMTP_HOST=localhost SMTP_PORT=1025)
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Attachment ไฟล์แนบ
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Email อีเมล 1 ฉบับ (มีทั้ง text และ HTML)
+type Email struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Sender ช่องทางส่งอีเมล
+type Sender interface {
+	Send(e Email) error
+}
+
+// Config ตั้งค่า SMTP
+type Config struct {
+	Host     string
+	Port     string
+	Username string // ว่าง = ไม่ login (เช่น SMTP catcher)
+	Password string
+	From     string // เช่น "Oracel999 <no-reply@example.com>"
+}
+
+// ConfigFromEnv อ่านจาก SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+func ConfigFromEnv() Config {
+	c := Config{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if c.Port == "" {
+		c.Port = "587"
+	}
+	if c.From == "" {
+		c.From = "Oracel999 <no-reply@oracel999.local>"
+	}
+	return c
+}
+
+// SMTP ส่งด้วย net/smtp (ใช้ STARTTLS อัตโนมัติถ้า server รองรับ)
+type SMTP struct {
+	Config Config
+}
+
+func (s SMTP) Send(e Email) error {
+	from, err := mail.ParseAddress(s.Config.From)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid SMTP_FROM: %w", err)
+	}
+	to, err := mail.ParseAddress(e.To)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid recipient: %w", err)
+	}
+	msg, err := build(from, to, e)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Config.Username != "" {
+		auth = smtp.PlainAuth("", s.Config.Username, s.Config.Password, s.Config.Host)
+	}
+	return smtp.SendMail(s.Config.Host+":"+s.Config.Port, auth, from.Address, []string{to.Address}, msg)
+}
+
+// build สร้างข้อความ MIME: multipart/mixed { multipart/alternative { text, html }, ไฟล์แนบ... }
+func build(from, to *mail.Address, e Email) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.BEncoding.Encode("UTF-8", e.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	var alt bytes.Buffer
+	altW := multipart.NewWriter(&alt)
+	for _, part := range []struct{ typ, body string }{
+		{"text/plain; charset=UTF-8", e.Text},
+		{"text/html; charset=UTF-8", e.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := altW.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, []byte(part.body))
+	}
+	altW.Close()
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {`multipart/alternative; boundary="` + altW.Boundary() + `"`}})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(alt.Bytes())
+
+	for _, a := range e.Attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, a.Data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 เขียน base64 บรรทัดละ 76 ตัวอักษรตาม RFC 2045
+func writeBase64(w interface{ Write([]byte) (int, error) }, b []byte) {
+	s := base64.StdEncoding.EncodeToString(b)
+	for len(s) > 76 {
+		w.Write([]byte(s[:76] + "\r\n"))
+		s = s[76:]
+	}
+	w.Write([]byte(s + "\r\n"))
+}
+
+func messageID(from string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,89 @@
+package mailer
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ตั้งค่าคิว
+var (
+	QueueSize   = 1000
+	Workers     = 2
+	MaxAttempts = 5
+	BaseBackoff = 5 * time.Second // รอนานขึ้นเท่าตัวทุกครั้งที่ลองใหม่
+)
+
+type job struct {
+	email   Email
+	attempt int
+}
+
+// Queue ส่งอีเมลเบื้องหลังและลองใหม่เมื่อผิดพลาด
+// (คิวอยู่ในหน่วยความจำ ถ้า restart ระหว่างรอ อีเมลที่ค้างจะหายไป)
+type Queue struct {
+	sender Sender
+	jobs   chan job
+	wg     sync.WaitGroup
+}
+
+// NewQueue เริ่ม worker ตามจำนวน Workers
+func NewQueue(sender Sender) *Queue {
+	q := &Queue{sender: sender, jobs: make(chan job, QueueSize)}
+	for i := 0; i < Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue เพิ่มอีเมลเข้าคิว คืน false ถ้าคิวเต็ม (อีเมลถูกทิ้ง)
+func (q *Queue) Enqueue(e Email) bool {
+	select {
+	case q.jobs <- job{email: e, attempt: 1}:
+		return true
+	default:
+		log.Printf("mailer: queue full, dropped %q to %s", e.Subject, e.To)
+		return false
+	}
+}
+
+// Close หยุดรับอีเมลใหม่และรอให้ส่งที่ค้างอยู่จนหมด
+// (อีเมลที่รอลองใหม่ตอนปิดจะถูกทิ้ง)
+func (q *Queue) Close() {
+	close(q.jobs)
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for j := range q.jobs {
+		err := q.sender.Send(j.email)
+		if err == nil {
+			continue
+		}
+		if j.attempt >= MaxAttempts {
+			log.Printf("mailer: giving up %q to %s after %d attempts: %v", j.email.Subject, j.email.To, j.attempt, err)
+			continue
+		}
+		log.Printf("mailer: send %q to %s failed (attempt %d): %v", j.email.Subject, j.email.To, j.attempt, err)
+		next := job{email: j.email, attempt: j.attempt + 1}
+		wait := BaseBackoff << (j.attempt - 1)
+		// รอแยก goroutine ไม่ให้ worker ค้าง
+		time.AfterFunc(wait, func() { q.retry(next) })
+	}
+}
+
+func (q *Queue) retry(j job) {
+	defer func() {
+		// คิวถูกปิดไปแล้วระหว่างรอ
+		if recover() != nil {
+			log.Printf("mailer: queue closed, dropped %q to %s", j.email.Subject, j.email.To)
+		}
+	}()
+	select {
+	case q.jobs <- j:
+	default:
+		log.Printf("mailer: queue full, dropped retry of %q to %s", j.email.Subject, j.email.To)
+	}
+}
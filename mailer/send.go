@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	mu    sync.RWMutex
+	queue *Queue
+)
+
+// Init เริ่มคิวส่งอีเมลด้วย SMTP จาก environment (ไม่ได้ตั้ง SMTP_HOST = ไม่ส่ง)
+func Init() {
+	cfg := ConfigFromEnv()
+	if cfg.Host == "" {
+		log.Printf("mailer: SMTP_HOST not set, emails disabled")
+		return
+	}
+	SetSender(SMTP{Config: cfg})
+	log.Printf("mailer: sending via %s:%s", cfg.Host, cfg.Port)
+}
+
+// SetSender เปลี่ยนช่องทางส่ง (nil = ไม่ส่ง) คิวเดิมจะส่งที่ค้างให้หมดก่อน
+func SetSender(s Sender) {
+	mu.Lock()
+	old := queue
+	queue = nil
+	if s != nil {
+		queue = NewQueue(s)
+	}
+	mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// Enqueue ส่งอีเมลแบบไม่รอผล (ไม่ได้ตั้งค่า = ไม่ส่ง)
+func Enqueue(e Email) {
+	mu.RLock()
+	q := queue
+	mu.RUnlock()
+	if q == nil {
+		return
+	}
+	q.Enqueue(e)
+}
+
+// Enabled true ถ้าตั้งค่าการส่งอีเมลไว้ (ใช้ข้ามงานเตรียมข้อมูลที่ไม่จำเป็น)
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return queue != nil
+}
+
+// ---------- ข้อมูลของแต่ละแม่แบบ ----------
+
+type WelcomeData struct {
+	Username     string
+	ReferralCode string
+}
+
+type ReceiptItem struct {
+	LottoNumber string
+	DrawDate    *time.Time
+	Price       float64
+}
+
+type ReceiptData struct {
+	Username    string
+	PurchaseID  uint
+	CreatedAt   time.Time
+	Items       []ReceiptItem
+	Subtotal    float64
+	Discount    float64
+	Promotion   string
+	PointsValue float64
+	Total       float64 // ยอดที่จ่ายด้วยเงิน
+}
+
+type WinData struct {
+	Username     string
+	LottoNumbers []string
+	Tickets      int
+}
+
+// ToUser สร้างอีเมลจากแม่แบบตามภาษาของผู้ใช้ แล้วเข้าคิวส่ง
+// data ต้องมีฟิลด์ Username (ถ้าว่างจะเติมจากบัญชีผู้ใช้ให้)
+func ToUser(db *gorm.DB, userID uint, name string, data any, attachments ...Attachment) error {
+	if !Enabled() {
+		return nil
+	}
+	var u struct {
+		Username string
+		Email    string
+		Language string
+	}
+	if err := db.Raw("SELECT username, email, language FROM users WHERE user_id = ?", userID).Scan(&u).Error; err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return nil
+	}
+	switch d := data.(type) {
+	case *WelcomeData:
+		fillName(&d.Username, u.Username)
+	case *ReceiptData:
+		fillName(&d.Username, u.Username)
+	case *WinData:
+		fillName(&d.Username, u.Username)
+	}
+
+	subject, text, html, err := Render(name, u.Language, data)
+	if err != nil {
+		return err
+	}
+	Enqueue(Email{To: u.Email, Subject: subject, Text: text, HTML: html, Attachments: attachments})
+	return nil
+}
+
+// ToUserQuiet เหมือน ToUser แต่แค่ log เมื่อผิดพลาด
+func ToUserQuiet(db *gorm.DB, userID uint, name string, data any, attachments ...Attachment) {
+	if err := ToUser(db, userID, name, data, attachments...); err != nil {
+		log.Printf("mailer: failed to send %s to user %d: %v", name, userID, err)
+	}
+}
+
+func fillName(dst *string, name string) {
+	if *dst == "" {
+		*dst = name
+	}
+}
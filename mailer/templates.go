@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// templates/<ชื่อ>.<ภาษา>.txt  กำหนด {{define "subject"}} และเนื้อหาแบบ text
+// templates/<ชื่อ>.<ภาษา>.html เนื้อหาแบบ HTML (ใช้ layout.<ภาษา>.html ครอบ)
+//
+//go:embed templates/*
+var templateFS embed.FS
+
+// ชื่อแม่แบบ
+const (
+	TemplateWelcome = "welcome"
+	TemplateReceipt = "receipt"
+	TemplateWin     = "win"
+)
+
+// ภาษาที่รองรับ (ภาษาอื่นใช้ภาษาไทย)
+const (
+	LangTH = "th"
+	LangEN = "en"
+)
+
+var funcs = map[string]any{
+	"money": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"date": func(t any) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format("2006-01-02")
+		case *time.Time:
+			if v != nil {
+				return v.Format("2006-01-02")
+			}
+		}
+		return "-"
+	},
+	"join": strings.Join,
+}
+
+// NormalizeLang ภาษาที่รองรับ (ค่าอื่น = th)
+func NormalizeLang(lang string) string {
+	if strings.ToLower(strings.TrimSpace(lang)) == LangEN {
+		return LangEN
+	}
+	return LangTH
+}
+
+// Render สร้างหัวเรื่อง เนื้อหา text และ HTML จากแม่แบบ
+func Render(name, lang string, data any) (subject, text, html string, err error) {
+	lang = NormalizeLang(lang)
+
+	tt, err := texttemplate.New(name).Funcs(funcs).ParseFS(templateFS, fmt.Sprintf("templates/%s.%s.txt", name, lang))
+	if err != nil {
+		return "", "", "", err
+	}
+	var sb, tb bytes.Buffer
+	if err := tt.ExecuteTemplate(&sb, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	if err := tt.ExecuteTemplate(&tb, "body", data); err != nil {
+		return "", "", "", err
+	}
+
+	ht, err := htmltemplate.New(name).Funcs(funcs).ParseFS(templateFS,
+		fmt.Sprintf("templates/layout.%s.html", lang),
+		fmt.Sprintf("templates/%s.%s.html", name, lang))
+	if err != nil {
+		return "", "", "", err
+	}
+	var hb bytes.Buffer
+	if err := ht.ExecuteTemplate(&hb, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	return strings.TrimSpace(sb.String()), strings.TrimSpace(tb.String()) + "\n", hb.String(), nil
+}
@@ -3,8 +3,15 @@ package main
 import (
 	"log"
 	"my-go-project/database"
+	"my-go-project/live"
+	"my-go-project/mailer"
+	"my-go-project/market"
+	"my-go-project/push"
 	"my-go-project/routers"
+	"my-go-project/ticket"
+	"my-go-project/watch"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +23,33 @@ func main() {
 		panic("Failed to connect to the database")
 	}
 
+	// คีย์เซ็นรหัสยืนยันสลาก (ต้องตั้งก่อน migrate เพราะมีการเติมรหัสให้สลากเก่า)
+	if err := ticket.CheckSecret(); err != nil {
+		log.Fatal("❌ Failed to configure ticket codes: ", err)
+	}
+
+	// สร้างตาราง/คอลัมน์ที่ยังไม่มี
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("❌ Failed to migrate database: ", err)
+	}
+
+	// push notification ผ่าน FCM (ไม่ได้ตั้ง FCM_CREDENTIALS = ไม่ส่ง)
+	if err := push.Init(); err != nil {
+		log.Fatal("❌ Failed to configure push notifications: ", err)
+	}
+
+	// อีเมลผ่าน SMTP (ไม่ได้ตั้ง SMTP_HOST = ไม่ส่ง)
+	mailer.Init()
+
+	// ปล่อยการจองสลากที่หมดเวลา และแจ้งผู้ติดตามเลขคนถัดไป
+	watch.StartSweeper(db, time.Minute)
+
+	// ยกเลิกประกาศขายต่อที่ถึงเวลาปิดตลาดของงวดแล้ว
+	market.StartSweeper(db, time.Minute)
+
+	// ส่งยอดสลากคงเหลือให้ผู้ที่เปิด /live อยู่ เมื่อมีการเปลี่ยนแปลง
+	live.StartInventory(db, 2*time.Second)
+
 	// สร้าง Gin router
 	r := gin.Default()
 
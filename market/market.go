@@ -0,0 +1,243 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"my-go-project/limits"
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/settings"
+	"my-go-project/wallet"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotOwned       = errors.New("you do not own this ticket")
+	ErrNotListable    = errors.New("ticket cannot be listed (syndicate ticket, draw settled or prize claimed)")
+	ErrAlreadyListed  = errors.New("ticket is already listed")
+	ErrPriceTooHigh   = errors.New("price exceeds the allowed cap")
+	ErrPastCutoff     = errors.New("marketplace is closed for this draw")
+	ErrListingClosed  = errors.New("listing is no longer active")
+	ErrBuyOwnListing  = errors.New("cannot buy your own listing")
+	ErrListingMissing = errors.New("listing not found")
+)
+
+// ticket ข้อมูลสลากที่ใช้ตรวจก่อนประกาศขาย/ซื้อ
+type ticket struct {
+	PDID        uint
+	OwnerID     *uint
+	Status      string
+	CashIn      string
+	SyndicateID *uint
+	LottoNumber string
+	FacePrice   float64
+	DrawID      *uint
+	DrawDate    *time.Time
+	DrawStatus  *string
+}
+
+func loadTicket(tx *gorm.DB, pdID uint) (*ticket, error) {
+	var t ticket
+	result := tx.Raw(`
+		SELECT pd.pd_id, pd.owner_id, pd.status, pd.cash_in, p.syndicate_id,
+		       l.lotto_number, l.price AS face_price, l.draw_id, d.draw_date, d.status AS draw_status
+		FROM purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+		WHERE pd.pd_id = ?
+		FOR UPDATE`, pdID).Scan(&t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotOwned
+	}
+	return &t, nil
+}
+
+// Cutoff เวลาปิดตลาดขายต่อของงวด
+func Cutoff(db *gorm.DB, drawDate time.Time) time.Time {
+	hours := settings.Float(db, settings.MarketCutoffHours)
+	return drawDate.Add(-time.Duration(hours * float64(time.Hour)))
+}
+
+// tradable สลากยังซื้อขายได้: ไม่ใช่ของกลุ่ม ยังไม่ออกผล ยังไม่ขึ้นเงิน และยังไม่ถึงเวลาปิดตลาด
+func (t *ticket) tradable(db *gorm.DB, now time.Time) error {
+	if t.SyndicateID != nil || t.Status != "ยัง" || t.CashIn == "ขึ้นเงิน" {
+		return ErrNotListable
+	}
+	if t.DrawStatus != nil && *t.DrawStatus != "open" {
+		return ErrPastCutoff
+	}
+	if t.DrawDate != nil && !now.Before(Cutoff(db, *t.DrawDate)) {
+		return ErrPastCutoff
+	}
+	return nil
+}
+
+// MaxPrice ราคาขายต่อสูงสุดของสลากราคาหน้าตั๋ว face
+func MaxPrice(db *gorm.DB, face float64) float64 {
+	markup := settings.Float(db, settings.MarketMaxMarkup)
+	return math.Round(face*(1+markup/100)*100) / 100
+}
+
+// Create ประกาศขายสลากที่ตัวเองถือ (ต้องเรียกภายใน transaction)
+func Create(tx *gorm.DB, sellerID, pdID uint, price float64, now time.Time) (*models.Listing, error) {
+	t, err := loadTicket(tx, pdID)
+	if err != nil {
+		return nil, err
+	}
+	if t.OwnerID == nil || *t.OwnerID != sellerID {
+		return nil, ErrNotOwned
+	}
+	if err := t.tradable(tx, now); err != nil {
+		return nil, err
+	}
+	if max := MaxPrice(tx, t.FacePrice); price > max {
+		return nil, fmt.Errorf("%w (max %.2f)", ErrPriceTooHigh, max)
+	}
+
+	var active int64
+	if err := tx.Raw("SELECT COUNT(*) FROM ticket_listings WHERE pd_id = ? AND status = 'active'", pdID).Scan(&active).Error; err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, ErrAlreadyListed
+	}
+
+	l := &models.Listing{PDID: pdID, SellerID: sellerID, Price: price, Status: "active"}
+	if err := tx.Create(l).Error; err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Buy ซื้อสลากจากประกาศขาย: หักเงินผู้ซื้อ จ่ายผู้ขาย (หักค่าธรรมเนียม) และโอนความเป็นเจ้าของ
+// ทั้งหมดใน transaction เดียว
+func Buy(db *gorm.DB, buyerID, listingID uint, now time.Time) (*models.Listing, error) {
+	var listing models.Listing
+	var lottoNumber string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Raw("SELECT * FROM ticket_listings WHERE listing_id = ? FOR UPDATE", listingID).Scan(&listing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrListingMissing
+		}
+		if listing.Status != "active" {
+			return ErrListingClosed
+		}
+		if listing.SellerID == buyerID {
+			return ErrBuyOwnListing
+		}
+
+		t, err := loadTicket(tx, listing.PDID)
+		if err != nil {
+			return err
+		}
+		if t.OwnerID == nil || *t.OwnerID != listing.SellerID {
+			return ErrListingClosed
+		}
+		if err := t.tradable(tx, now); err != nil {
+			return err
+		}
+		lottoNumber = t.LottoNumber
+
+		// ซื้อต่อก็นับเป็นการใช้จ่าย ต้องผ่านวงเงิน/การพักการซื้อ
+		byDraw := map[uint]float64{}
+		if t.DrawID != nil {
+			byDraw[*t.DrawID] = listing.Price
+		}
+		if err := limits.Check(tx, buyerID, listing.Price, byDraw, now); err != nil {
+			return err
+		}
+
+		fee := math.Round(listing.Price*settings.Float(tx, settings.MarketFeePercent)) / 100
+		if err := wallet.Debit(tx, buyerID, listing.Price, wallet.TypeMarketBuy, &listing.ListingID, "ซื้อต่อสลากเลข "+t.LottoNumber); err != nil {
+			return err
+		}
+		if err := wallet.Credit(tx, listing.SellerID, listing.Price-fee, wallet.TypeMarketSale, &listing.ListingID, "ขายต่อสลากเลข "+t.LottoNumber); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("UPDATE purchases_detail SET owner_id = ? WHERE pd_id = ?", buyerID, listing.PDID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.TicketTransfer{PDID: listing.PDID, FromUserID: listing.SellerID, ToUserID: buyerID}).Error; err != nil {
+			return err
+		}
+
+		listing.Status = "sold"
+		listing.BuyerID = &buyerID
+		listing.Fee = fee
+		listing.ClosedAt = &now
+		return tx.Exec("UPDATE ticket_listings SET status = ?, buyer_id = ?, fee = ?, closed_at = ? WHERE listing_id = ?",
+			listing.Status, buyerID, fee, now, listing.ListingID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notify.PublishQuiet(db, listing.SellerID, notify.TypeMarket,
+		"ขายสลากสำเร็จ",
+		fmt.Sprintf("สลากเลข %s ขายได้ %.2f บาท (ค่าธรรมเนียม %.2f บาท)", lottoNumber, listing.Price, listing.Fee),
+		map[string]any{"listing_id": listing.ListingID, "pd_id": listing.PDID})
+	return &listing, nil
+}
+
+// Cancel ผู้ขายยกเลิกประกาศของตัวเอง
+func Cancel(db *gorm.DB, sellerID, listingID uint, now time.Time) error {
+	result := db.Exec("UPDATE ticket_listings SET status = 'cancelled', closed_at = ? WHERE listing_id = ? AND seller_id = ? AND status = 'active'",
+		now, listingID, sellerID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrListingMissing
+	}
+	return nil
+}
+
+// CancelAtCutoff ยกเลิกประกาศทั้งหมดของงวดที่ถึงเวลาปิดตลาด/ปิดการขายแล้ว
+// รวมถึงสลากที่ออกผลแล้ว คืนจำนวนประกาศที่ถูกยกเลิก
+func CancelAtCutoff(db *gorm.DB, now time.Time) (int64, error) {
+	hours := settings.Float(db, settings.MarketCutoffHours)
+	cutoffBefore := now.Add(time.Duration(hours * float64(time.Hour)))
+	result := db.Exec(`
+		UPDATE ticket_listings AS tl
+		JOIN purchases_detail AS pd ON pd.pd_id = tl.pd_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+		SET tl.status = 'cancelled', tl.closed_at = ?
+		WHERE tl.status = 'active'
+		  AND (pd.status <> 'ยัง' OR d.status <> 'open' OR d.draw_date <= ?)`, now, cutoffBefore)
+	return result.RowsAffected, result.Error
+}
+
+// BeforeCutoffSQL เงื่อนไข WHERE ของประกาศที่ยังซื้อได้ (ยังไม่ออกผล งวดยังเปิด และยังไม่ถึงเวลาปิดตลาด)
+// ใช้ alias pd = purchases_detail และ d = draws (LEFT JOIN) ใช้ตอนอ่านรายการโดยไม่ต้องแก้ข้อมูล
+func BeforeCutoffSQL(db *gorm.DB, now time.Time) (string, []interface{}) {
+	hours := settings.Float(db, settings.MarketCutoffHours)
+	cutoffBefore := now.Add(time.Duration(hours * float64(time.Hour)))
+	return "pd.status = 'ยัง' AND (d.draw_id IS NULL OR (d.status = 'open' AND d.draw_date > ?))", []interface{}{cutoffBefore}
+}
+
+// StartSweeper ยกเลิกประกาศที่ถึงเวลาปิดตลาดเป็นระยะ (ทำเบื้องหลังตลอดอายุ server)
+func StartSweeper(db *gorm.DB, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := CancelAtCutoff(db, time.Now()); err != nil {
+				log.Printf("market: cancel listings at cutoff failed: %v", err)
+			}
+		}
+	}()
+}
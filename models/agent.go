@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// ตาราง Agents (ตัวแทนขาย ผู้ใช้ที่ role = 'agent') เก็บอัตราค่าคอมมิชชัน
+type Agent struct {
+	AgentID        uint      `json:"agent_id"        gorm:"column:agent_id;primaryKey;autoIncrement:false"`              // = users.user_id
+	CommissionRate float64   `json:"commission_rate" gorm:"column:commission_rate;type:decimal(5,2);not null;default:0"` // % ของยอดขาย
+	Active         bool      `json:"active"          gorm:"column:active;not null;default:true"`
+	CreatedAt      time.Time `json:"created_at"      gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:AgentID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Agent) TableName() string { return "agents" }
+
+// ตาราง Agent_allocations (สลากที่แบ่งให้ตัวแทนขาย คนอื่นซื้อไม่ได้ ขายแล้วลบออก)
+type AgentAllocation struct {
+	AllocationID uint      `json:"allocation_id" gorm:"column:allocation_id;primaryKey;autoIncrement"`
+	AgentID      uint      `json:"agent_id"      gorm:"column:agent_id;not null;index"`
+	LottoID      uint      `json:"lotto_id"      gorm:"column:lotto_id;not null;uniqueIndex"`
+	CreatedAt    time.Time `json:"created_at"    gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	Agent *Agent `json:"-" gorm:"foreignKey:AgentID;references:AgentID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	Lotto *Lotto `json:"-" gorm:"foreignKey:LottoID;references:LottoID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (AgentAllocation) TableName() string { return "agent_allocations" }
+
+// ตาราง Agent_commissions (ค่าคอมมิชชันของตัวแทน 1 แถวต่อ 1 บิลต่อ 1 งวด)
+// Status: accrued = ยังไม่จ่าย, paid = จ่ายเข้า wallet แล้ว (ตอน admin ปิดยอดงวด)
+type AgentCommission struct {
+	CommissionID uint       `json:"commission_id" gorm:"column:commission_id;primaryKey;autoIncrement"`
+	AgentID      uint       `json:"agent_id"      gorm:"column:agent_id;not null;index:idx_agent_commission_draw,priority:1"`
+	DrawID       *uint      `json:"draw_id"       gorm:"column:draw_id;index:idx_agent_commission_draw,priority:2"`
+	PurchaseID   uint       `json:"purchase_id"   gorm:"column:purchase_id;not null;index"`
+	CustomerID   uint       `json:"customer_id"   gorm:"column:customer_id;not null"`
+	Tickets      int        `json:"tickets"       gorm:"column:tickets;not null"`
+	Sales        float64    `json:"sales"         gorm:"column:sales;type:decimal(10,2);not null"`
+	Rate         float64    `json:"rate"          gorm:"column:rate;type:decimal(5,2);not null"`
+	Amount       float64    `json:"amount"        gorm:"column:amount;type:decimal(10,2);not null"`
+	Status       string     `json:"status"        gorm:"column:status;type:enum('accrued','paid');not null;default:'accrued'"`
+	CreatedAt    time.Time  `json:"created_at"    gorm:"column:created_at;autoCreateTime"`
+	PaidAt       *time.Time `json:"paid_at"       gorm:"column:paid_at"`
+
+	// relations
+	Agent    *Agent    `json:"-" gorm:"foreignKey:AgentID;references:AgentID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+	Purchase *Purchase `json:"-" gorm:"foreignKey:PurchaseID;references:PurchaseID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+}
+
+func (AgentCommission) TableName() string { return "agent_commissions" }
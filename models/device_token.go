@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ตาราง DeviceTokens (FCM registration token ของแอปแต่ละเครื่อง)
+type DeviceToken struct {
+	DeviceID   uint      `json:"device_id"    gorm:"column:device_id;primaryKey;autoIncrement"`
+	UserID     uint      `json:"user_id"      gorm:"column:user_id;not null;index"`
+	Token      string    `json:"token"        gorm:"column:token;type:varchar(512);not null;uniqueIndex"` // 1 token = 1 เครื่อง (ล็อกอินบัญชีใหม่ → ย้ายเจ้าของ)
+	Platform   string    `json:"platform"     gorm:"column:platform;type:enum('android','ios','web');not null;default:'android'"`
+	LastSeenAt time.Time `json:"last_seen_at" gorm:"column:last_seen_at;not null"`
+	CreatedAt  time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (DeviceToken) TableName() string { return "device_tokens" }
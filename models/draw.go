@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ตาราง Draws (งวดสลาก)
+type Draw struct {
+	DrawID   uint      `json:"draw_id"   gorm:"column:draw_id;primaryKey;autoIncrement"`
+	DrawDate time.Time `json:"draw_date" gorm:"column:draw_date;type:date;not null;uniqueIndex"`
+	Status   string    `json:"status"    gorm:"column:status;type:enum('open','closed','released');not null;default:'open'"`
+
+	// relations
+	Lottos []Lotto `json:"-" gorm:"foreignKey:DrawID;references:DrawID"`
+}
+
+func (Draw) TableName() string { return "draws" }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ตาราง Draw_results (ผลรางวัลย้อนหลัง เก็บทุกครั้งที่ปล่อยรางวัล)
+// ตาราง rewards เก็บแค่ผลล่าสุด ผลเก่าจึงเก็บไว้ที่นี่สำหรับสถิติ
+// แถวที่ปล่อยพร้อมกันมี ReleasedAt เดียวกัน (1 ครั้ง = 1 งวด)
+type DrawResult struct {
+	ResultID    uint      `json:"result_id"    gorm:"column:result_id;primaryKey;autoIncrement"`
+	DrawID      *uint     `json:"draw_id"      gorm:"column:draw_id;index"`
+	ReleasedAt  time.Time `json:"released_at"  gorm:"column:released_at;not null;index"`
+	PrizeTier   int       `json:"prize_tier"   gorm:"column:prize_tier;not null"`
+	PrizeMoney  float64   `json:"prize_money"  gorm:"column:prize_money;type:decimal(10,2);not null"`
+	LottoNumber string    `json:"lotto_number" gorm:"column:lotto_number;type:varchar(6);not null"`
+
+	// relations
+	Draw *Draw `json:"-" gorm:"foreignKey:DrawID;references:DrawID;constraint:OnUpdate:RESTRICT,OnDelete:SET NULL"`
+}
+
+func (DrawResult) TableName() string { return "draw_results" }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ตาราง Ticket_listings (ประกาศขายต่อสลากก่อนออกรางวัล)
+type Listing struct {
+	ListingID uint       `json:"listing_id" gorm:"column:listing_id;primaryKey;autoIncrement"`
+	PDID      uint       `json:"pd_id"      gorm:"column:pd_id;not null;index"`
+	SellerID  uint       `json:"seller_id"  gorm:"column:seller_id;not null;index"`
+	Price     float64    `json:"price"      gorm:"column:price;type:decimal(10,2);not null"`
+	Status    string     `json:"status"     gorm:"column:status;type:enum('active','sold','cancelled');not null;default:'active';index"`
+	BuyerID   *uint      `json:"buyer_id"   gorm:"column:buyer_id"`
+	Fee       float64    `json:"fee"        gorm:"column:fee;type:decimal(10,2);not null;default:0"` // ค่าธรรมเนียมแพลตฟอร์มที่หักจากผู้ขาย
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	ClosedAt  *time.Time `json:"closed_at"  gorm:"column:closed_at"`
+
+	// relations
+	PurchaseDetail *PurchaseDetail `json:"-" gorm:"foreignKey:PDID;references:PDID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	Seller         *User           `json:"-" gorm:"foreignKey:SellerID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Listing) TableName() string { return "ticket_listings" }
@@ -3,13 +3,20 @@ package models
 // ตาราง Lotto
 type Lotto struct {
 	LottoID     uint    `json:"lotto_id"     gorm:"column:lotto_id;primaryKey;autoIncrement"`
-	LottoNumber string  `json:"lotto_number" gorm:"column:lotto_number;type:varchar(6);not null"`
-	Status      string  `json:"status"       gorm:"column:status;type:enum('sell','sold');not null;default:'sell'"`
-	Price       float64 `json:"price"        gorm:"column:price;type:decimal(10,2);default:80"`
+	LottoNumber string  `json:"lotto_number" gorm:"column:lotto_number;type:varchar(6);not null;index:idx_lotto_number;uniqueIndex:idx_lotto_draw_number_set,priority:2"`
+	Status      string  `json:"status"       gorm:"column:status;type:enum('sell','sold');not null;default:'sell';index:idx_lotto_status"`
+	Price       float64 `json:"price"        gorm:"column:price;type:decimal(10,2);default:80;index:idx_lotto_price"`
 	CreatedBy   *uint   `json:"created_by"   gorm:"column:created_by;index:idx_lotto_created_by"`
+	DrawID      *uint   `json:"draw_id"      gorm:"column:draw_id;index:idx_lotto_draw_id;uniqueIndex:idx_lotto_draw_number_set,priority:1"`
+	SetNo       int     `json:"set_no"       gorm:"column:set_no;not null;default:1;uniqueIndex:idx_lotto_draw_number_set,priority:3"` // ชุดที่ (เลขเดียวกันขายได้หลายชุด)
+
+	// คอลัมน์ที่ MySQL คำนวณเอง (generated) ใช้สำหรับค้นหาเลข อ่านอย่างเดียว
+	LottoNumberRev string `json:"-" gorm:"column:lotto_number_rev;->;index:idx_lotto_number_rev"` // เลขกลับด้าน ค้นหาเลขท้าย
+	DigitSum       int    `json:"-" gorm:"column:digit_sum;->;index:idx_lotto_digit_sum"`         // ผลรวมทุกหลัก
 
 	// relations
 	Creator          *User            `json:"-" gorm:"foreignKey:CreatedBy;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:SET NULL"`
+	Draw             *Draw            `json:"-" gorm:"foreignKey:DrawID;references:DrawID;constraint:OnUpdate:RESTRICT,OnDelete:SET NULL"`
 	Reward           *Reward          `json:"-" gorm:"foreignKey:LottoID;references:LottoID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
 	PurchasesDetails []PurchaseDetail `json:"-" gorm:"foreignKey:LottoID;references:LottoID"`
 }
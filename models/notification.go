@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ตาราง Notifications (กล่องข้อความแจ้งเตือนของผู้ใช้)
+type Notification struct {
+	NotificationID uint       `json:"notification_id" gorm:"column:notification_id;primaryKey;autoIncrement"`
+	UserID         uint       `json:"user_id"         gorm:"column:user_id;not null;index:idx_notifications_user_read,priority:1"`
+	Type           string     `json:"type"            gorm:"column:type;type:varchar(50);not null"`
+	Title          string     `json:"title"           gorm:"column:title;type:varchar(255);not null"`
+	Body           string     `json:"body"            gorm:"column:body;type:text"`
+	Data           *string    `json:"data"            gorm:"column:data;type:json"` // ข้อมูลเพิ่มเติม (JSON) เช่น purchase_id
+	ReadAt         *time.Time `json:"read_at"         gorm:"column:read_at;index:idx_notifications_user_read,priority:2"`
+	CreatedAt      time.Time  `json:"created_at"      gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Notification) TableName() string { return "notifications" }
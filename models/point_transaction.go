@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ตาราง Point_transactions (สมุดบัญชีแต้มสะสม แยกจาก wallet)
+// Points เป็นบวกเมื่อได้แต้ม และติดลบเมื่อใช้แต้ม
+type PointTransaction struct {
+	PTxID        uint      `json:"ptx_id"        gorm:"column:ptx_id;primaryKey;autoIncrement"`
+	UserID       uint      `json:"user_id"       gorm:"column:user_id;not null;index"`
+	Points       int       `json:"points"        gorm:"column:points;not null"`
+	BalanceAfter int       `json:"balance_after" gorm:"column:balance_after;not null"`
+	Type         string    `json:"type"          gorm:"column:type;type:varchar(50);not null"`
+	RefID        *uint     `json:"ref_id"        gorm:"column:ref_id"` // เช่น purchase_id
+	Note         string    `json:"note"          gorm:"column:note;type:varchar(255)"`
+	CreatedAt    time.Time `json:"created_at"    gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (PointTransaction) TableName() string { return "point_transactions" }
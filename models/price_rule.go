@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ตาราง Price_rules (กฎปรับราคาสลากตอนขาย admin เป็นคนกำหนด)
+// เงื่อนไขที่ไม่เป็น NULL ต้องตรงทุกข้อ กฎจึงจะถูกใช้ (ไม่ใส่เงื่อนไขเลย = ใช้กับทุกใบ)
+// Pattern: "double" (เลขท้าย 2 ตัวเบิ้ล), "triple" (เลขท้าย 3 ตัวตอง) หรือเลข 6 หลักใช้ x แทนหลักใดก็ได้
+type PriceRule struct {
+	RuleID       uint      `json:"rule_id"       gorm:"column:rule_id;primaryKey;autoIncrement"`
+	Name         string    `json:"name"          gorm:"column:name;type:varchar(100);not null"`
+	Pattern      *string   `json:"pattern"       gorm:"column:pattern;type:varchar(10)"`
+	MaxRemaining *int      `json:"max_remaining" gorm:"column:max_remaining"` // เลขนี้ในงวดเหลือขายไม่เกินกี่ใบ
+	HoursBefore  *int      `json:"hours_before"  gorm:"column:hours_before"`  // เหลือเวลาถึงวันออกรางวัลไม่เกินกี่ชั่วโมง
+	Adjust       string    `json:"adjust"        gorm:"column:adjust;type:enum('percent','amount','fixed');not null"`
+	Value        float64   `json:"value"         gorm:"column:value;type:decimal(10,2);not null"`
+	Priority     int       `json:"priority"      gorm:"column:priority;not null;default:0"` // น้อยทำก่อน
+	Active       bool      `json:"active"        gorm:"column:active;not null;default:true"`
+	CreatedAt    time.Time `json:"created_at"    gorm:"column:created_at;autoCreateTime"`
+}
+
+func (PriceRule) TableName() string { return "price_rules" }
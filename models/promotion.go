@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ตาราง Promotions (โค้ดส่วนลด / โปรโมชันตอนซื้อ)
+// Code เป็น NULL = โปรอัตโนมัติ ใช้ให้เองเมื่อไม่ได้ใส่โค้ด
+// Kind: percent = ลด Value % ของยอดบิล, fixed = ลด Value บาท,
+// bundle = ซื้อ BuyQty ใบ แถม FreeQty ใบ (ใบที่ถูกที่สุดในบิลเป็นของแถม)
+type Promotion struct {
+	PromotionID  uint       `json:"promotion_id"   gorm:"column:promotion_id;primaryKey;autoIncrement"`
+	Code         *string    `json:"code"           gorm:"column:code;type:varchar(50);uniqueIndex"`
+	Name         string     `json:"name"           gorm:"column:name;type:varchar(100);not null"`
+	Kind         string     `json:"kind"           gorm:"column:kind;type:enum('percent','fixed','bundle');not null"`
+	Value        float64    `json:"value"          gorm:"column:value;type:decimal(10,2);not null;default:0"`
+	BuyQty       int        `json:"buy_qty"        gorm:"column:buy_qty;not null;default:0"`
+	FreeQty      int        `json:"free_qty"       gorm:"column:free_qty;not null;default:0"`
+	MinTotal     float64    `json:"min_total"      gorm:"column:min_total;type:decimal(10,2);not null;default:0"`    // ยอดบิลขั้นต่ำ
+	MaxDiscount  float64    `json:"max_discount"   gorm:"column:max_discount;type:decimal(10,2);not null;default:0"` // ลดได้สูงสุด (0 = ไม่จำกัด)
+	PerUserLimit int        `json:"per_user_limit" gorm:"column:per_user_limit;not null;default:0"`                  // ใช้ได้กี่ครั้งต่อคน (0 = ไม่จำกัด)
+	TotalLimit   int        `json:"total_limit"    gorm:"column:total_limit;not null;default:0"`                     // ใช้ได้ทั้งหมดกี่ครั้ง (0 = ไม่จำกัด)
+	StartsAt     *time.Time `json:"starts_at"      gorm:"column:starts_at"`
+	EndsAt       *time.Time `json:"ends_at"        gorm:"column:ends_at"`
+	Active       bool       `json:"active"         gorm:"column:active;not null;default:true"`
+	CreatedAt    time.Time  `json:"created_at"     gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Promotion) TableName() string { return "promotions" }
+
+// ตาราง Promotion_uses (ประวัติการใช้โปรโมชัน 1 บิลใช้ได้ 1 โปร)
+type PromotionUse struct {
+	UseID       uint      `json:"use_id"       gorm:"column:use_id;primaryKey;autoIncrement"`
+	PromotionID uint      `json:"promotion_id" gorm:"column:promotion_id;not null;index:idx_promotion_use_user,priority:1"`
+	UserID      uint      `json:"user_id"      gorm:"column:user_id;not null;index:idx_promotion_use_user,priority:2"`
+	PurchaseID  uint      `json:"purchase_id"  gorm:"column:purchase_id;not null;uniqueIndex"`
+	Discount    float64   `json:"discount"     gorm:"column:discount;type:decimal(10,2);not null"`
+	CreatedAt   time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	Promotion *Promotion `json:"-" gorm:"foreignKey:PromotionID;references:PromotionID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+	Purchase  *Purchase  `json:"-" gorm:"foreignKey:PurchaseID;references:PurchaseID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (PromotionUse) TableName() string { return "promotion_uses" }
@@ -2,15 +2,19 @@ package models
 
 // ตาราง Purchases_detail
 type PurchaseDetail struct {
-	PDID       uint   `json:"pd_id"       gorm:"column:pd_id;primaryKey;autoIncrement"`
-	PurchaseID uint   `json:"purchase_id" gorm:"column:purchase_id;not null;index"`
-	LottoID    uint   `json:"lotto_id"    gorm:"column:lotto_id;not null;index"` // ใน DB มี UNIQUE(lotto_id) อยู่แล้ว
-	Status     string `json:"status"      gorm:"column:status;type:enum('ยัง','ถูก','ไม่ถูก');not null;default:'ยัง'"`
-	CashIn     string `json:"cash_in"     gorm:"column:cash_in;type:enum('ซื้อ','ขึ้นเงิน');not null;default:'ซื้อ'"`
+	PDID       uint    `json:"pd_id"       gorm:"column:pd_id;primaryKey;autoIncrement"`
+	PurchaseID uint    `json:"purchase_id" gorm:"column:purchase_id;not null;index"`
+	LottoID    uint    `json:"lotto_id"    gorm:"column:lotto_id;not null;index"` // ใน DB มี UNIQUE(lotto_id) อยู่แล้ว
+	Status     string  `json:"status"      gorm:"column:status;type:enum('ยัง','ถูก','ไม่ถูก');not null;default:'ยัง'"`
+	CashIn     string  `json:"cash_in"     gorm:"column:cash_in;type:enum('ซื้อ','ขึ้นเงิน');not null;default:'ซื้อ'"`
+	VerifyCode *string `json:"verify_code" gorm:"column:verify_code;type:varchar(100);uniqueIndex:idx_pd_verify_code"` // รหัสยืนยันใน QR (ticket.Code)
+	OwnerID    *uint   `json:"owner_id"    gorm:"column:owner_id;index"`                                               // เจ้าของปัจจุบัน (เปลี่ยนเมื่อโอนสลาก)
+	Price      float64 `json:"price"       gorm:"column:price;type:decimal(10,2)"`                                     // ราคาที่ขายจริง (หลังใช้กฎราคา)
 
 	// relations
 	Purchase *Purchase `json:"-" gorm:"foreignKey:PurchaseID;references:PurchaseID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
 	Lotto    *Lotto    `json:"-" gorm:"foreignKey:LottoID;references:LottoID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+	Owner    *User     `json:"-" gorm:"foreignKey:OwnerID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
 }
 
 func (PurchaseDetail) TableName() string { return "purchases_detail" }
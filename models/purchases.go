@@ -1,13 +1,29 @@
 package models
 
-//  ตาราง Purchases
+import "time"
+
+// ตาราง Purchases
 type Purchase struct {
-	PurchaseID uint    `json:"purchase_id" gorm:"column:purchase_id;primaryKey;autoIncrement"`
-	UserID     uint    `json:"user_id"      gorm:"column:user_id;not null;index"`
-	TotalPrice float64 `json:"total_price"  gorm:"column:total_price;type:decimal(10,2);not null"`
+	PurchaseID uint      `json:"purchase_id" gorm:"column:purchase_id;primaryKey;autoIncrement"`
+	UserID     uint      `json:"user_id"      gorm:"column:user_id;not null;index"`
+	TotalPrice float64   `json:"total_price"  gorm:"column:total_price;type:decimal(10,2);not null"`
+	CreatedAt  time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime;default:CURRENT_TIMESTAMP"`
+
+	SyndicateID *uint `json:"syndicate_id" gorm:"column:syndicate_id;index"` // ซื้อในนามกลุ่ม (จ่ายจากเงินกองกลาง)
+
+	// ส่วนลดจากโปรโมชัน (TotalPrice = ยอดที่จ่ายจริงหลังหักส่วนลดแล้ว)
+	Discount    float64 `json:"discount"     gorm:"column:discount;type:decimal(10,2);not null;default:0"`
+	PromotionID *uint   `json:"promotion_id" gorm:"column:promotion_id;index"`
+
+	// จ่ายบางส่วนด้วยแต้มสะสม (PointsValue บาท ไม่รวมใน TotalPrice)
+	PointsUsed  int     `json:"points_used"  gorm:"column:points_used;not null;default:0"`
+	PointsValue float64 `json:"points_value" gorm:"column:points_value;type:decimal(10,2);not null;default:0"`
+
+	AgentID *uint `json:"agent_id" gorm:"column:agent_id;index"` // ตัวแทนที่ขายและจ่ายเงินแทนลูกค้า (UserID = ลูกค้า)
 
 	// relations
 	User             *User            `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+	Syndicate        *Syndicate       `json:"-" gorm:"foreignKey:SyndicateID;references:SyndicateID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
 	PurchasesDetails []PurchaseDetail `json:"-" gorm:"foreignKey:PurchaseID;references:PurchaseID"`
 }
 
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ตาราง Referrals (การชวนเพื่อน 1 แถวต่อผู้ถูกชวน 1 คน)
+// โบนัสจ่ายให้ผู้ชวนเมื่อผู้ถูกชวนซื้อครั้งแรก
+// Email/DeviceID เก็บไว้กันการสมัครซ้ำเพื่อรับโบนัส (อีเมลเก็บแบบตัด . และ +tag แล้ว)
+type Referral struct {
+	ReferralID uint       `json:"referral_id" gorm:"column:referral_id;primaryKey;autoIncrement"`
+	ReferrerID uint       `json:"referrer_id" gorm:"column:referrer_id;not null;index"`
+	RefereeID  uint       `json:"referee_id"  gorm:"column:referee_id;not null;uniqueIndex"`
+	Status     string     `json:"status"      gorm:"column:status;type:enum('pending','rewarded','rejected');not null;default:'pending'"`
+	Reason     string     `json:"reason"      gorm:"column:reason;type:varchar(255)"` // เหตุผลที่ไม่ได้โบนัส
+	Email      string     `json:"-"           gorm:"column:email;type:varchar(255);not null;index"`
+	DeviceID   *string    `json:"-"           gorm:"column:device_id;type:varchar(100);index"`
+	Bonus      float64    `json:"bonus"       gorm:"column:bonus;type:decimal(10,2);not null;default:0"`
+	PurchaseID *uint      `json:"purchase_id" gorm:"column:purchase_id"` // บิลแรกของผู้ถูกชวน
+	CreatedAt  time.Time  `json:"created_at"  gorm:"column:created_at;autoCreateTime"`
+	RewardedAt *time.Time `json:"rewarded_at" gorm:"column:rewarded_at"`
+
+	// relations
+	Referrer *User `json:"-" gorm:"foreignKey:ReferrerID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	Referee  *User `json:"-" gorm:"foreignKey:RefereeID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Referral) TableName() string { return "referrals" }
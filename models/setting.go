@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ตาราง Settings (ค่าตั้งระบบที่ admin แก้ได้ เก็บแบบ key/value)
+type Setting struct {
+	Key       string    `json:"key"        gorm:"column:setting_key;type:varchar(100);primaryKey"`
+	Value     string    `json:"value"      gorm:"column:setting_value;type:varchar(255);not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Setting) TableName() string { return "settings" }
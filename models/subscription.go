@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ตาราง Subscriptions (ซื้ออัตโนมัติทุกงวด)
+// Pattern เป็นเลข 6 หลัก ใช้ x แทนหลักที่เป็นเลขอะไรก็ได้ เช่น "123456", "xxxx89"
+type Subscription struct {
+	SubscriptionID uint      `json:"subscription_id" gorm:"column:subscription_id;primaryKey;autoIncrement"`
+	UserID         uint      `json:"user_id"         gorm:"column:user_id;not null;index"`
+	Pattern        string    `json:"pattern"         gorm:"column:pattern;type:varchar(6);not null"`
+	Quantity       int       `json:"quantity"        gorm:"column:quantity;not null;default:1"`
+	MaxPrice       float64   `json:"max_price"       gorm:"column:max_price;type:decimal(10,2);not null"` // ราคาสูงสุดต่อใบ
+	Active         bool      `json:"active"          gorm:"column:active;not null;default:true"`
+	CreatedAt      time.Time `json:"created_at"      gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Subscription) TableName() string { return "subscriptions" }
+
+// ตาราง Subscription_runs (ผลการซื้ออัตโนมัติของแต่ละงวด กันซื้อซ้ำ)
+type SubscriptionRun struct {
+	RunID          uint      `json:"run_id"          gorm:"column:run_id;primaryKey;autoIncrement"`
+	SubscriptionID uint      `json:"subscription_id" gorm:"column:subscription_id;not null;uniqueIndex:idx_subscription_run,priority:1"`
+	DrawID         uint      `json:"draw_id"         gorm:"column:draw_id;not null;uniqueIndex:idx_subscription_run,priority:2"`
+	Status         string    `json:"status"          gorm:"column:status;type:enum('success','failed');not null"`
+	PurchaseID     *uint     `json:"purchase_id"     gorm:"column:purchase_id"`
+	Bought         int       `json:"bought"          gorm:"column:bought;not null;default:0"`
+	Message        string    `json:"message"         gorm:"column:message;type:varchar(255)"`
+	UpdatedAt      time.Time `json:"updated_at"      gorm:"column:updated_at;autoUpdateTime"`
+
+	// relations
+	Subscription *Subscription `json:"-" gorm:"foreignKey:SubscriptionID;references:SubscriptionID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	Draw         *Draw         `json:"-" gorm:"foreignKey:DrawID;references:DrawID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (SubscriptionRun) TableName() string { return "subscription_runs" }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ตาราง Syndicates (กลุ่มซื้อสลากร่วมกัน)
+// Pool คือเงินสมทบที่ยังไม่ได้ใช้ซื้อสลาก
+type Syndicate struct {
+	SyndicateID uint      `json:"syndicate_id" gorm:"column:syndicate_id;primaryKey;autoIncrement"`
+	Name        string    `json:"name"         gorm:"column:name;type:varchar(255);not null"`
+	CreatorID   uint      `json:"creator_id"   gorm:"column:creator_id;not null;index"`
+	Pool        float64   `json:"pool"         gorm:"column:pool;type:decimal(10,2);not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	Creator *User             `json:"-" gorm:"foreignKey:CreatorID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:RESTRICT"`
+	Members []SyndicateMember `json:"-" gorm:"foreignKey:SyndicateID;references:SyndicateID"`
+}
+
+func (Syndicate) TableName() string { return "syndicates" }
+
+// ตาราง Syndicate_members (สมาชิกกลุ่ม และยอดเงินสมทบ ใช้คิดสัดส่วนรางวัล)
+type SyndicateMember struct {
+	SyndicateID  uint      `json:"syndicate_id" gorm:"column:syndicate_id;primaryKey"`
+	UserID       uint      `json:"user_id"      gorm:"column:user_id;primaryKey;index"`
+	Status       string    `json:"status"       gorm:"column:status;type:enum('invited','joined','declined');not null;default:'invited'"`
+	Contribution float64   `json:"contribution" gorm:"column:contribution;type:decimal(10,2);not null;default:0"`
+	CreatedAt    time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	Syndicate *Syndicate `json:"-" gorm:"foreignKey:SyndicateID;references:SyndicateID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (SyndicateMember) TableName() string { return "syndicate_members" }
+
+// ตาราง Syndicate_shares (สัดส่วนของสมาชิก ณ ตอนที่กลุ่มซื้อสลากบิลนั้น)
+// ใช้แบ่งเงินรางวัลของบิลนั้น ไม่ให้การสมทบเพิ่มหลังรู้ผลเปลี่ยนส่วนแบ่ง
+type SyndicateShare struct {
+	PurchaseID   uint    `json:"purchase_id"  gorm:"column:purchase_id;primaryKey"`
+	UserID       uint    `json:"user_id"      gorm:"column:user_id;primaryKey;index"`
+	Contribution float64 `json:"contribution" gorm:"column:contribution;type:decimal(10,2);not null"`
+
+	// relations
+	Purchase *Purchase `json:"-" gorm:"foreignKey:PurchaseID;references:PurchaseID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	User     *User     `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (SyndicateShare) TableName() string { return "syndicate_shares" }
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ตาราง Ticket_transfers (ประวัติการโอน/ให้สลากระหว่างผู้ใช้)
+type TicketTransfer struct {
+	TransferID uint      `json:"transfer_id"  gorm:"column:transfer_id;primaryKey;autoIncrement"`
+	PDID       uint      `json:"pd_id"        gorm:"column:pd_id;not null;index"`
+	FromUserID uint      `json:"from_user_id" gorm:"column:from_user_id;not null;index"`
+	ToUserID   uint      `json:"to_user_id"   gorm:"column:to_user_id;not null;index"`
+	CreatedAt  time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	PurchaseDetail *PurchaseDetail `json:"-" gorm:"foreignKey:PDID;references:PDID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	FromUser       *User           `json:"-" gorm:"foreignKey:FromUserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	ToUser         *User           `json:"-" gorm:"foreignKey:ToUserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (TicketTransfer) TableName() string { return "ticket_transfers" }
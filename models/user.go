@@ -1,14 +1,25 @@
 package models
 
+import "time"
+
 // ตาราง User
 type User struct {
 	UserID   uint    `json:"user_id" gorm:"column:user_id;primaryKey;autoIncrement"`
 	Username string  `json:"username" gorm:"column:username;type:varchar(255);not null"`
 	Email    string  `json:"email"    gorm:"column:email;type:varchar(255);not null"`
 	Password string  `json:"password" gorm:"column:password;type:varchar(255);not null"`
-	Role     string  `json:"role"     gorm:"column:role;type:enum('member','admin');not null;default:'member'"`
+	Role     string  `json:"role"     gorm:"column:role;type:enum('member','admin','agent');not null;default:'member'"`
 	Wallet   float64 `json:"wallet"   gorm:"column:wallet;type:decimal(10,2);default:0"`
 
+	ExcludedUntil *time.Time `json:"excluded_until,omitempty" gorm:"column:excluded_until"` // พักการซื้อ (self-exclusion) ถึงเวลานี้
+
+	ReferralCode *string `json:"referral_code,omitempty" gorm:"column:referral_code;type:varchar(12);uniqueIndex"` // โค้ดชวนเพื่อนของผู้ใช้
+
+	Points       int `json:"points"        gorm:"column:points;not null;default:0"`        // แต้มสะสมคงเหลือ
+	PointsEarned int `json:"points_earned" gorm:"column:points_earned;not null;default:0"` // แต้มที่เคยได้ทั้งหมด (ใช้จัดระดับสมาชิก)
+
+	Language string `json:"language" gorm:"column:language;type:varchar(2);not null;default:'th'"` // ภาษาของอีเมล (th / en)
+
 	// relations
 	Purchases []Purchase `json:"-" gorm:"foreignKey:UserID;references:UserID"`
 }
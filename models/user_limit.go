@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ตาราง User_limits (วงเงินซื้อที่ผู้ใช้ตั้งเอง แยกตามช่วงเวลา)
+// Amount = nil คือไม่จำกัด, Pending* คือค่าที่จะมีผลเมื่อถึง PendingAt (ใช้ตอนเพิ่มวงเงิน)
+type UserLimit struct {
+	UserID        uint       `json:"user_id"        gorm:"column:user_id;primaryKey"`
+	Period        string     `json:"period"         gorm:"column:period;type:enum('daily','weekly','draw');primaryKey"`
+	Amount        *float64   `json:"amount"         gorm:"column:amount;type:decimal(10,2)"`
+	PendingAmount *float64   `json:"pending_amount" gorm:"column:pending_amount;type:decimal(10,2)"`
+	PendingAt     *time.Time `json:"pending_at"     gorm:"column:pending_at"`
+	UpdatedAt     time.Time  `json:"updated_at"     gorm:"column:updated_at;autoUpdateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (UserLimit) TableName() string { return "user_limits" }
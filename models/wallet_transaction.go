@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ตาราง Wallet_transactions (สมุดบัญชีการเคลื่อนไหวของ wallet)
+// Amount เป็นบวกเมื่อเงินเข้า และติดลบเมื่อเงินออก
+type WalletTransaction struct {
+	TxID         uint      `json:"tx_id"         gorm:"column:tx_id;primaryKey;autoIncrement"`
+	UserID       uint      `json:"user_id"       gorm:"column:user_id;not null;index"`
+	Amount       float64   `json:"amount"        gorm:"column:amount;type:decimal(10,2);not null"`
+	BalanceAfter float64   `json:"balance_after" gorm:"column:balance_after;type:decimal(10,2);not null"`
+	Type         string    `json:"type"          gorm:"column:type;type:varchar(50);not null"`
+	RefID        *uint     `json:"ref_id"        gorm:"column:ref_id"` // id ของรายการที่เกี่ยวข้อง เช่น purchase_id, syndicate_id
+	Note         string    `json:"note"          gorm:"column:note;type:varchar(255)"`
+	CreatedAt    time.Time `json:"created_at"    gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (WalletTransaction) TableName() string { return "wallet_transactions" }
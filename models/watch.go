@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ตาราง Watchlist (เลขที่ผู้ใช้ติดตาม แจ้งเตือนเมื่อมีสลากว่าง)
+// Pattern ใช้รูปแบบเดียวกับ Subscription เช่น "123456", "xxxx89"
+type Watch struct {
+	WatchID     uint      `json:"watch_id"     gorm:"column:watch_id;primaryKey;autoIncrement"`
+	UserID      uint      `json:"user_id"      gorm:"column:user_id;not null;index"`
+	Pattern     string    `json:"pattern"      gorm:"column:pattern;type:varchar(6);not null"`
+	AutoReserve bool      `json:"auto_reserve" gorm:"column:auto_reserve;not null;default:false"` // จองให้อัตโนมัติ 1 ใบเมื่อมีสลากว่าง
+	Active      bool      `json:"active"       gorm:"column:active;not null;default:true"`
+	CreatedAt   time.Time `json:"created_at"   gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	User *User `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+}
+
+func (Watch) TableName() string { return "watchlist" }
+
+// ตาราง Lotto_reservations (จองสลากชั่วคราว คนอื่นซื้อไม่ได้จนกว่าจะหมดเวลา)
+type Reservation struct {
+	ReservationID uint      `json:"reservation_id" gorm:"column:reservation_id;primaryKey;autoIncrement"`
+	LottoID       uint      `json:"lotto_id"       gorm:"column:lotto_id;not null;uniqueIndex"`
+	UserID        uint      `json:"user_id"        gorm:"column:user_id;not null;index"`
+	WatchID       *uint     `json:"watch_id"       gorm:"column:watch_id"`
+	ExpiresAt     time.Time `json:"expires_at"     gorm:"column:expires_at;not null;index"`
+	CreatedAt     time.Time `json:"created_at"     gorm:"column:created_at;autoCreateTime"`
+
+	// relations
+	Lotto *Lotto `json:"-" gorm:"foreignKey:LottoID;references:LottoID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	User  *User  `json:"-" gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:RESTRICT,OnDelete:CASCADE"`
+	Watch *Watch `json:"-" gorm:"foreignKey:WatchID;references:WatchID;constraint:OnUpdate:RESTRICT,OnDelete:SET NULL"`
+}
+
+func (Reservation) TableName() string { return "lotto_reservations" }
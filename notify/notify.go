@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"my-go-project/live"
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ประเภทของการแจ้งเตือน
+const (
+	TypeSubscription = "subscription" // ผลการซื้ออัตโนมัติ
+	TypeTransfer     = "transfer"     // ได้รับโอนสลาก
+	TypeMarket       = "market"       // สลากที่ประกาศขายถูกซื้อ/ถูกยกเลิก
+	TypeWatch        = "watch"        // มีสลากว่างตรงกับเลขที่ติดตาม
+	TypeReferral     = "referral"     // ได้โบนัสชวนเพื่อน
+	TypeLoyalty      = "loyalty"      // เลื่อนระดับสมาชิก
+	TypePurchase     = "purchase"     // ซื้อสลากสำเร็จ
+	TypeWin          = "win"          // สลากที่ถืออยู่ถูกรางวัล
+	TypePrize        = "prize"        // ขึ้นเงินรางวัลเข้ากระเป๋าแล้ว
+)
+
+// Publish เพิ่มข้อความเข้ากล่องแจ้งเตือนของผู้ใช้
+// data (ถ้ามี) จะถูกเก็บเป็น JSON สำหรับให้ client เปิดหน้าที่เกี่ยวข้อง
+func Publish(db *gorm.DB, userID uint, typ, title, body string, data any) error {
+	n := models.Notification{
+		UserID: userID,
+		Type:   typ,
+		Title:  title,
+		Body:   body,
+	}
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		s := string(b)
+		n.Data = &s
+	}
+	if err := db.Create(&n).Error; err != nil {
+		return err
+	}
+	// ส่งเข้า stream ของผู้ใช้ทันที ยกเว้นอยู่ใน transaction (อาจถูก rollback ผู้ใช้จะเห็นตอนเปิดกล่องข้อความแทน)
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+		live.ToUser(userID, live.TypeNotification, n)
+	}
+	return nil
+}
+
+// PublishQuiet เหมือน Publish แต่แค่ log เมื่อผิดพลาด
+// ใช้หลังงานหลักสำเร็จแล้ว ไม่ให้การแจ้งเตือนทำให้คำขอล้มเหลว
+func PublishQuiet(db *gorm.DB, userID uint, typ, title, body string, data any) {
+	if err := Publish(db, userID, typ, title, body, data); err != nil {
+		log.Printf("notify: failed to publish %s to user %d: %v", typ, userID, err)
+	}
+}
+
+// UnreadCount จำนวนข้อความที่ยังไม่ได้อ่าน
+func UnreadCount(db *gorm.DB, userID uint) (int64, error) {
+	var n int64
+	err := db.Raw("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&n).Error
+	return n, err
+}
+
+// MarkRead ทำเครื่องหมายว่าอ่านแล้ว (ids ว่าง = ทุกข้อความของผู้ใช้)
+// ข้อความที่อ่านไปแล้วจะไม่ถูกเปลี่ยนเวลาอ่าน คืนจำนวนข้อความที่เพิ่งถูกอ่าน
+func MarkRead(db *gorm.DB, userID uint, ids []uint) (int64, error) {
+	sql := "UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL"
+	args := []interface{}{time.Now(), userID}
+	if len(ids) > 0 {
+		sql += " AND notification_id IN ?"
+		args = append(args, ids)
+	}
+	res := db.Exec(sql, args...)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"my-go-project/live"
+	"my-go-project/mailer"
+	"my-go-project/push"
+
+	"gorm.io/gorm"
+)
+
+// Winners แจ้งผู้ถือสลากที่ถูกรางวัลของงวดที่เพิ่งประกาศผล (ยังไม่ขึ้นเงิน)
+// สลากของกลุ่ม (syndicate) แจ้งสมาชิกทุกคนในกลุ่ม
+// (กล่องข้อความ + push ไปยังเครื่องของผู้ใช้ + อีเมล)
+// drawIDs ว่าง = สลากที่ไม่ผูกกับงวด (ข้อมูลแบบเก่า) คืนจำนวนผู้ใช้ที่ได้รับแจ้ง
+func Winners(db *gorm.DB, drawIDs []uint) (int, error) {
+	drawCond := "l.draw_id IS NULL"
+	var drawArgs []interface{}
+	if len(drawIDs) > 0 {
+		drawCond = "l.draw_id IN ?"
+		drawArgs = []interface{}{drawIDs}
+	}
+
+	type row struct {
+		UserID      uint
+		LottoNumber string
+	}
+	var rows []row
+	args := append(append([]interface{}{}, drawArgs...), drawArgs...)
+	if err := db.Raw(`
+		SELECT COALESCE(pd.owner_id, p.user_id) AS user_id, l.lotto_number
+		FROM purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		WHERE pd.status = 'ถูก' AND pd.cash_in <> 'ขึ้นเงิน' AND p.syndicate_id IS NULL AND `+drawCond+`
+		UNION ALL
+		SELECT sm.user_id, l.lotto_number
+		FROM purchases_detail AS pd
+		JOIN purchases AS p ON p.purchase_id = pd.purchase_id
+		JOIN lotto AS l ON l.lotto_id = pd.lotto_id
+		JOIN syndicate_members AS sm ON sm.syndicate_id = p.syndicate_id AND sm.status = 'joined'
+		WHERE pd.status = 'ถูก' AND pd.cash_in <> 'ขึ้นเงิน' AND `+drawCond, args...).Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	// รวมเป็นข้อความเดียวต่อผู้ใช้
+	numbers := map[uint][]string{}
+	tickets := map[uint]int{}
+	for _, r := range rows {
+		tickets[r.UserID]++
+		if !contains(numbers[r.UserID], r.LottoNumber) {
+			numbers[r.UserID] = append(numbers[r.UserID], r.LottoNumber)
+		}
+	}
+	for userID, nums := range numbers {
+		sort.Strings(nums)
+		title := "ยินดีด้วย! สลากของคุณถูกรางวัล"
+		body := fmt.Sprintf("สลากเลข %s ถูกรางวัล (%d ใบ) ขึ้นเงินได้ที่หน้าตรวจรางวัล", strings.Join(nums, ", "), tickets[userID])
+		PublishQuiet(db, userID, TypeWin, title, body, map[string]any{"draw_ids": drawIDs, "lotto_numbers": nums})
+		push.ToUserQuiet(db, userID, push.Message{
+			Title: title,
+			Body:  body,
+			Data:  map[string]string{"type": TypeWin, "lotto_numbers": strings.Join(nums, ",")},
+		})
+		mailer.ToUserQuiet(db, userID, mailer.TemplateWin, &mailer.WinData{LottoNumbers: nums, Tickets: tickets[userID]})
+		live.TicketStatus(userID, "won", map[string]any{"draw_ids": drawIDs, "lotto_numbers": nums, "tickets": tickets[userID]})
+	}
+	return len(numbers), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+// Package numquery แปลงคำค้นเลขสลากแบบย่อ เป็นเงื่อนไข SQL ที่ใช้ index ได้
+//
+// คำค้นคั่นด้วยช่องว่างหรือจุลภาค ทุกคำต้องเป็นจริงพร้อมกัน (AND)
+//
+//	123456    ตรงทั้ง 6 หลัก
+//	89        มีเลข 89 อยู่ตรงไหนก็ได้ (1-5 หลัก)
+//	xx5x7x    กำหนดทีละหลัก x หรือ * = หลักใดก็ได้
+//	^1        ขึ้นต้นด้วย 1          (หรือ start:1)
+//	89$       ลงท้ายด้วย 89          (หรือ end:89)
+//	!4        ไม่มีเลข 4 เลย        (หรือ no:4, !48 = ไม่มีทั้ง 4 และ 8)
+//	sum:27    ผลรวมทุกหลักเท่ากับ 27 (sum:20-30 = ช่วง)
+package numquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxTerms จำนวนคำค้นสูงสุดต่อคำขอ
+const MaxTerms = 10
+
+// SyntaxError คำค้นที่อ่านไม่ออก
+type SyntaxError struct {
+	Term   string
+	Reason string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid term %q: %s", e.Term, e.Reason)
+}
+
+// Query เงื่อนไขที่แปลงแล้ว ใช้ต่อท้าย WHERE ได้ทันที
+// คอลัมน์อ้างผ่าน prefix ที่ส่งให้ Parse (เช่น "l." หรือ "")
+type Query struct {
+	Where string
+	Args  []interface{}
+}
+
+// Parse แปลงคำค้นเป็น Query (คืน *SyntaxError ถ้าคำค้นผิดรูปแบบ)
+// prefix คือชื่อตาราง/alias ของ lotto พร้อมจุด เช่น "l." ส่ง "" ถ้าไม่ใช้ alias
+func Parse(input, prefix string) (*Query, error) {
+	terms := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t'
+	})
+	if len(terms) == 0 {
+		return nil, &SyntaxError{Term: input, Reason: "empty query"}
+	}
+	if len(terms) > MaxTerms {
+		return nil, &SyntaxError{Term: input, Reason: fmt.Sprintf("at most %d terms", MaxTerms)}
+	}
+
+	number := prefix + "lotto_number"
+	reversed := prefix + "lotto_number_rev"
+	digitSum := prefix + "digit_sum"
+
+	var clauses []string
+	q := &Query{}
+	for _, raw := range terms {
+		t := strings.ToLower(raw)
+		switch {
+		case strings.HasPrefix(t, "^") || strings.HasPrefix(t, "start:"):
+			d := strings.TrimPrefix(strings.TrimPrefix(t, "^"), "start:")
+			if !isDigits(d, 1, 6) {
+				return nil, &SyntaxError{Term: raw, Reason: "prefix must be 1-6 digits"}
+			}
+			clauses = append(clauses, number+" LIKE ?")
+			q.Args = append(q.Args, d+"%")
+
+		case strings.HasSuffix(t, "$") || strings.HasPrefix(t, "end:"):
+			d := strings.TrimSuffix(strings.TrimPrefix(t, "end:"), "$")
+			if !isDigits(d, 1, 6) {
+				return nil, &SyntaxError{Term: raw, Reason: "suffix must be 1-6 digits"}
+			}
+			// ค้นท้ายเลขผ่านคอลัมน์เลขกลับด้าน จึงเป็น LIKE 'ขึ้นต้น%' ที่ใช้ index ได้
+			clauses = append(clauses, reversed+" LIKE ?")
+			q.Args = append(q.Args, reverse(d)+"%")
+
+		case strings.HasPrefix(t, "!") || strings.HasPrefix(t, "no:"):
+			d := strings.TrimPrefix(strings.TrimPrefix(t, "!"), "no:")
+			if !isDigits(d, 1, 10) {
+				return nil, &SyntaxError{Term: raw, Reason: "excluded digits must be 0-9"}
+			}
+			for _, r := range d {
+				clauses = append(clauses, number+" NOT LIKE ?")
+				q.Args = append(q.Args, "%"+string(r)+"%")
+			}
+
+		case strings.HasPrefix(t, "sum:"):
+			lo, hi, err := parseRange(strings.TrimPrefix(t, "sum:"))
+			if err != "" {
+				return nil, &SyntaxError{Term: raw, Reason: err}
+			}
+			if lo == hi {
+				clauses = append(clauses, digitSum+" = ?")
+				q.Args = append(q.Args, lo)
+			} else {
+				clauses = append(clauses, digitSum+" BETWEEN ? AND ?")
+				q.Args = append(q.Args, lo, hi)
+			}
+
+		case len(t) == 6 && isDigits(t, 6, 6):
+			clauses = append(clauses, number+" = ?")
+			q.Args = append(q.Args, t)
+
+		case len(t) == 6 && isPositional(t):
+			clauses = append(clauses, number+" LIKE ?")
+			q.Args = append(q.Args, positionalLike(t))
+
+		case isDigits(t, 1, 5):
+			clauses = append(clauses, number+" LIKE ?")
+			q.Args = append(q.Args, "%"+t+"%")
+
+		default:
+			return nil, &SyntaxError{Term: raw, Reason: "unknown term"}
+		}
+	}
+
+	q.Where = strings.Join(clauses, " AND ")
+	return q, nil
+}
+
+func isDigits(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isPositional(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && c != 'x' && c != '*' && c != '?' {
+			return false
+		}
+	}
+	return true
+}
+
+// positionalLike แปลง xx5x7x เป็น __5_7_ (ถ้าหลักแรกระบุไว้ MySQL ใช้ index ได้)
+func positionalLike(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c == 'x' || c == '*' || c == '?' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// parseRange อ่าน "27" หรือ "20-30" (ผลรวม 6 หลักอยู่ระหว่าง 0-54)
+func parseRange(s string) (int, int, string) {
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hiStr = loStr
+	}
+	lo, err1 := strconv.Atoi(loStr)
+	hi, err2 := strconv.Atoi(hiStr)
+	if err1 != nil || err2 != nil {
+		return 0, 0, "sum must be a number or range like 20-30"
+	}
+	if lo < 0 || hi > 54 || lo > hi {
+		return 0, 0, "sum must be within 0-54"
+	}
+	return lo, hi, ""
+}
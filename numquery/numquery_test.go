@@ -0,0 +1,69 @@
+package numquery
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		prefix    string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"123456", "", "lotto_number = ?", []interface{}{"123456"}},
+		{"89", "", "lotto_number LIKE ?", []interface{}{"%89%"}},
+		{"xx5x7*", "", "lotto_number LIKE ?", []interface{}{"__5_7_"}},
+		{"^1", "", "lotto_number LIKE ?", []interface{}{"1%"}},
+		{"start:12", "", "lotto_number LIKE ?", []interface{}{"12%"}},
+		// ลงท้ายค้นผ่านคอลัมน์เลขกลับด้าน
+		{"89$", "", "lotto_number_rev LIKE ?", []interface{}{"98%"}},
+		{"END:123", "", "lotto_number_rev LIKE ?", []interface{}{"321%"}},
+		{"!48", "", "lotto_number NOT LIKE ? AND lotto_number NOT LIKE ?", []interface{}{"%4%", "%8%"}},
+		{"no:0", "", "lotto_number NOT LIKE ?", []interface{}{"%0%"}},
+		{"sum:27", "", "digit_sum = ?", []interface{}{27}},
+		{"sum:20-30", "", "digit_sum BETWEEN ? AND ?", []interface{}{20, 30}},
+		{"^1 89$", "l.", "l.lotto_number LIKE ? AND l.lotto_number_rev LIKE ?", []interface{}{"1%", "98%"}},
+		{"^1,!4\tsum:0-54", "", "lotto_number LIKE ? AND lotto_number NOT LIKE ? AND digit_sum BETWEEN ? AND ?", []interface{}{"1%", "%4%", 0, 54}},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.input, tt.prefix)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if q.Where != tt.wantWhere {
+			t.Errorf("Parse(%q).Where = %q, want %q", tt.input, q.Where, tt.wantWhere)
+		}
+		if !reflect.DeepEqual(q.Args, tt.wantArgs) {
+			t.Errorf("Parse(%q).Args = %v, want %v", tt.input, q.Args, tt.wantArgs)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"^",
+		"^1234567",
+		"12a$",
+		"!",
+		"!4a",
+		"sum:55",
+		"sum:30-20",
+		"sum:abc",
+		"1234567",
+		"12x4567",
+		"hello",
+		"1 2 3 4 5 6 7 8 9 0 1", // เกิน MaxTerms
+	} {
+		q, err := Parse(input, "")
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) = %+v, %v, want *SyntaxError", input, q, err)
+		}
+	}
+}
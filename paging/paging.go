@@ -0,0 +1,120 @@
+// Package paging แบ่งหน้าแบบ cursor (keyset) และรูปแบบ response ของ endpoint ที่คืนรายการ
+//
+// cursor เป็นค่าคอลัมน์ที่ใช้เรียง + id ของแถวสุดท้ายในหน้าก่อน (เข้ารหัส base64)
+// หน้าถัดไปจึงใช้ WHERE (col, id) > (?, ?) แทน OFFSET ไม่ช้าลงเมื่อข้อมูลเยอะ
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor ตำแหน่งของแถวสุดท้ายในหน้าก่อน
+type Cursor struct {
+	Value string `json:"v"`  // ค่าคอลัมน์ที่ใช้เรียง
+	ID    uint   `json:"id"` // id ของแถว (ตัดสินเมื่อค่าเท่ากัน)
+}
+
+// Encode แปลง cursor เป็นสตริงสำหรับส่งให้ client
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode อ่าน cursor ที่ client ส่งกลับมา
+func Decode(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Params ค่าแบ่งหน้าจาก query string (?limit=&cursor=)
+type Params struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+// FromQuery อ่าน limit/cursor (limit ที่ผิดหรือเกิน max ใช้ค่า def)
+func FromQuery(c *gin.Context, def, max int) (Params, error) {
+	p := Params{Limit: def}
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= max {
+		p.Limit = n
+	}
+	if s := c.Query("cursor"); s != "" {
+		cur, err := Decode(s)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = cur
+	}
+	return p, nil
+}
+
+// After เงื่อนไข WHERE สำหรับแถวที่อยู่หลัง cursor ตามลำดับ (col, idCol)
+// คืน "" ถ้าเป็นหน้าแรก ถ้า col == idCol จะเทียบแค่ id
+func (p Params) After(col, idCol string, desc bool) (string, []interface{}) {
+	if p.Cursor == nil {
+		return "", nil
+	}
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if col == idCol {
+		return fmt.Sprintf("%s %s ?", idCol, op), []interface{}{p.Cursor.ID}
+	}
+	return fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", col, op, col, idCol, op),
+		[]interface{}{p.Cursor.Value, p.Cursor.Value, p.Cursor.ID}
+}
+
+// Page ผลลัพธ์ 1 หน้า
+type Page struct {
+	Count      int    // จำนวนแถวในหน้านี้
+	Total      int64  // จำนวนแถวทั้งหมดที่ตรงเงื่อนไข
+	Limit      int    // ขนาดหน้า
+	NextCursor string // "" = หน้าสุดท้าย
+}
+
+// Trim ตัดแถวเกินที่ query มาเพื่อดูว่ามีหน้าถัดไปไหม (query ด้วย LIMIT p.Limit+1)
+// คืนจำนวนแถวที่เหลือ และ true ถ้ายังมีหน้าถัดไป
+func (p Params) Trim(n int) (int, bool) {
+	if n > p.Limit {
+		return p.Limit, true
+	}
+	return n, false
+}
+
+// Respond ส่ง response รูปแบบเดียวกันทุก endpoint ที่คืนรายการ
+// { status, data, count, total, limit, next_cursor, has_more, ...extra }
+func Respond(c *gin.Context, data any, page Page, extra gin.H) {
+	body := gin.H{
+		"status":      "success",
+		"data":        data,
+		"count":       page.Count,
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"next_cursor": nil,
+		"has_more":    page.NextCursor != "",
+	}
+	if page.NextCursor != "" {
+		body["next_cursor"] = page.NextCursor
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,219 @@
+// Package pricing คำนวณราคาขายจริงของสลากจากราคาหน้าตั๋ว (lotto.price) และกฎใน price_rules
+//
+// กฎที่ตรงเงื่อนไขจะถูกใช้ต่อกันตามลำดับ priority:
+// percent = บวก/ลบเป็น % ของราคาขณะนั้น, amount = บวก/ลบเป็นบาท, fixed = ตั้งราคาใหม่
+package pricing
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+// MinPrice ราคาต่ำสุดต่อใบหลังปรับราคา
+const MinPrice = 1.0
+
+const (
+	AdjustPercent = "percent"
+	AdjustAmount  = "amount"
+	AdjustFixed   = "fixed"
+)
+
+var (
+	ErrInvalidAdjust  = errors.New("adjust must be percent, amount or fixed")
+	ErrInvalidPattern = errors.New(`pattern must be "double", "triple" or 6 characters of digits/x`)
+	ErrInvalidValue   = errors.New("fixed price must be greater than 0")
+)
+
+// Applied กฎที่ถูกใช้กับสลาก 1 ใบ
+type Applied struct {
+	RuleID uint    `json:"rule_id"`
+	Name   string  `json:"name"`
+	Adjust string  `json:"adjust"`
+	Value  float64 `json:"value"`
+}
+
+// Quote ราคาของสลาก 1 ใบ
+type Quote struct {
+	LottoID     uint      `json:"lotto_id"`
+	LottoNumber string    `json:"lotto_number"`
+	DrawID      *uint     `json:"draw_id"`
+	BasePrice   float64   `json:"base_price"`
+	Price       float64   `json:"price"`
+	Remaining   int       `json:"remaining"` // เลขเดียวกันในงวดที่ยังขายได้ (รวมใบนี้)
+	Rules       []Applied `json:"rules"`
+}
+
+// Validate ตรวจกฎก่อนบันทึก
+func Validate(r *models.PriceRule) error {
+	switch r.Adjust {
+	case AdjustPercent, AdjustAmount:
+	case AdjustFixed:
+		if r.Value <= 0 {
+			return ErrInvalidValue
+		}
+	default:
+		return ErrInvalidAdjust
+	}
+	if r.Pattern != nil && !validPattern(*r.Pattern) {
+		return ErrInvalidPattern
+	}
+	return nil
+}
+
+func validPattern(p string) bool {
+	if p == "double" || p == "triple" {
+		return true
+	}
+	if len(p) != 6 {
+		return false
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] != 'x' && (p[i] < '0' || p[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchPattern ตรวจว่าเลขสลากตรงกับ Pattern ของกฎ
+func MatchPattern(pattern, number string) bool {
+	n := len(number)
+	switch pattern {
+	case "double":
+		return n >= 2 && number[n-1] == number[n-2]
+	case "triple":
+		return n >= 3 && number[n-1] == number[n-2] && number[n-2] == number[n-3]
+	}
+	if len(pattern) != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if pattern[i] != 'x' && pattern[i] != number[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Rules กฎที่เปิดใช้ เรียงตามลำดับที่ต้องคำนวณ
+func Rules(db *gorm.DB) ([]models.PriceRule, error) {
+	var rules []models.PriceRule
+	err := db.Where("active = ?", true).Order("priority ASC, rule_id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// Apply คำนวณราคาจากกฎ (untilDraw < 0 = ไม่ทราบวันออกรางวัล กฎแบบเวลาไม่ถูกใช้)
+func Apply(rules []models.PriceRule, base float64, number string, remaining int, untilDraw time.Duration) (float64, []Applied) {
+	price := base
+	applied := []Applied{}
+	for _, r := range rules {
+		if r.Pattern != nil && !MatchPattern(*r.Pattern, number) {
+			continue
+		}
+		if r.MaxRemaining != nil && remaining > *r.MaxRemaining {
+			continue
+		}
+		if r.HoursBefore != nil && (untilDraw < 0 || untilDraw > time.Duration(*r.HoursBefore)*time.Hour) {
+			continue
+		}
+		switch r.Adjust {
+		case AdjustPercent:
+			price += price * r.Value / 100
+		case AdjustAmount:
+			price += r.Value
+		case AdjustFixed:
+			price = r.Value
+		}
+		applied = append(applied, Applied{RuleID: r.RuleID, Name: r.Name, Adjust: r.Adjust, Value: r.Value})
+	}
+	price = math.Round(price*100) / 100
+	if price < MinPrice {
+		price = MinPrice
+	}
+	return price, applied
+}
+
+// QuoteLottos คำนวณราคาขายของสลากแต่ละใบ ณ เวลา now (key = lotto_id)
+func QuoteLottos(db *gorm.DB, lottos []models.Lotto, now time.Time) (map[uint]Quote, error) {
+	quotes := make(map[uint]Quote, len(lottos))
+	if len(lottos) == 0 {
+		return quotes, nil
+	}
+	rules, err := Rules(db)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make([]string, 0, len(lottos))
+	var drawIDs []uint
+	for _, l := range lottos {
+		numbers = append(numbers, l.LottoNumber)
+		if l.DrawID != nil {
+			drawIDs = append(drawIDs, *l.DrawID)
+		}
+	}
+
+	// จำนวนที่ยังขายได้ของแต่ละเลขในแต่ละงวด
+	type stockKey struct {
+		drawID uint
+		number string
+	}
+	var stock []struct {
+		DrawID      *uint
+		LottoNumber string
+		Remaining   int
+	}
+	if err := db.Raw(`
+		SELECT draw_id, lotto_number, COUNT(*) AS remaining
+		FROM lotto
+		WHERE status = 'sell' AND lotto_number IN ?
+		GROUP BY draw_id, lotto_number`, numbers).Scan(&stock).Error; err != nil {
+		return nil, err
+	}
+	remaining := make(map[stockKey]int, len(stock))
+	for _, s := range stock {
+		k := stockKey{number: s.LottoNumber}
+		if s.DrawID != nil {
+			k.drawID = *s.DrawID
+		}
+		remaining[k] = s.Remaining
+	}
+
+	drawDates := map[uint]time.Time{}
+	if len(drawIDs) > 0 {
+		var draws []models.Draw
+		if err := db.Select("draw_id", "draw_date").Where("draw_id IN ?", drawIDs).Find(&draws).Error; err != nil {
+			return nil, err
+		}
+		for _, d := range draws {
+			drawDates[d.DrawID] = d.DrawDate
+		}
+	}
+
+	for _, l := range lottos {
+		k := stockKey{number: l.LottoNumber}
+		until := time.Duration(-1)
+		if l.DrawID != nil {
+			k.drawID = *l.DrawID
+			if d, ok := drawDates[*l.DrawID]; ok {
+				until = max(d.Sub(now), 0)
+			}
+		}
+		price, applied := Apply(rules, l.Price, l.LottoNumber, remaining[k], until)
+		quotes[l.LottoID] = Quote{
+			LottoID:     l.LottoID,
+			LottoNumber: l.LottoNumber,
+			DrawID:      l.DrawID,
+			BasePrice:   l.Price,
+			Price:       price,
+			Remaining:   remaining[k],
+			Rules:       applied,
+		}
+	}
+	return quotes, nil
+}
@@ -0,0 +1,118 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"my-go-project/models"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(n int) *int       { return &n }
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, number string
+		want            bool
+	}{
+		{"double", "123455", true},
+		{"double", "123456", false},
+		{"triple", "123444", true},
+		{"triple", "123344", false},
+		{"xxxx89", "123489", true},
+		{"xxxx89", "123498", false},
+		{"1xxxxx", "199999", true},
+		{"123456", "123456", true},
+		{"12345", "123456", false}, // ความยาวไม่เท่ากัน
+	}
+	for _, tt := range tests {
+		if got := MatchPattern(tt.pattern, tt.number); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.PriceRule
+		want error
+	}{
+		{"percent", models.PriceRule{Adjust: AdjustPercent, Value: -10}, nil},
+		{"amount with pattern", models.PriceRule{Adjust: AdjustAmount, Value: 20, Pattern: strPtr("xxxx89")}, nil},
+		{"double", models.PriceRule{Adjust: AdjustAmount, Value: 20, Pattern: strPtr("double")}, nil},
+		{"fixed", models.PriceRule{Adjust: AdjustFixed, Value: 100}, nil},
+		{"fixed zero", models.PriceRule{Adjust: AdjustFixed, Value: 0}, ErrInvalidValue},
+		{"unknown adjust", models.PriceRule{Adjust: "double"}, ErrInvalidAdjust},
+		{"short pattern", models.PriceRule{Adjust: AdjustAmount, Pattern: strPtr("xx89")}, ErrInvalidPattern},
+		{"bad pattern char", models.PriceRule{Adjust: AdjustAmount, Pattern: strPtr("xxxx8?")}, ErrInvalidPattern},
+	}
+	for _, tt := range tests {
+		if err := Validate(&tt.rule); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestApplyChainsRulesInOrder(t *testing.T) {
+	rules := []models.PriceRule{
+		{RuleID: 1, Adjust: AdjustPercent, Value: 10},                           // 80 → 88
+		{RuleID: 2, Adjust: AdjustAmount, Value: 12, Pattern: strPtr("double")}, // 88 → 100
+		{RuleID: 3, Adjust: AdjustAmount, Value: 50, Pattern: strPtr("triple")}, // ไม่ตรง
+	}
+	price, applied := Apply(rules, 80, "123455", 10, -1)
+	if price != 100 {
+		t.Errorf("price = %v, want 100", price)
+	}
+	if len(applied) != 2 || applied[0].RuleID != 1 || applied[1].RuleID != 2 {
+		t.Errorf("applied = %+v, want rules 1 and 2", applied)
+	}
+}
+
+func TestApplyFixedReplacesPrice(t *testing.T) {
+	rules := []models.PriceRule{
+		{RuleID: 1, Adjust: AdjustAmount, Value: 40},
+		{RuleID: 2, Adjust: AdjustFixed, Value: 90},
+		{RuleID: 3, Adjust: AdjustPercent, Value: 10},
+	}
+	if price, _ := Apply(rules, 80, "000000", 1, -1); price != 99 {
+		t.Errorf("price = %v, want 99", price)
+	}
+}
+
+func TestApplyRemainingAndTimeConditions(t *testing.T) {
+	rules := []models.PriceRule{
+		{RuleID: 1, Adjust: AdjustAmount, Value: 20, MaxRemaining: intPtr(2)},
+		{RuleID: 2, Adjust: AdjustPercent, Value: -50, HoursBefore: intPtr(24)},
+	}
+	tests := []struct {
+		name      string
+		remaining int
+		untilDraw time.Duration
+		want      float64
+	}{
+		{"plenty left, far from draw", 10, 72 * time.Hour, 80},
+		{"few left", 2, 72 * time.Hour, 100},
+		{"close to draw", 10, 12 * time.Hour, 40},
+		{"few left and close to draw", 1, 24 * time.Hour, 50},
+		{"unknown draw date", 10, -1, 80},
+	}
+	for _, tt := range tests {
+		if price, _ := Apply(rules, 80, "123456", tt.remaining, tt.untilDraw); price != tt.want {
+			t.Errorf("%s: price = %v, want %v", tt.name, price, tt.want)
+		}
+	}
+}
+
+func TestApplyRoundsAndClampsToMinPrice(t *testing.T) {
+	if price, _ := Apply([]models.PriceRule{{Adjust: AdjustPercent, Value: 33.333}}, 80, "123456", 1, -1); price != 106.67 {
+		t.Errorf("price = %v, want 106.67", price)
+	}
+	if price, _ := Apply([]models.PriceRule{{Adjust: AdjustAmount, Value: -500}}, 80, "123456", 1, -1); price != MinPrice {
+		t.Errorf("price = %v, want %v", price, MinPrice)
+	}
+	if price, applied := Apply(nil, 80, "123456", 1, -1); price != 80 || len(applied) != 0 {
+		t.Errorf("no rules: price = %v, applied = %v", price, applied)
+	}
+}
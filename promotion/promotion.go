@@ -0,0 +1,239 @@
+// Package promotion คิดส่วนลดจากโค้ดโปรโมชันตอนซื้อ (ใช้ภายใน transaction ของ purchase.Buy)
+package promotion
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	KindPercent = "percent"
+	KindFixed   = "fixed"
+	KindBundle  = "bundle"
+)
+
+// รหัสข้อผิดพลาดที่ส่งให้ client
+const (
+	CodeNotFound     = "promo_not_found"
+	CodeNotStarted   = "promo_not_started"
+	CodeExpired      = "promo_expired"
+	CodeUserLimit    = "promo_user_limit"
+	CodeTotalLimit   = "promo_total_limit"
+	CodeMinTotal     = "promo_min_total"
+	CodeNotQualified = "promo_not_qualified"
+)
+
+// Error ใช้โค้ดนี้กับบิลนี้ไม่ได้
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+var (
+	ErrInvalidKind   = errors.New("kind must be percent, fixed or bundle")
+	ErrInvalidValue  = errors.New("value must be greater than 0 (percent at most 100)")
+	ErrInvalidBundle = errors.New("bundle needs buy_qty >= 1 and free_qty >= 1")
+	ErrInvalidWindow = errors.New("ends_at must be after starts_at")
+)
+
+// Result โปรที่ใช้กับบิล
+type Result struct {
+	PromotionID uint    `json:"promotion_id"`
+	Code        *string `json:"code"`
+	Name        string  `json:"name"`
+	Discount    float64 `json:"discount"`
+}
+
+// Normalize รูปแบบโค้ดที่เก็บ/ค้นหา (ตัวพิมพ์ใหญ่ ไม่มีช่องว่าง)
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate ตรวจโปรก่อนบันทึก
+func Validate(p *models.Promotion) error {
+	switch p.Kind {
+	case KindPercent:
+		if p.Value <= 0 || p.Value > 100 {
+			return ErrInvalidValue
+		}
+	case KindFixed:
+		if p.Value <= 0 {
+			return ErrInvalidValue
+		}
+	case KindBundle:
+		if p.BuyQty < 1 || p.FreeQty < 1 {
+			return ErrInvalidBundle
+		}
+	default:
+		return ErrInvalidKind
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+// Discount ส่วนลดของโปร p สำหรับบิลที่มีราคาต่อใบ prices (0 = ไม่เข้าเงื่อนไข)
+func Discount(p models.Promotion, prices []float64) float64 {
+	total := sum(prices)
+	if total < p.MinTotal {
+		return 0
+	}
+
+	var discount float64
+	switch p.Kind {
+	case KindPercent:
+		discount = total * p.Value / 100
+	case KindFixed:
+		discount = p.Value
+	case KindBundle:
+		// ทุก ๆ BuyQty+FreeQty ใบ ใบที่ถูกที่สุด FreeQty ใบเป็นของแถม
+		free := len(prices) / (p.BuyQty + p.FreeQty) * p.FreeQty
+		sorted := append([]float64(nil), prices...)
+		sort.Float64s(sorted)
+		for _, price := range sorted[:free] {
+			discount += price
+		}
+	}
+	if p.MaxDiscount > 0 && discount > p.MaxDiscount {
+		discount = p.MaxDiscount
+	}
+	return math.Round(math.Min(discount, total)*100) / 100
+}
+
+// Apply หาโปรสำหรับบิล: ใส่โค้ด → ต้องใช้ได้ ไม่งั้นคืน *Error,
+// ไม่ใส่โค้ด → ใช้โปรอัตโนมัติที่ลดได้มากที่สุด (ไม่มี = nil)
+// ล็อกแถวโปรไว้จนจบ transaction เพื่อนับจำนวนครั้งที่ใช้ได้ถูกต้อง
+func Apply(tx *gorm.DB, userID uint, code string, prices []float64, now time.Time) (*Result, error) {
+	code = Normalize(code)
+	if code == "" {
+		return best(tx, userID, prices, now)
+	}
+
+	var p models.Promotion
+	found := tx.Raw("SELECT * FROM promotions WHERE code = ? AND active = ? FOR UPDATE", code, true).Scan(&p)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, &Error{Code: CodeNotFound, Message: "promotion code not found"}
+	}
+	if err := usable(tx, p, userID, now); err != nil {
+		return nil, err
+	}
+	discount := Discount(p, prices)
+	if discount <= 0 {
+		if total := sum(prices); total < p.MinTotal {
+			return nil, &Error{Code: CodeMinTotal, Message: "order total is below the minimum for this code"}
+		}
+		return nil, &Error{Code: CodeNotQualified, Message: "order does not qualify for this code"}
+	}
+	return &Result{PromotionID: p.PromotionID, Code: p.Code, Name: p.Name, Discount: discount}, nil
+}
+
+// best โปรอัตโนมัติ (ไม่มีโค้ด) ที่ลดได้มากที่สุด
+// เลือกจากรายการโดยไม่ล็อก แล้วล็อกเฉพาะแถวที่ชนะเพื่อตรวจสิทธิ์ซ้ำ ถ้าใช้ไม่ได้จึงลองโปรถัดไป
+func best(tx *gorm.DB, userID uint, prices []float64, now time.Time) (*Result, error) {
+	var promos []models.Promotion
+	if err := tx.Raw(`
+		SELECT * FROM promotions
+		WHERE code IS NULL AND active = ?
+		  AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)
+		ORDER BY promotion_id ASC`, true, now, now).Scan(&promos).Error; err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		id       uint
+		discount float64
+	}
+	var candidates []candidate
+	for _, p := range promos {
+		if discount := Discount(p, prices); discount > 0 {
+			candidates = append(candidates, candidate{p.PromotionID, discount})
+		}
+	}
+	// ลดมากสุดก่อน เท่ากัน → โปรที่สร้างก่อน
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].discount > candidates[j].discount })
+
+	for _, cand := range candidates {
+		var p models.Promotion
+		found := tx.Raw("SELECT * FROM promotions WHERE promotion_id = ? FOR UPDATE", cand.id).Scan(&p)
+		if found.Error != nil {
+			return nil, found.Error
+		}
+		// ถูกปิด/แก้ระหว่างเลือก
+		if found.RowsAffected == 0 || p.Code != nil || !p.Active {
+			continue
+		}
+		discount := Discount(p, prices)
+		if discount <= 0 {
+			continue
+		}
+		if err := usable(tx, p, userID, now); err != nil {
+			var promoErr *Error
+			if errors.As(err, &promoErr) {
+				continue
+			}
+			return nil, err
+		}
+		return &Result{PromotionID: p.PromotionID, Code: p.Code, Name: p.Name, Discount: discount}, nil
+	}
+	return nil, nil
+}
+
+// usable ตรวจช่วงเวลาและจำนวนครั้งที่ใช้ได้
+func usable(tx *gorm.DB, p models.Promotion, userID uint, now time.Time) error {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return &Error{Code: CodeNotStarted, Message: "promotion has not started yet"}
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return &Error{Code: CodeExpired, Message: "promotion has expired"}
+	}
+	if p.TotalLimit > 0 {
+		var used int64
+		if err := tx.Raw("SELECT COUNT(*) FROM promotion_uses WHERE promotion_id = ?", p.PromotionID).Scan(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(p.TotalLimit) {
+			return &Error{Code: CodeTotalLimit, Message: "promotion has been fully redeemed"}
+		}
+	}
+	if p.PerUserLimit > 0 {
+		var used int64
+		if err := tx.Raw("SELECT COUNT(*) FROM promotion_uses WHERE promotion_id = ? AND user_id = ?", p.PromotionID, userID).Scan(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(p.PerUserLimit) {
+			return &Error{Code: CodeUserLimit, Message: "you have already used this promotion the maximum number of times"}
+		}
+	}
+	return nil
+}
+
+// Record บันทึกการใช้โปรของบิล
+func Record(tx *gorm.DB, res *Result, userID, purchaseID uint) error {
+	return tx.Create(&models.PromotionUse{
+		PromotionID: res.PromotionID,
+		UserID:      userID,
+		PurchaseID:  purchaseID,
+		Discount:    res.Discount,
+	}).Error
+}
+
+func sum(prices []float64) float64 {
+	var total float64
+	for _, p := range prices {
+		total += p
+	}
+	return total
+}
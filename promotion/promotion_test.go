@@ -0,0 +1,73 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"my-go-project/models"
+)
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("  new5free1 "); got != "NEW5FREE1" {
+		t.Errorf("Normalize = %q, want NEW5FREE1", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+	tests := []struct {
+		name  string
+		promo models.Promotion
+		want  error
+	}{
+		{"percent", models.Promotion{Kind: KindPercent, Value: 10}, nil},
+		{"percent over 100", models.Promotion{Kind: KindPercent, Value: 101}, ErrInvalidValue},
+		{"fixed zero", models.Promotion{Kind: KindFixed}, ErrInvalidValue},
+		{"bundle", models.Promotion{Kind: KindBundle, BuyQty: 5, FreeQty: 1}, nil},
+		{"bundle without free", models.Promotion{Kind: KindBundle, BuyQty: 5}, ErrInvalidBundle},
+		{"unknown kind", models.Promotion{Kind: "cashback", Value: 10}, ErrInvalidKind},
+		{"ends before start", models.Promotion{Kind: KindFixed, Value: 10, StartsAt: &start, EndsAt: &end}, ErrInvalidWindow},
+	}
+	for _, tt := range tests {
+		if err := Validate(&tt.promo); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	five := []float64{80, 80, 80, 80, 80}
+	tests := []struct {
+		name   string
+		promo  models.Promotion
+		prices []float64
+		want   float64
+	}{
+		{"percent", models.Promotion{Kind: KindPercent, Value: 10}, five, 40},
+		{"percent capped", models.Promotion{Kind: KindPercent, Value: 10, MaxDiscount: 25}, five, 25},
+		{"fixed", models.Promotion{Kind: KindFixed, Value: 30}, five, 30},
+		{"fixed larger than bill", models.Promotion{Kind: KindFixed, Value: 500}, []float64{80, 80}, 160},
+		{"below min total", models.Promotion{Kind: KindFixed, Value: 30, MinTotal: 500}, five, 0},
+		{"at min total", models.Promotion{Kind: KindFixed, Value: 30, MinTotal: 400}, five, 30},
+		// ซื้อ 5 แถม 1: ครบ 6 ใบ ใบที่ถูกที่สุดฟรี
+		{"bundle frees cheapest", models.Promotion{Kind: KindBundle, BuyQty: 5, FreeQty: 1}, []float64{100, 80, 90, 120, 80, 70}, 70},
+		{"bundle not enough tickets", models.Promotion{Kind: KindBundle, BuyQty: 5, FreeQty: 1}, five, 0},
+		{"bundle twice", models.Promotion{Kind: KindBundle, BuyQty: 2, FreeQty: 1}, []float64{80, 80, 80, 90, 90, 90, 100}, 160},
+		{"rounds to satang", models.Promotion{Kind: KindPercent, Value: 33.333}, []float64{80}, 26.67},
+	}
+	for _, tt := range tests {
+		if got := Discount(tt.promo, tt.prices); got != tt.want {
+			t.Errorf("%s: Discount = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiscountDoesNotReorderPrices(t *testing.T) {
+	prices := []float64{100, 70, 90}
+	Discount(models.Promotion{Kind: KindBundle, BuyQty: 2, FreeQty: 1}, prices)
+	if prices[0] != 100 || prices[1] != 70 || prices[2] != 90 {
+		t.Errorf("prices changed to %v", prices)
+	}
+}
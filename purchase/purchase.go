@@ -0,0 +1,435 @@
+package purchase
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"my-go-project/agent"
+	"my-go-project/limits"
+	"my-go-project/loyalty"
+	"my-go-project/models"
+	"my-go-project/pricing"
+	"my-go-project/promotion"
+	"my-go-project/referral"
+	"my-go-project/syndicate"
+	"my-go-project/ticket"
+	"my-go-project/wallet"
+
+	"gorm.io/gorm"
+)
+
+// Request คำสั่งซื้อ 1 บิล: เลือกใบเอง (LottoIDs) และ/หรือ ซื้อตามเลข + จำนวนใบ (Numbers)
+type Request struct {
+	UserID      uint
+	LottoIDs    []uint
+	Numbers     []Number
+	SyndicateID *uint  // ซื้อในนามกลุ่ม จ่ายจากเงินกองกลางแทน wallet
+	PromoCode   string // โค้ดส่วนลด (ว่าง = ใช้โปรอัตโนมัติถ้ามี)
+	Points      int    // แต้มสะสมที่ใช้จ่ายบางส่วน (0 = ไม่ใช้)
+	AgentID     *uint  // ตัวแทนซื้อแทนลูกค้า UserID (ตัวแทนจ่ายเงิน ลูกค้าเป็นเจ้าของสลาก)
+}
+
+// Number ซื้อเลขเดียวกันหลายใบ (หลายชุด)
+type Number struct {
+	LottoNumber string
+	Quantity    int
+}
+
+// Result ผลการซื้อที่สำเร็จ
+type Result struct {
+	PurchaseID   uint
+	Subtotal     float64           // ยอดรวมก่อนหักส่วนลด
+	Discount     float64           // ส่วนลดจากโปรโมชัน
+	Promotion    *promotion.Result // โปรที่ใช้ (nil = ไม่มี)
+	PointsUsed   int               // แต้มที่ใช้จ่าย
+	PointsValue  float64           // มูลค่าแต้มที่ใช้ (บาท)
+	PointsEarned int               // แต้มที่ได้จากบิลนี้
+	TotalPrice   float64           // ยอดที่จ่ายจริงด้วยเงิน (หลังหักส่วนลดและแต้ม)
+	Items        []map[string]any  // รายการสลากที่ซื้อสำเร็จ (เรียงตาม lotto_id)
+	Wallet       float64           // ยอดเงินในกระเป๋าของผู้จ่ายหลังหักเงิน
+}
+
+var (
+	ErrNoLotto           = errors.New("no lotto ids")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInsufficientFunds = wallet.ErrInsufficientFunds
+
+	ErrDrawClosed          = errors.New("draw is closed for sale")
+	ErrPointsWithSyndicate = errors.New("points cannot be used for syndicate purchases")
+	ErrAgentOptions        = errors.New("agent purchases cannot use syndicate funds or points")
+)
+
+// NotReservedSQL เงื่อนไข WHERE ตัดสลากที่คนอื่นจองไว้และยังไม่หมดเวลา (ต้องใช้ชื่อตาราง lotto)
+// พารามิเตอร์: user_id ของผู้ซื้อ, เวลาปัจจุบัน
+const NotReservedSQL = `NOT EXISTS (
+	SELECT 1 FROM lotto_reservations AS r
+	WHERE r.lotto_id = lotto.lotto_id AND r.user_id <> ? AND r.expires_at > ?)`
+
+// OpenDrawSQL เงื่อนไข WHERE เฉพาะสลากที่ไม่ระบุงวด หรืองวดยังเปิดขาย (ต้องใช้ชื่อตาราง lotto)
+const OpenDrawSQL = `(lotto.draw_id IS NULL OR EXISTS (
+	SELECT 1 FROM draws AS d WHERE d.draw_id = lotto.draw_id AND d.status = 'open'))`
+
+// NotAvailableError สลากบางใบขายไปแล้ว/ไม่มีอยู่
+type NotAvailableError struct {
+	LottoIDs []uint
+}
+
+func (e *NotAvailableError) Error() string { return "some tickets are not available" }
+
+// NotEnoughError เลขที่เหลือไม่พอตามจำนวนที่ขอ
+type NotEnoughError struct {
+	Numbers []Shortage
+}
+
+type Shortage struct {
+	LottoNumber string `json:"lotto_number"`
+	Requested   int    `json:"requested"`
+	Available   int    `json:"available"`
+}
+
+func (e *NotEnoughError) Error() string { return "not enough copies of some numbers" }
+
+// Buy ซื้อสลากทั้งบิลใน transaction เดียว
+// (ตรวจสลาก, ตรวจวงเงิน, สร้างบิล, เปลี่ยนสถานะ, หักเงิน) ถ้ามี error → rollback ทั้งหมด
+// error ที่อาจได้: ErrNoLotto, ErrUserNotFound, ErrInsufficientFunds, ErrDrawClosed,
+// *NotAvailableError, *NotEnoughError, *limits.Error, *promotion.Error,
+// ErrPointsWithSyndicate, loyalty.ErrInsufficientPoints, *loyalty.RedeemLimitError,
+// ErrAgentOptions, agent.ErrNotAgent, agent.ErrInactive และ error ของ syndicate (ถ้าซื้อในนามกลุ่ม)
+func Buy(db *gorm.DB, req Request) (*Result, error) {
+	//ส่วนของการตัด ID ซ้ำ  ---
+	idset := map[uint]struct{}{}
+	uniq := make([]uint, 0, len(req.LottoIDs))
+	for _, id := range req.LottoIDs {
+		if id == 0 {
+			continue
+		}
+		if _, ok := idset[id]; !ok {
+			idset[id] = struct{}{}
+			uniq = append(uniq, id)
+		}
+	}
+
+	// รวมจำนวนของเลขที่ส่งมาซ้ำ
+	wantByNumber := map[string]int{}
+	numberOrder := make([]string, 0, len(req.Numbers))
+	for _, n := range req.Numbers {
+		if n.Quantity <= 0 {
+			continue
+		}
+		if _, ok := wantByNumber[n.LottoNumber]; !ok {
+			numberOrder = append(numberOrder, n.LottoNumber)
+		}
+		wantByNumber[n.LottoNumber] += n.Quantity
+	}
+	if len(uniq) == 0 && len(numberOrder) == 0 {
+		return nil, ErrNoLotto
+	}
+	if req.AgentID != nil && (req.SyndicateID != nil || req.Points > 0) {
+		return nil, ErrAgentOptions
+	}
+	payerID := req.UserID // ผู้จ่ายเงิน (ตัวแทน หรือผู้ซื้อเอง)
+	var allocatedTo uint  // ซื้อสลากที่แบ่งให้ตัวแทนคนนี้ได้ (0 = ไม่ใช่ตัวแทน)
+	if req.AgentID != nil {
+		payerID = *req.AgentID
+		allocatedTo = *req.AgentID
+	}
+
+	res := &Result{}
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// ล็อกแถวผู้ใช้ไว้จนจบบิล (ยอดเงิน/วงเงินของคนเดียวกันจะไม่ชนกัน)
+		var user models.User
+		result := tx.Raw("SELECT user_id FROM users WHERE user_id = ? FOR UPDATE", req.UserID).Scan(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		var seller *models.Agent
+		if req.AgentID != nil {
+			a, err := agent.Lock(tx, *req.AgentID)
+			if err != nil {
+				return err
+			}
+			seller = a
+		}
+
+		// --- ซื้อตามเลข: เลือกใบที่ยังขายได้ของเลขนั้นตามจำนวนที่ขอ (ชุดที่น้อยก่อน) ---
+		var notEnough []Shortage
+		for _, number := range numberOrder {
+			want := wantByNumber[number]
+			var ids []uint
+			pickSQL := "SELECT lotto_id FROM lotto WHERE lotto_number = ? AND status = ? AND " + NotReservedSQL + " AND " + agent.NotAllocatedSQL + " AND " + OpenDrawSQL + " ORDER BY set_no ASC, lotto_id ASC LIMIT ? FOR UPDATE"
+			if err := tx.Raw(pickSQL, number, "sell", req.UserID, now, allocatedTo, want+len(idset)).Scan(&ids).Error; err != nil {
+				return err
+			}
+			picked := 0
+			for _, id := range ids {
+				if picked == want {
+					break
+				}
+				if _, ok := idset[id]; ok { // เลือกไว้แล้วจาก lotto_ids
+					continue
+				}
+				idset[id] = struct{}{}
+				uniq = append(uniq, id)
+				picked++
+			}
+			if picked < want {
+				notEnough = append(notEnough, Shortage{LottoNumber: number, Requested: want, Available: picked})
+			}
+		}
+		if len(notEnough) > 0 {
+			// ที่ขาดเพราะใบที่เหลืออยู่ในงวดที่ปิดการขายแล้ว → แจ้งว่างวดปิด ไม่ใช่สลากหมด
+			numbers := make([]string, 0, len(notEnough))
+			for _, s := range notEnough {
+				numbers = append(numbers, s.LottoNumber)
+			}
+			closed, err := inClosedDraw(tx, "lotto.lotto_number IN (?) AND lotto.status = 'sell'", numbers)
+			if err != nil {
+				return err
+			}
+			if closed {
+				return ErrDrawClosed
+			}
+			return &NotEnoughError{Numbers: notEnough}
+		}
+
+		var lottos []models.Lotto
+		lockSQL := "SELECT * FROM lotto WHERE lotto_id IN (?) AND status = ? AND " + NotReservedSQL + " AND " + agent.NotAllocatedSQL + " AND " + OpenDrawSQL + " ORDER BY lotto_id ASC FOR UPDATE"
+		if err := tx.Raw(lockSQL, uniq, "sell", req.UserID, now, allocatedTo).Scan(&lottos).Error; err != nil {
+			return err
+		}
+
+		// --- ตรวจสอบว่าสลากครบไหม  ---
+		if len(lottos) != len(uniq) {
+			found := make(map[uint]struct{}, len(lottos))
+			for _, l := range lottos {
+				found[l.LottoID] = struct{}{}
+			}
+			var notAvailable []uint
+			for _, id := range uniq {
+				if _, ok := found[id]; !ok {
+					notAvailable = append(notAvailable, id)
+				}
+			}
+			closed, err := inClosedDraw(tx, "lotto.lotto_id IN (?)", notAvailable)
+			if err != nil {
+				return err
+			}
+			if closed {
+				return ErrDrawClosed
+			}
+			return &NotAvailableError{LottoIDs: notAvailable}
+		}
+
+		// --- คิดราคาตามกฎราคา ณ ตอนซื้อ (ราคาที่ได้ใช้แทน lotto.price ทั้งบิล) ---
+		quotes, err := pricing.QuoteLottos(tx, lottos, now)
+		if err != nil {
+			return err
+		}
+		for i := range lottos {
+			lottos[i].Price = quotes[lottos[i].LottoID].Price
+		}
+
+		// --- รวมราคา และเตรียม response ---
+		for _, l := range lottos {
+			res.TotalPrice += l.Price
+			res.Items = append(res.Items, map[string]any{
+				"lotto_id":     l.LottoID,
+				"lotto_number": l.LottoNumber,
+				"set_no":       l.SetNo,
+				"price":        l.Price,
+				"base_price":   quotes[l.LottoID].BasePrice,
+			})
+		}
+		sort.Slice(res.Items, func(i, j int) bool {
+			return res.Items[i]["lotto_id"].(uint) < res.Items[j]["lotto_id"].(uint)
+		})
+
+		// --- โปรโมชัน / โค้ดส่วนลด (1 บิลใช้ได้ 1 โปร) ---
+		prices := make([]float64, 0, len(lottos))
+		for _, l := range lottos {
+			prices = append(prices, l.Price)
+		}
+		promo, err := promotion.Apply(tx, req.UserID, req.PromoCode, prices, now)
+		if err != nil {
+			return err
+		}
+		res.Subtotal = math.Round(res.TotalPrice*100) / 100
+		res.TotalPrice = res.Subtotal
+		if promo != nil {
+			res.Promotion = promo
+			res.Discount = promo.Discount
+			res.TotalPrice = math.Round((res.Subtotal-promo.Discount)*100) / 100
+		}
+
+		// --- ใช้แต้มสะสมจ่ายบางส่วน (ไม่ใช้กับการซื้อในนามกลุ่ม) ---
+		if req.Points > 0 {
+			if req.SyndicateID != nil {
+				return ErrPointsWithSyndicate
+			}
+			value, err := loyalty.Quote(tx, req.UserID, req.Points, res.TotalPrice)
+			if err != nil {
+				return err
+			}
+			res.PointsUsed = req.Points
+			res.PointsValue = value
+			res.TotalPrice = math.Round((res.TotalPrice-value)*100) / 100
+		}
+
+		// --- ตรวจวงเงินซื้อ / การพักการซื้อ ---
+		byDraw := map[uint]float64{}
+		for _, l := range lottos {
+			if l.DrawID != nil {
+				byDraw[*l.DrawID] += l.Price
+			}
+		}
+		if err := limits.Check(tx, req.UserID, res.TotalPrice, byDraw, now); err != nil {
+			return err
+		}
+
+		//  สร้างหัวบิล (ใช้ Create เพื่อให้ได้ PurchaseID กลับมา)
+		p := models.Purchase{
+			UserID:      req.UserID,
+			TotalPrice:  res.TotalPrice,
+			SyndicateID: req.SyndicateID,
+			Discount:    res.Discount,
+			PointsUsed:  res.PointsUsed,
+			PointsValue: res.PointsValue,
+			AgentID:     req.AgentID,
+		}
+		if promo != nil {
+			p.PromotionID = &promo.PromotionID
+		}
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+		res.PurchaseID = p.PurchaseID // GORM จะใส่ ID ที่เพิ่งสร้างให้เราอัตโนมัติ
+		if promo != nil {
+			if err := promotion.Record(tx, promo, req.UserID, res.PurchaseID); err != nil {
+				return err
+			}
+		}
+		if res.PointsUsed > 0 {
+			if err := loyalty.Redeem(tx, req.UserID, res.PointsUsed, res.PurchaseID); err != nil {
+				return err
+			}
+		}
+
+		//  สร้างรายละเอียดบิล
+		details := make([]models.PurchaseDetail, 0, len(lottos))
+		for _, l := range lottos {
+			details = append(details, models.PurchaseDetail{
+				PurchaseID: res.PurchaseID,
+				LottoID:    l.LottoID,
+				OwnerID:    &req.UserID,
+				Price:      l.Price,
+			})
+		}
+		if err := tx.Create(&details).Error; err != nil {
+			return err
+		}
+
+		//  ใส่รหัสยืนยัน (HMAC) ให้สลากแต่ละใบ สำหรับใส่ใน QR
+		lottoByID := make(map[uint]models.Lotto, len(lottos))
+		for _, l := range lottos {
+			lottoByID[l.LottoID] = l
+		}
+		codes := make(map[uint]string, len(details)) // lotto_id -> verify_code
+		for _, d := range details {
+			l := lottoByID[d.LottoID]
+			code, err := ticket.Code(d.PDID, l.LottoNumber, l.DrawID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE purchases_detail SET verify_code = ? WHERE pd_id = ?", code, d.PDID).Error; err != nil {
+				return err
+			}
+			codes[d.LottoID] = code
+		}
+		for _, item := range res.Items {
+			item["verify_code"] = codes[item["lotto_id"].(uint)]
+		}
+
+		// เปลี่ยนสถานะลอตเตอรี่เป็น "sold"
+		updateStatusSQL := "UPDATE lotto SET status = ? WHERE lotto_id IN (?)"
+		if err := tx.Exec(updateStatusSQL, "sold", uniq).Error; err != nil {
+			return err
+		}
+		// ขายแล้ว ไม่ต้องจองต่อ (รวมถึงใบที่ผู้ซื้อจองไว้เอง)
+		if err := tx.Exec("DELETE FROM lotto_reservations WHERE lotto_id IN (?)", uniq).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM agent_allocations WHERE lotto_id IN (?)", uniq).Error; err != nil {
+			return err
+		}
+
+		//  หักเงิน: ซื้อในนามกลุ่มหักจากกองกลาง นอกนั้นหักจากกระเป๋า (wallet) ของผู้จ่าย
+		if req.SyndicateID != nil {
+			if err := syndicate.SpendPool(tx, *req.SyndicateID, req.UserID, res.PurchaseID, res.TotalPrice); err != nil {
+				return err
+			}
+		} else if err := wallet.Debit(tx, payerID, res.TotalPrice, wallet.TypePurchase, &res.PurchaseID, ""); err != nil {
+			return err
+		}
+
+		// ตัวแทนขาย → บันทึกค่าคอมมิชชันแยกตามงวด (คิดจากราคาสลาก ก่อนหักส่วนลด)
+		if seller != nil {
+			sales, tickets := map[uint]float64{}, map[uint]int{}
+			for _, l := range lottos {
+				var drawID uint
+				if l.DrawID != nil {
+					drawID = *l.DrawID
+				}
+				sales[drawID] += l.Price
+				tickets[drawID]++
+			}
+			if err := agent.Accrue(tx, seller, res.PurchaseID, req.UserID, sales, tickets); err != nil {
+				return err
+			}
+		}
+
+		// แต้มสะสมจากยอดที่จ่ายด้วยเงินของตัวเอง (ซื้อในนามกลุ่ม หรือตัวแทนซื้อให้ ไม่ได้แต้ม)
+		if req.SyndicateID == nil && req.AgentID == nil {
+			earned, err := loyalty.Earn(tx, req.UserID, res.TotalPrice, res.PurchaseID)
+			if err != nil {
+				return err
+			}
+			res.PointsEarned = earned
+		}
+
+		// ซื้อครั้งแรกของผู้ถูกชวน → จ่ายโบนัสให้ผู้ชวน
+		// นับเฉพาะบิลที่ผู้ใช้จ่ายเงินเอง (ไม่ใช่ตัวแทนซื้อให้หรือใช้เงินกองกลางของกลุ่ม)
+		if req.AgentID == nil && req.SyndicateID == nil {
+			if err := referral.OnPurchase(tx, req.UserID, res.PurchaseID); err != nil {
+				return err
+			}
+		}
+
+		//  ดึงยอดเงินในกระเป๋าใหม่หลังหักเงิน
+		selectWalletSQL := "SELECT wallet FROM users WHERE user_id = ?"
+		if err := tx.Raw(selectWalletSQL, payerID).Scan(&res.Wallet).Error; err != nil {
+			return err
+		}
+
+		return nil // Commit Transaction
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// inClosedDraw มีสลากที่ตรง where อยู่ในงวดที่ไม่เปิดขายแล้วหรือไม่
+func inClosedDraw(tx *gorm.DB, where string, args ...interface{}) (bool, error) {
+	var n int64
+	err := tx.Raw(`
+		SELECT COUNT(*) FROM lotto
+		JOIN draws AS d ON d.draw_id = lotto.draw_id
+		WHERE d.status <> 'open' AND `+where, args...).Scan(&n).Error
+	return n > 0, err
+}
@@ -0,0 +1,107 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPlatform = errors.New("platform must be android, ios or web")
+
+// sendTimeout เวลาสูงสุดของการส่ง 1 ครั้ง (รวมการลองใหม่)
+const sendTimeout = 30 * time.Second
+
+// Register บันทึก token ของเครื่อง ถ้า token นี้เคยผูกกับบัญชีอื่น (ล็อกอินบัญชีใหม่บนเครื่องเดิม) จะย้ายมาเป็นของผู้ใช้นี้
+func Register(db *gorm.DB, userID uint, token, platform string) (*models.DeviceToken, error) {
+	token = strings.TrimSpace(token)
+	if platform == "" {
+		platform = "android"
+	}
+	if platform != "android" && platform != "ios" && platform != "web" {
+		return nil, ErrInvalidPlatform
+	}
+
+	now := time.Now()
+	if err := db.Exec(`
+		INSERT INTO device_tokens (user_id, token, platform, last_seen_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE user_id = VALUES(user_id), platform = VALUES(platform), last_seen_at = VALUES(last_seen_at)`,
+		userID, token, platform, now, now).Error; err != nil {
+		return nil, err
+	}
+
+	var d models.DeviceToken
+	if err := db.Where("token = ?", token).First(&d).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Unregister ลบ token ของผู้ใช้ (ออกจากระบบ/ปิดการแจ้งเตือน) คืนจำนวนที่ลบ
+func Unregister(db *gorm.DB, userID uint, token string) (int64, error) {
+	res := db.Exec("DELETE FROM device_tokens WHERE user_id = ? AND token = ?", userID, strings.TrimSpace(token))
+	return res.RowsAffected, res.Error
+}
+
+// ToUser ส่งถึงทุกเครื่องของผู้ใช้ ลบ token ที่ใช้ไม่ได้แล้ว คืนจำนวนเครื่องที่ส่งสำเร็จ
+// ยังไม่ได้ตั้งค่า Pusher = ไม่ส่งและไม่ถือว่าผิดพลาด
+func ToUser(db *gorm.DB, userID uint, m Message) (int, error) {
+	p := Get()
+	if p == nil {
+		return 0, nil
+	}
+
+	var tokens []string
+	if err := db.Raw("SELECT token FROM device_tokens WHERE user_id = ?", userID).Scan(&tokens).Error; err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	sent := 0
+	var dead []string
+	var lastErr error
+	for _, t := range tokens {
+		err := withRetry(ctx, func() error { return p.Send(ctx, t, m) })
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, ErrUnregistered):
+			dead = append(dead, t)
+		default:
+			lastErr = err
+		}
+	}
+
+	if len(dead) > 0 {
+		if err := db.Exec("DELETE FROM device_tokens WHERE token IN ?", dead).Error; err != nil {
+			log.Printf("push: failed to remove %d dead tokens: %v", len(dead), err)
+		}
+	}
+	return sent, lastErr
+}
+
+// ToUserQuiet เหมือน ToUser แต่แค่ log เมื่อผิดพลาด
+func ToUserQuiet(db *gorm.DB, userID uint, m Message) {
+	if _, err := ToUser(db, userID, m); err != nil {
+		log.Printf("push: failed to send to user %d: %v", userID, err)
+	}
+}
+
+// ToTopic ส่งถึงทุกเครื่องที่สมัครหัวข้อไว้ (ยังไม่ได้ตั้งค่า Pusher = ไม่ส่ง)
+func ToTopic(topic string, m Message) error {
+	p := Get()
+	if p == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return withRetry(ctx, func() error { return p.SendTopic(ctx, topic, m) })
+}
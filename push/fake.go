@@ -0,0 +1,55 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// Sent ข้อความที่ Fake ได้รับ (Token หรือ Topic อย่างใดอย่างหนึ่ง)
+type Sent struct {
+	Token   string
+	Topic   string
+	Message Message
+}
+
+// Fake Pusher ในหน่วยความจำ สำหรับทดสอบ/รัน local
+// token ใน Dead จะได้ ErrUnregistered และ Fail[token] = n จะผิดพลาดชั่วคราว n ครั้งก่อนสำเร็จ
+type Fake struct {
+	mu   sync.Mutex
+	Sent []Sent
+	Dead map[string]bool
+	Fail map[string]int
+}
+
+func NewFake() *Fake {
+	return &Fake{Dead: map[string]bool{}, Fail: map[string]int{}}
+}
+
+func (f *Fake) Send(_ context.Context, token string, m Message) error {
+	return f.record(token, Sent{Token: token, Message: m})
+}
+
+func (f *Fake) SendTopic(_ context.Context, topic string, m Message) error {
+	return f.record("/topics/"+topic, Sent{Topic: topic, Message: m})
+}
+
+func (f *Fake) record(key string, s Sent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Dead[key] {
+		return ErrUnregistered
+	}
+	if f.Fail[key] > 0 {
+		f.Fail[key]--
+		return &Error{Status: 503, Code: "UNAVAILABLE", Message: "fake temporary failure"}
+	}
+	f.Sent = append(f.Sent, s)
+	return nil
+}
+
+// Messages สำเนาของข้อความที่ส่งสำเร็จทั้งหมด
+func (f *Fake) Messages() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Sent(nil), f.Sent...)
+}
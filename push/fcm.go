@@ -0,0 +1,243 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	googleToken = "https://oauth2.googleapis.com/token"
+)
+
+// FCM ส่งผ่าน Firebase Cloud Messaging HTTP v1 API ด้วย service account
+// (ขอ access token เองด้วย JWT ที่เซ็นจาก private key ของ service account)
+type FCM struct {
+	ProjectID   string
+	ClientEmail string
+	TokenURI    string
+	Endpoint    string // ค่าเริ่มต้น fcmEndpoint ของ ProjectID
+	HTTP        *http.Client
+
+	key *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// NewFCM สร้างจากไฟล์ service account JSON ที่ดาวน์โหลดจาก Firebase console
+func NewFCM(credentials []byte) (*FCM, error) {
+	var sa struct {
+		Type        string `json:"type"`
+		ProjectID   string `json:"project_id"`
+		PrivateKey  string `json:"private_key"`
+		ClientEmail string `json:"client_email"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(credentials, &sa); err != nil {
+		return nil, fmt.Errorf("push: invalid FCM credentials: %w", err)
+	}
+	if sa.Type != "service_account" || sa.ProjectID == "" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("push: FCM credentials must be a service account key")
+	}
+	key, err := parseKey(sa.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = googleToken
+	}
+	return &FCM{
+		ProjectID:   sa.ProjectID,
+		ClientEmail: sa.ClientEmail,
+		TokenURI:    sa.TokenURI,
+		Endpoint:    fmt.Sprintf(fcmEndpoint, sa.ProjectID),
+		HTTP:        &http.Client{Timeout: 10 * time.Second},
+		key:         key,
+	}, nil
+}
+
+func parseKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("push: invalid private key")
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rk, ok := k.(*rsa.PrivateKey); ok {
+			return rk, nil
+		}
+		return nil, errors.New("push: private key is not RSA")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func (f *FCM) Send(ctx context.Context, token string, m Message) error {
+	err := f.post(ctx, map[string]any{"token": token}, m)
+	var pe *Error
+	// เฉพาะ token ที่ถอนแอปไปแล้วเท่านั้นที่ลบทิ้ง; INVALID_ARGUMENT อาจมาจากข้อความของเราเอง จึงคืนเป็น error ปกติ
+	if errors.As(err, &pe) && (pe.Code == "UNREGISTERED" || pe.Status == http.StatusNotFound) {
+		return fmt.Errorf("%w (%s)", ErrUnregistered, pe.Code)
+	}
+	return err
+}
+
+func (f *FCM) SendTopic(ctx context.Context, topic string, m Message) error {
+	return f.post(ctx, map[string]any{"topic": topic}, m)
+}
+
+func (f *FCM) post(ctx context.Context, target map[string]any, m Message) error {
+	msg := target
+	msg["notification"] = map[string]string{"title": m.Title, "body": m.Body}
+	if len(m.Data) > 0 {
+		msg["data"] = m.Data
+	}
+	msg["android"] = map[string]any{"priority": "high"}
+	msg["apns"] = map[string]any{"payload": map[string]any{"aps": map[string]any{"sound": "default"}}}
+	body, err := json.Marshal(map[string]any{"message": msg})
+	if err != nil {
+		return err
+	}
+
+	access, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+access)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.HTTP.Do(req)
+	if err != nil {
+		// เครือข่ายผิดพลาด ถือเป็นชั่วคราว
+		return &Error{Status: http.StatusServiceUnavailable, Code: "UNAVAILABLE", Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		f.mu.Lock()
+		f.accessToken = "" // ขอ token ใหม่ในครั้งถัดไป
+		f.mu.Unlock()
+	}
+	return parseError(resp)
+}
+
+// parseError อ่าน error ของ FCM
+// { "error": { "code": 404, "status": "NOT_FOUND", "message": "...", "details": [{ "errorCode": "UNREGISTERED" }] } }
+func parseError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	json.Unmarshal(b, &body)
+
+	e := &Error{Status: resp.StatusCode, Code: body.Error.Status, Message: body.Error.Message}
+	for _, d := range body.Error.Details {
+		if d.ErrorCode != "" {
+			e.Code = d.ErrorCode
+		}
+	}
+	if e.Code == "" {
+		e.Code = http.StatusText(resp.StatusCode)
+	}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if sec, err := strconv.Atoi(s); err == nil {
+			e.RetryAfter = time.Duration(sec) * time.Second
+		}
+	}
+	return e
+}
+
+// token access token ที่ยังใช้ได้ (ขอใหม่ก่อนหมดอายุ 1 นาที)
+func (f *FCM) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.accessToken != "" && time.Now().Add(time.Minute).Before(f.expiry) {
+		return f.accessToken, nil
+	}
+
+	assertion, err := f.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := f.HTTP.Do(req)
+	if err != nil {
+		return "", &Error{Status: http.StatusServiceUnavailable, Code: "UNAVAILABLE", Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return "", &Error{Status: resp.StatusCode, Code: "AUTH", Message: strings.TrimSpace(string(b))}
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.AccessToken == "" {
+		return "", errors.New("push: invalid token response")
+	}
+	f.accessToken = tok.AccessToken
+	f.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return f.accessToken, nil
+}
+
+// assertion JWT (RS256) สำหรับแลก access token
+func (f *FCM) assertion(now time.Time) (string, error) {
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	unsigned := enc(map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + enc(map[string]any{
+		"iss":   f.ClientEmail,
+		"scope": fcmScope,
+		"aud":   f.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
@@ -0,0 +1,134 @@
+// Package push ส่ง push notification ไปยังแอป (Flutter) ผ่าน FCM
+//
+// ใช้ผ่าน interface Pusher เพื่อสลับเป็น Fake ตอนทดสอบ หรือปิดการส่งเมื่อไม่ได้ตั้งค่า
+// ส่งไม่สำเร็จชั่วคราว (429/5xx) จะลองใหม่ token ที่ใช้ไม่ได้แล้วจะถูกลบออกจากตาราง device_tokens
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TopicDrawResults หัวข้อที่แอปสมัครรับไว้เพื่อรับผลรางวัลทุกงวด
+const TopicDrawResults = "draw_results"
+
+var (
+	// ErrUnregistered token ใช้ไม่ได้แล้ว (ถอนแอป/token หมดอายุ) ต้องลบทิ้ง
+	ErrUnregistered = errors.New("push: device token is no longer valid")
+	// ErrDisabled ยังไม่ได้ตั้งค่า Pusher
+	ErrDisabled = errors.New("push: not configured")
+)
+
+// Message ข้อความที่จะแสดงบนเครื่อง
+// Data ส่งไปให้แอปใช้เปิดหน้าที่เกี่ยวข้อง (FCM รับเฉพาะค่า string)
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Pusher ช่องทางส่ง push
+type Pusher interface {
+	// Send ส่งถึงเครื่องเดียว คืน ErrUnregistered ถ้า token ใช้ไม่ได้แล้ว
+	Send(ctx context.Context, token string, m Message) error
+	// SendTopic ส่งถึงทุกเครื่องที่สมัครหัวข้อนี้
+	SendTopic(ctx context.Context, topic string, m Message) error
+}
+
+// Error ข้อผิดพลาดจากผู้ให้บริการ (ใช้ตัดสินว่าควรลองใหม่หรือไม่)
+type Error struct {
+	Status     int    // HTTP status
+	Code       string // เช่น UNAVAILABLE, QUOTA_EXCEEDED
+	Message    string
+	RetryAfter time.Duration // จาก header Retry-After (0 = ไม่ระบุ)
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("push: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Temporary true ถ้าลองใหม่ภายหลังอาจสำเร็จ
+func (e *Error) Temporary() bool {
+	return e.Status == 429 || e.Status >= 500
+}
+
+var (
+	mu      sync.RWMutex
+	current Pusher
+)
+
+// Set ตั้ง Pusher ที่ใช้ทั้งระบบ (nil = ปิดการส่ง)
+func Set(p Pusher) {
+	mu.Lock()
+	current = p
+	mu.Unlock()
+}
+
+// Get Pusher ที่ใช้อยู่ (nil = ยังไม่ได้ตั้งค่า)
+func Get() Pusher {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Init ตั้งค่า FCM จาก service account ใน FCM_CREDENTIALS (path ไฟล์ JSON)
+// หรือ FCM_CREDENTIALS_JSON (เนื้อหา JSON) ไม่ได้ตั้งไว้ = ไม่ส่ง push
+func Init() error {
+	var raw []byte
+	if s := os.Getenv("FCM_CREDENTIALS_JSON"); s != "" {
+		raw = []byte(s)
+	} else if path := os.Getenv("FCM_CREDENTIALS"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		raw = b
+	}
+	if raw == nil {
+		log.Printf("push: FCM credentials not set, push notifications disabled")
+		return nil
+	}
+	f, err := NewFCM(raw)
+	if err != nil {
+		return err
+	}
+	Set(f)
+	log.Printf("push: FCM enabled for project %s", f.ProjectID)
+	return nil
+}
+
+// ตั้งค่าการลองใหม่
+var (
+	MaxAttempts = 3
+	BaseBackoff = 500 * time.Millisecond
+)
+
+// withRetry เรียก fn ซ้ำเมื่อผิดพลาดชั่วคราว (รอนานขึ้นเท่าตัวทุกครั้ง หรือตาม Retry-After)
+func withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	wait := BaseBackoff
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var pe *Error
+		if !errors.As(err, &pe) || !pe.Temporary() || attempt >= MaxAttempts {
+			return err
+		}
+		d := wait
+		if pe.RetryAfter > 0 {
+			d = pe.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+		wait *= 2
+	}
+}
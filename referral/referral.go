@@ -0,0 +1,220 @@
+// Package referral ระบบชวนเพื่อน: โค้ดชวนของผู้ใช้, ผูกผู้สมัครใหม่กับผู้ชวน
+// และจ่ายโบนัสเข้า wallet ผู้ชวนเมื่อเพื่อนซื้อครั้งแรก
+package referral
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/settings"
+	"my-go-project/wallet"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusRewarded = "rewarded"
+	StatusRejected = "rejected"
+)
+
+// codeAlphabet ไม่มีตัวที่อ่านสับสน (0/O, 1/I/L)
+const (
+	codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	codeLength   = 8
+)
+
+var (
+	ErrCodeNotFound = errors.New("referral code not found")
+	ErrSelfReferral = errors.New("cannot use your own referral code")
+)
+
+// NewCode สุ่มโค้ดชวนเพื่อน
+func NewCode() (string, error) {
+	b := make([]byte, codeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// AssignCode ตั้งโค้ดชวนเพื่อนให้ผู้ใช้ที่ยังไม่มี (สุ่มใหม่ถ้าชนกับโค้ดที่มีอยู่)
+func AssignCode(db *gorm.DB, userID uint) (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := NewCode()
+		if err != nil {
+			return "", err
+		}
+		var taken int64
+		if err := db.Raw("SELECT COUNT(*) FROM users WHERE referral_code = ?", code).Scan(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken > 0 {
+			continue
+		}
+		if err := db.Exec("UPDATE users SET referral_code = ? WHERE user_id = ? AND referral_code IS NULL", code, userID).Error; err != nil {
+			return "", err
+		}
+		return code, nil
+	}
+	return "", errors.New("could not generate a unique referral code")
+}
+
+// NormalizeEmail ตัด +tag (และจุดของ gmail) ออก เพื่อให้อีเมลแฝงของคนเดียวกันนับเป็นอีเมลเดียว
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	if i := strings.IndexByte(local, '+'); i >= 0 {
+		local = local[:i]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// Attach ผูกผู้สมัครใหม่กับเจ้าของโค้ด (เรียกตอนสมัคร ภายใน transaction เดียวกับการสร้างผู้ใช้)
+// ถ้าอีเมลหรืออุปกรณ์นี้เคยถูกชวนมาแล้ว จะบันทึกเป็น rejected (สมัครได้แต่ไม่มีโบนัส)
+func Attach(tx *gorm.DB, refereeID uint, email, code, deviceID string) (*models.Referral, error) {
+	var referrer models.User
+	found := tx.Raw("SELECT user_id FROM users WHERE referral_code = ?", strings.ToUpper(strings.TrimSpace(code))).Scan(&referrer)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, ErrCodeNotFound
+	}
+	if referrer.UserID == refereeID {
+		return nil, ErrSelfReferral
+	}
+
+	r := models.Referral{
+		ReferrerID: referrer.UserID,
+		RefereeID:  refereeID,
+		Status:     StatusPending,
+		Email:      NormalizeEmail(email),
+	}
+	if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+		r.DeviceID = &deviceID
+	}
+
+	// กันสมัครซ้ำเพื่อรับโบนัส: 1 อีเมล / 1 อุปกรณ์ ได้โบนัสครั้งเดียว
+	var dup int64
+	if err := tx.Raw("SELECT COUNT(*) FROM referrals WHERE email = ? OR (device_id IS NOT NULL AND device_id = ?)",
+		r.Email, deviceID).Scan(&dup).Error; err != nil {
+		return nil, err
+	}
+	if dup > 0 {
+		r.Status = StatusRejected
+		r.Reason = "email or device already used for a referral"
+	}
+
+	if err := tx.Create(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// OnPurchase จ่ายโบนัสให้ผู้ชวนเมื่อผู้ถูกชวนซื้อครั้งแรก (เรียกภายใน transaction ของการซื้อ)
+// ไม่มีการชวนที่รอจ่าย = ไม่ทำอะไร
+func OnPurchase(tx *gorm.DB, refereeID, purchaseID uint) error {
+	var r models.Referral
+	found := tx.Raw("SELECT * FROM referrals WHERE referee_id = ? AND status = ? FOR UPDATE", refereeID, StatusPending).Scan(&r)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	bonus := settings.Float(tx, settings.ReferralBonus)
+	if limit := int64(settings.Float(tx, settings.ReferralMaxPerReferrer)); limit > 0 {
+		var rewarded int64
+		if err := tx.Raw("SELECT COUNT(*) FROM referrals WHERE referrer_id = ? AND status = ?", r.ReferrerID, StatusRewarded).Scan(&rewarded).Error; err != nil {
+			return err
+		}
+		if rewarded >= limit {
+			return tx.Exec("UPDATE referrals SET status = ?, reason = ?, purchase_id = ? WHERE referral_id = ?",
+				StatusRejected, "referrer reached the bonus limit", purchaseID, r.ReferralID).Error
+		}
+	}
+
+	if err := tx.Exec("UPDATE referrals SET status = ?, bonus = ?, purchase_id = ?, rewarded_at = ? WHERE referral_id = ?",
+		StatusRewarded, bonus, purchaseID, now, r.ReferralID).Error; err != nil {
+		return err
+	}
+	if err := wallet.Credit(tx, r.ReferrerID, bonus, wallet.TypeReferralBonus, &r.ReferralID, "โบนัสชวนเพื่อน"); err != nil {
+		return err
+	}
+	notify.PublishQuiet(tx, r.ReferrerID, notify.TypeReferral,
+		"ได้รับโบนัสชวนเพื่อน",
+		fmt.Sprintf("เพื่อนที่คุณชวนซื้อสลากครั้งแรกแล้ว ได้รับโบนัส %.2f บาท", bonus),
+		map[string]any{"referral_id": r.ReferralID})
+	return nil
+}
+
+// Summary สรุปการชวนเพื่อนของผู้ใช้
+type Summary struct {
+	Code       string  `json:"referral_code"`
+	Pending    int     `json:"pending"`
+	Rewarded   int     `json:"rewarded"`
+	Rejected   int     `json:"rejected"`
+	TotalBonus float64 `json:"total_bonus"`
+}
+
+// Get โค้ดและสรุปการชวนของผู้ใช้ (ผู้ใช้เก่าที่ยังไม่มีโค้ดจะได้โค้ดใหม่)
+func Get(db *gorm.DB, userID uint) (*Summary, error) {
+	var user models.User
+	found := db.Raw("SELECT user_id, referral_code FROM users WHERE user_id = ?", userID).Scan(&user)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	s := &Summary{}
+	if user.ReferralCode != nil {
+		s.Code = *user.ReferralCode
+	} else {
+		code, err := AssignCode(db, userID)
+		if err != nil {
+			return nil, err
+		}
+		s.Code = code
+	}
+
+	var rows []struct {
+		Status string
+		N      int
+		Bonus  float64
+	}
+	if err := db.Raw("SELECT status, COUNT(*) AS n, COALESCE(SUM(bonus), 0) AS bonus FROM referrals WHERE referrer_id = ? GROUP BY status", userID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		switch r.Status {
+		case StatusPending:
+			s.Pending = r.N
+		case StatusRewarded:
+			s.Rewarded = r.N
+		case StatusRejected:
+			s.Rejected = r.N
+		}
+		s.TotalBonus += r.Bonus
+	}
+	return s, nil
+}
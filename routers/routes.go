@@ -27,8 +27,20 @@ func SetupRouter(r *gin.Engine, db *gorm.DB) {
 		handlers.LottoAuspicious(c, db)
 	})
 
+	r.GET("/stats/numbers", func(c *gin.Context) {
+		handlers.NumberStats(c, db) // เลขร้อน/เลขเย็นจากผลรางวัลย้อนหลัง
+	})
+
 	r.POST("/purchases", func(c *gin.Context) { handlers.CreatePurchase(c, db) }) // ซื้อจริง
 
+	r.GET("/pricing/preview", func(c *gin.Context) {
+		handlers.PricePreview(c, db) // ราคาขายจริงตามกฎราคา ก่อนซื้อ
+	})
+
+	r.GET("/live", func(c *gin.Context) {
+		handlers.LiveStream(c, db) // SSE ผลรางวัล/สลากคงเหลือ/สถานะสลากแบบ real-time
+	})
+
 	r.GET("/users/purchases", func(c *gin.Context) {
 		handlers.ListPurchasedLottosByUser(c, db)
 	})
@@ -60,6 +72,153 @@ func SetupRouter(r *gin.Engine, db *gorm.DB) {
 		handlers.CashIn(c, db)
 	})
 
+	r.GET("/purchases/:purchase_id/receipt", func(c *gin.Context) {
+		handlers.DownloadReceipt(c, db) // ใบเสร็จ PDF
+	})
+
+	r.GET("/tickets/:pd_id/image", func(c *gin.Context) {
+		handlers.DownloadTicketImage(c, db) // รูปสลาก PNG
+	})
+
+	r.GET("/tickets/verify", func(c *gin.Context) {
+		handlers.VerifyTicket(c, db) // สาธารณะ สำหรับร้านค้าตรวจสลาก
+	})
+
+	r.POST("/tickets/:pd_id/transfer", func(c *gin.Context) {
+		handlers.TransferTicket(c, db) // โอน/ให้สลาก
+	})
+
+	r.GET("/tickets/:pd_id/transfers", func(c *gin.Context) {
+		handlers.ListTicketTransfers(c, db)
+	})
+
+	// ตลาดขายต่อสลาก
+	r.GET("/market/listings", func(c *gin.Context) {
+		handlers.ListMarketListings(c, db)
+	})
+
+	r.POST("/market/listings", func(c *gin.Context) {
+		handlers.CreateMarketListing(c, db)
+	})
+
+	r.POST("/market/listings/:listing_id/buy", func(c *gin.Context) {
+		handlers.BuyMarketListing(c, db)
+	})
+
+	r.DELETE("/market/listings/:listing_id", func(c *gin.Context) {
+		handlers.CancelMarketListing(c, db)
+	})
+
+	r.GET("/users/limits", func(c *gin.Context) {
+		handlers.GetLimits(c, db)
+	})
+
+	r.PUT("/users/limits", func(c *gin.Context) {
+		handlers.SetLimits(c, db) // ลดได้ทันที เพิ่มต้องรอ cool-down
+	})
+
+	r.GET("/users/referral", func(c *gin.Context) {
+		handlers.ReferralSummary(c, db) // โค้ดชวนเพื่อน + โบนัสที่ได้
+	})
+
+	r.GET("/users/points", func(c *gin.Context) {
+		handlers.GetPoints(c, db) // แต้มสะสม + ระดับสมาชิก
+	})
+
+	r.GET("/users/points/history", func(c *gin.Context) {
+		handlers.PointsHistory(c, db)
+	})
+
+	r.GET("/notifications", func(c *gin.Context) {
+		handlers.ListNotifications(c, db) // กล่องข้อความแจ้งเตือน
+	})
+
+	r.GET("/notifications/unread-count", func(c *gin.Context) {
+		handlers.UnreadNotificationCount(c, db)
+	})
+
+	r.POST("/notifications/read", func(c *gin.Context) {
+		handlers.MarkNotificationsRead(c, db)
+	})
+
+	r.POST("/devices", func(c *gin.Context) {
+		handlers.RegisterDevice(c, db) // FCM token สำหรับ push
+	})
+
+	r.DELETE("/devices", func(c *gin.Context) {
+		handlers.UnregisterDevice(c, db)
+	})
+
+	r.POST("/users/self-exclusion", func(c *gin.Context) {
+		handlers.SelfExclude(c, db)
+	})
+
+	r.GET("/subscriptions", func(c *gin.Context) {
+		handlers.ListSubscriptions(c, db)
+	})
+
+	r.POST("/subscriptions", func(c *gin.Context) {
+		handlers.CreateSubscription(c, db) // ซื้ออัตโนมัติทุกงวด
+	})
+
+	r.DELETE("/subscriptions/:subscription_id", func(c *gin.Context) {
+		handlers.CancelSubscription(c, db)
+	})
+
+	r.GET("/watchlist", func(c *gin.Context) {
+		handlers.ListWatchlist(c, db)
+	})
+
+	r.POST("/watchlist", func(c *gin.Context) {
+		handlers.AddWatch(c, db) // แจ้งเตือนเมื่อมีสลากเลขที่ติดตาม
+	})
+
+	r.DELETE("/watchlist/:watch_id", func(c *gin.Context) {
+		handlers.RemoveWatch(c, db)
+	})
+
+	r.DELETE("/reservations/:reservation_id", func(c *gin.Context) {
+		handlers.CancelReservation(c, db)
+	})
+
+	// กลุ่มซื้อสลากร่วมกัน (syndicate)
+	r.POST("/syndicates", func(c *gin.Context) {
+		handlers.CreateSyndicate(c, db)
+	})
+
+	r.GET("/syndicates", func(c *gin.Context) {
+		handlers.ListSyndicates(c, db)
+	})
+
+	r.GET("/syndicates/:syndicate_id", func(c *gin.Context) {
+		handlers.GetSyndicate(c, db)
+	})
+
+	r.POST("/syndicates/:syndicate_id/invite", func(c *gin.Context) {
+		handlers.InviteSyndicateMember(c, db)
+	})
+
+	r.POST("/syndicates/:syndicate_id/join", func(c *gin.Context) {
+		handlers.JoinSyndicate(c, db)
+	})
+
+	r.POST("/syndicates/:syndicate_id/contribute", func(c *gin.Context) {
+		handlers.ContributeSyndicate(c, db)
+	})
+
+	// ตัวแทนขาย (agent)
+	r.POST("/agents/:agent_id/purchases", func(c *gin.Context) {
+		handlers.AgentPurchase(c, db) // ขายให้ลูกค้า จ่ายจาก wallet ตัวแทน
+	})
+
+	r.GET("/agents/:agent_id/inventory", func(c *gin.Context) {
+		handlers.AgentInventory(c, db)
+	})
+
+	r.GET("/agents/:agent_id/statements", func(c *gin.Context) {
+		handlers.AgentStatements(c, db) // สรุปยอดขาย/ค่าคอมรายงวด
+	})
+
 	//admin
 
 	r.GET("/lotto", func(c *gin.Context) {
@@ -70,6 +229,18 @@ func SetupRouter(r *gin.Engine, db *gorm.DB) {
 		handlersadmin.InsertLottoHandler(c, db)
 	})
 
+	r.POST("/lotto/generate/bulk", func(c *gin.Context) {
+		handlersadmin.GenerateLottoBulk(c, db) // สร้างเลขฝั่ง server ทีละก้อน
+	})
+
+	r.GET("/lotto/generate/jobs/:job_id", func(c *gin.Context) {
+		handlersadmin.GenerateLottoStatus(c)
+	})
+
+	r.POST("/lotto/import", func(c *gin.Context) {
+		handlersadmin.ImportLotto(c, db) // นำเข้าจากไฟล์ CSV / XLSX
+	})
+
 	r.POST("/lotto/clear", func(c *gin.Context) {
 		handlersadmin.ClearLottoDataHandler(c, db) // <--- เส้นทางและฟังก์ชันใหม่
 	})
@@ -94,4 +265,80 @@ func SetupRouter(r *gin.Engine, db *gorm.DB) {
 	r.POST("/admin/clearData", func(c *gin.Context) {
 		handlersadmin.ClearDataHandler(c, db)
 	})
+
+	r.POST("/draws", func(c *gin.Context) {
+		handlersadmin.CreateDraw(c, db)
+	})
+
+	r.GET("/draws", func(c *gin.Context) {
+		handlersadmin.ListDraws(c, db)
+	})
+
+	r.POST("/draws/:draw_id/subscriptions/run", func(c *gin.Context) {
+		handlersadmin.RunDrawSubscriptions(c, db)
+	})
+
+	r.POST("/draws/:draw_id/close", func(c *gin.Context) {
+		handlersadmin.CloseDrawSales(c, db) // ปิดการขาย + ยกเลิกประกาศขายต่อ
+	})
+
+	r.GET("/admin/settings", func(c *gin.Context) {
+		handlersadmin.GetSettings(c, db)
+	})
+
+	r.PUT("/admin/settings", func(c *gin.Context) {
+		handlersadmin.UpdateSetting(c, db)
+	})
+
+	r.GET("/pricing/rules", func(c *gin.Context) {
+		handlersadmin.ListPriceRules(c, db)
+	})
+
+	r.POST("/pricing/rules", func(c *gin.Context) {
+		handlersadmin.CreatePriceRule(c, db)
+	})
+
+	r.PUT("/pricing/rules/:rule_id", func(c *gin.Context) {
+		handlersadmin.UpdatePriceRule(c, db)
+	})
+
+	r.DELETE("/pricing/rules/:rule_id", func(c *gin.Context) {
+		handlersadmin.DeletePriceRule(c, db)
+	})
+
+	r.GET("/promotions", func(c *gin.Context) {
+		handlersadmin.ListPromotions(c, db)
+	})
+
+	r.POST("/promotions", func(c *gin.Context) {
+		handlersadmin.CreatePromotion(c, db)
+	})
+
+	r.PUT("/promotions/:promotion_id", func(c *gin.Context) {
+		handlersadmin.UpdatePromotion(c, db)
+	})
+
+	r.DELETE("/promotions/:promotion_id", func(c *gin.Context) {
+		handlersadmin.DeletePromotion(c, db) // ปิดโปร ไม่ลบจริง
+	})
+
+	r.GET("/agents", func(c *gin.Context) {
+		handlersadmin.ListAgents(c, db)
+	})
+
+	r.PUT("/agents/:agent_id", func(c *gin.Context) {
+		handlersadmin.SetAgent(c, db) // ตั้งตัวแทน / อัตราค่าคอม
+	})
+
+	r.POST("/agents/:agent_id/allocations", func(c *gin.Context) {
+		handlersadmin.AllocateToAgent(c, db)
+	})
+
+	r.DELETE("/agents/:agent_id/allocations", func(c *gin.Context) {
+		handlersadmin.ReleaseAgentAllocations(c, db)
+	})
+
+	r.POST("/agents/:agent_id/statements/:draw_id/settle", func(c *gin.Context) {
+		handlersadmin.SettleAgentCommission(c, db) // จ่ายค่าคอมงวดนั้นเข้า wallet
+	})
 }
@@ -0,0 +1,157 @@
+// Package sampler สุ่มแถวจากตาราง lotto โดยไม่ใช้ ORDER BY RAND()
+//
+// ORDER BY RAND() ต้องอ่านทุกแถวที่ตรงเงื่อนไขแล้วเรียงใหม่ทุกครั้ง (ช้าลงตามจำนวนแถว)
+// ที่นี่สุ่มตำแหน่ง id ในช่วง [min, max] แล้วหาแถวแรกที่ id >= ค่านั้นผ่าน index
+// แต่ละครั้งจึงเป็นการค้น index แค่ไม่กี่ครั้ง ไม่ขึ้นกับขนาดตาราง
+// (แถวที่อยู่หลังช่องว่างของ id จะมีโอกาสถูกสุ่มมากกว่าเล็กน้อย ยอมรับได้สำหรับการสุ่มเลขเสี่ยงโชค)
+package sampler
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"my-go-project/agent"
+	"my-go-project/models"
+	"my-go-project/purchase"
+
+	"gorm.io/gorm"
+)
+
+// Source แหล่ง id ที่เรียงจากน้อยไปมาก (เช่น lotto_id ของสลากที่ยังขายได้)
+type Source interface {
+	// Bounds id ต่ำสุด/สูงสุดที่ตรงเงื่อนไข ok=false ถ้าไม่มีเลย
+	Bounds() (min, max uint, ok bool, err error)
+	// Next id แรกที่ >= from ok=false ถ้าไม่มีแล้ว
+	Next(from uint) (id uint, ok bool, err error)
+}
+
+// Sample สุ่ม id ไม่ซ้ำกันไม่เกิน k ตัวจาก src
+// ถ้าสุ่มแล้วชนซ้ำบ่อย (แถวที่ตรงเงื่อนไขมีน้อย) จะเติมที่เหลือโดยไล่จาก id ต่ำสุด
+func Sample(src Source, k int) ([]uint, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	min, max, ok, err := src.Bounds()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	seen := make(map[uint]struct{}, k)
+	out := make([]uint, 0, k)
+	for attempt := 0; len(out) < k && attempt < k*8; attempt++ {
+		from := min + uint(rand.Uint64N(uint64(max-min)+1))
+		id, found, err := src.Next(from)
+		if err != nil {
+			return nil, err
+		}
+		if !found { // เลย id สุดท้ายไปแล้ว วนกลับไปต้นตาราง
+			if id, found, err = src.Next(min); err != nil {
+				return nil, err
+			}
+			if !found {
+				break
+			}
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+
+	// fallback: ไล่ id จากต้นตารางจนครบ k (หรือหมด)
+	for from := min; len(out) < k; {
+		id, found, err := src.Next(from)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		if _, dup := seen[id]; !dup {
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+		from = id + 1
+	}
+	return out, nil
+}
+
+// SQLSource ใช้ lotto_id ของตาราง lotto ที่ตรง Where (เว้นว่าง = ทุกแถว)
+// ควรมี index ที่ขึ้นต้นด้วยคอลัมน์ใน Where (InnoDB ต่อท้าย primary key ให้เอง)
+// เช่น idx_lotto_status สำหรับ status = 'sell'
+type SQLSource struct {
+	DB    *gorm.DB
+	Where string
+	Args  []interface{}
+}
+
+func (s SQLSource) where(extra string) string {
+	switch {
+	case s.Where == "" && extra == "":
+		return ""
+	case s.Where == "":
+		return " WHERE " + extra
+	case extra == "":
+		return " WHERE " + s.Where
+	default:
+		return " WHERE " + s.Where + " AND " + extra
+	}
+}
+
+func (s SQLSource) Bounds() (uint, uint, bool, error) {
+	var b struct {
+		Min *uint
+		Max *uint
+	}
+	if err := s.DB.Raw("SELECT MIN(lotto_id) AS min, MAX(lotto_id) AS max FROM lotto"+s.where(""), s.Args...).Scan(&b).Error; err != nil {
+		return 0, 0, false, err
+	}
+	if b.Min == nil || b.Max == nil {
+		return 0, 0, false, nil
+	}
+	return *b.Min, *b.Max, true, nil
+}
+
+func (s SQLSource) Next(from uint) (uint, bool, error) {
+	var ids []uint
+	args := append(append([]interface{}{}, s.Args...), from)
+	if err := s.DB.Raw("SELECT lotto_id FROM lotto"+s.where("lotto_id >= ?")+" ORDER BY lotto_id ASC LIMIT 1", args...).Scan(&ids).Error; err != nil {
+		return 0, false, err
+	}
+	if len(ids) == 0 {
+		return 0, false, nil
+	}
+	return ids[0], true, nil
+}
+
+// Buyable เงื่อนไข Where ของสลากที่ซื้อได้จริงตอนนี้: ยังขายได้ ไม่มีใครจองอยู่ ไม่ได้แบ่งให้ตัวแทน
+// และงวดยังเปิดขาย ต่อเงื่อนไขอื่นท้ายด้วย " AND ..." ได้ (ต้องใช้ชื่อตาราง lotto ไม่ใช้ alias)
+func Buyable(now time.Time) (string, []interface{}) {
+	where := "status = ? AND " + purchase.NotReservedSQL + " AND " + agent.NotAllocatedSQL + " AND " + purchase.OpenDrawSQL
+	return where, []interface{}{"sell", 0, now, 0}
+}
+
+// Lottos สุ่มสลากไม่เกิน k ใบจากแถวที่ตรง where (ลำดับตามที่สุ่มได้)
+func Lottos(db *gorm.DB, k int, where string, args ...interface{}) ([]models.Lotto, error) {
+	ids, err := Sample(SQLSource{DB: db, Where: where, Args: args}, k)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var rows []models.Lotto
+	if err := db.Raw("SELECT * FROM lotto WHERE lotto_id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Lotto, len(rows))
+	for _, l := range rows {
+		byID[l.LottoID] = l
+	}
+	items := make([]models.Lotto, 0, len(ids))
+	for _, id := range ids {
+		if l, ok := byID[id]; ok {
+			items = append(items, l)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,204 @@
+package sampler
+
+import (
+	"container/heap"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memSource จำลอง index ของ lotto_id ในหน่วยความจำ (ค้นแบบ binary search เหมือน B-tree)
+type memSource []uint
+
+func (m memSource) Bounds() (uint, uint, bool, error) {
+	if len(m) == 0 {
+		return 0, 0, false, nil
+	}
+	return m[0], m[len(m)-1], true, nil
+}
+
+func (m memSource) Next(from uint) (uint, bool, error) {
+	i := sort.Search(len(m), func(i int) bool { return m[i] >= from })
+	if i == len(m) {
+		return 0, false, nil
+	}
+	return m[i], true, nil
+}
+
+// ตาราง 1M แถว ขายไปแล้วประมาณครึ่งหนึ่ง (id ของใบที่ยังขายได้จึงมีช่องว่าง)
+func sellIDs(n int) memSource {
+	r := rand.New(rand.NewPCG(1, 2))
+	ids := make(memSource, 0, n/2)
+	for id := 1; id <= n; id++ {
+		if r.IntN(2) == 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// orderByRand ทำแบบเดียวกับ ORDER BY RAND() LIMIT k:
+// ให้ค่าสุ่มกับทุกแถว แล้วเก็บ k แถวที่ค่าน้อยสุด (MySQL ใช้ priority queue เมื่อมี LIMIT)
+func orderByRand(ids []uint, k int) []uint {
+	h := &randHeap{}
+	for _, id := range ids {
+		key := rand.Float64()
+		if h.Len() < k {
+			heap.Push(h, keyed{key, id})
+		} else if key < (*h)[0].key {
+			(*h)[0] = keyed{key, id}
+			heap.Fix(h, 0)
+		}
+	}
+	out := make([]uint, 0, h.Len())
+	for _, e := range *h {
+		out = append(out, e.id)
+	}
+	return out
+}
+
+type keyed struct {
+	key float64
+	id  uint
+}
+
+type randHeap []keyed // max-heap ตาม key
+
+func (h randHeap) Len() int           { return len(h) }
+func (h randHeap) Less(i, j int) bool { return h[i].key > h[j].key }
+func (h randHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *randHeap) Push(x any)        { *h = append(*h, x.(keyed)) }
+func (h *randHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// errSource คืน error ทุกครั้ง
+type errSource struct{ err error }
+
+func (e errSource) Bounds() (uint, uint, bool, error) { return 1, 10, true, nil }
+func (e errSource) Next(uint) (uint, bool, error)     { return 0, false, e.err }
+
+func TestSampleReturnsDistinctIDsFromSource(t *testing.T) {
+	src := sellIDs(10_000)
+	in := make(map[uint]bool, len(src))
+	for _, id := range src {
+		in[id] = true
+	}
+	ids, err := Sample(src, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 50 {
+		t.Fatalf("len = %d, want 50", len(ids))
+	}
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if !in[id] {
+			t.Errorf("id %d is not in the source", id)
+		}
+		if seen[id] {
+			t.Errorf("id %d returned twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestSampleFewerRowsThanK(t *testing.T) {
+	src := memSource{3, 9, 10, 42, 100}
+	ids, err := Sample(src, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) != len(src) {
+		t.Fatalf("Sample = %v, want every id of %v", ids, src)
+	}
+	for i := range src {
+		if ids[i] != src[i] {
+			t.Fatalf("Sample = %v, want every id of %v", ids, src)
+		}
+	}
+}
+
+func TestSampleEmpty(t *testing.T) {
+	for _, k := range []int{0, 1, 3} {
+		ids, err := Sample(memSource{}, k)
+		if err != nil || len(ids) != 0 {
+			t.Errorf("Sample(empty, %d) = %v, %v", k, ids, err)
+		}
+	}
+	if ids, err := Sample(memSource{1, 2, 3}, 0); err != nil || len(ids) != 0 {
+		t.Errorf("Sample(k=0) = %v, %v", ids, err)
+	}
+}
+
+// ทุกแถวต้องมีโอกาสถูกสุ่ม รวมถึงแถวแรกและแถวหลังช่องว่างของ id
+func TestSampleReachesEveryRow(t *testing.T) {
+	src := memSource{1, 2, 3, 50, 51, 200, 201, 202, 999, 1000}
+	hits := map[uint]int{}
+	for i := 0; i < 5000; i++ {
+		ids, err := Sample(src, 1)
+		if err != nil || len(ids) != 1 {
+			t.Fatalf("Sample = %v, %v", ids, err)
+		}
+		hits[ids[0]]++
+	}
+	for _, id := range src {
+		if hits[id] == 0 {
+			t.Errorf("id %d was never sampled", id)
+		}
+	}
+}
+
+func TestSampleReturnsSourceError(t *testing.T) {
+	want := errors.New("db down")
+	if _, err := Sample(errSource{want}, 3); !errors.Is(err, want) {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
+
+// เงื่อนไขซื้อได้ต้องตัดใบที่ติดจอง ใบที่แบ่งให้ตัวแทน และงวดที่ปิดแล้ว และจำนวน ? ต้องตรงกับ args
+func TestBuyableExcludesReservedAndAllocated(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	where, args := Buyable(now)
+	for _, table := range []string{"lotto_reservations", "agent_allocations", "draws"} {
+		if !strings.Contains(where, table) {
+			t.Errorf("where does not exclude %s: %s", table, where)
+		}
+	}
+	if n := strings.Count(where, "?"); n != len(args) {
+		t.Fatalf("where has %d placeholders, args has %d", n, len(args))
+	}
+	if args[0] != "sell" {
+		t.Errorf("status arg = %v, want sell", args[0])
+	}
+	if got, ok := args[2].(time.Time); !ok || !got.Equal(now) {
+		t.Errorf("reservation time arg = %v, want %v", args[2], now)
+	}
+}
+
+func BenchmarkSample1M(b *testing.B) {
+	src := sellIDs(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ids, err := Sample(src, 3); err != nil || len(ids) != 3 {
+			b.Fatalf("Sample = %v, %v", ids, err)
+		}
+	}
+}
+
+func BenchmarkOrderByRand1M(b *testing.B) {
+	src := sellIDs(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ids := orderByRand(src, 3); len(ids) != 3 {
+			b.Fatalf("orderByRand = %v", ids)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package settings
+
+import (
+	"strconv"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ค่าตั้งระบบที่รู้จัก และค่าเริ่มต้นเมื่อยังไม่มีในตาราง settings
+const (
+	HardDailyLimit     = "limit.hard.daily"  // วงเงินซื้อสูงสุดต่อวันที่ admin กำหนด (0 = ไม่จำกัด)
+	HardWeeklyLimit    = "limit.hard.weekly" // วงเงินซื้อสูงสุดต่อสัปดาห์ (0 = ไม่จำกัด)
+	HardDrawLimit      = "limit.hard.draw"   // วงเงินซื้อสูงสุดต่องวด (0 = ไม่จำกัด)
+	LimitRaiseCooldown = "limit.raise_cooldown_hours"
+
+	MarketMaxMarkup   = "market.max_markup_percent" // ราคาขายต่อสูงสุดเกินราคาหน้าตั๋วได้กี่ %
+	MarketFeePercent  = "market.fee_percent"        // ค่าธรรมเนียมที่หักจากผู้ขาย (%)
+	MarketCutoffHours = "market.cutoff_hours"       // ปิดตลาดขายต่อกี่ชั่วโมงก่อนวันออกรางวัล
+
+	WatchReserveMinutes = "watch.reserve_minutes" // จองสลากให้ผู้ติดตามเลขกี่นาที (0 = ไม่จองให้)
+
+	ReferralBonus          = "referral.bonus"            // โบนัสเข้า wallet ผู้ชวนเมื่อเพื่อนซื้อครั้งแรก (บาท)
+	ReferralMaxPerReferrer = "referral.max_per_referrer" // ผู้ชวน 1 คนได้โบนัสสูงสุดกี่ครั้ง (0 = ไม่จำกัด)
+
+	LoyaltyEarnPerBaht = "loyalty.earn_per_baht" // แต้มที่ได้ต่อ 1 บาทที่จ่าย (ก่อนคูณตามระดับ)
+	LoyaltyPointValue  = "loyalty.point_value"   // 1 แต้มแลกได้กี่บาท
+	LoyaltySilverAt    = "loyalty.silver_points" // แต้มสะสมทั้งหมดที่ได้ระดับ silver
+	LoyaltyGoldAt      = "loyalty.gold_points"   // แต้มสะสมทั้งหมดที่ได้ระดับ gold
+)
+
+var Defaults = map[string]string{
+	HardDailyLimit:      "0",
+	HardWeeklyLimit:     "0",
+	HardDrawLimit:       "0",
+	LimitRaiseCooldown:  "24",
+	MarketMaxMarkup:     "20",
+	MarketFeePercent:    "5",
+	MarketCutoffHours:   "24",
+	WatchReserveMinutes: "15",
+
+	ReferralBonus:          "50",
+	ReferralMaxPerReferrer: "0",
+
+	LoyaltyEarnPerBaht: "0.1",
+	LoyaltyPointValue:  "0.1",
+	LoyaltySilverAt:    "1000",
+	LoyaltyGoldAt:      "5000",
+}
+
+// Get อ่านค่าจากตาราง settings ถ้าไม่มีใช้ค่าใน Defaults
+func Get(db *gorm.DB, key string) string {
+	var s models.Setting
+	if err := db.Where("setting_key = ?", key).Limit(1).Find(&s).Error; err != nil || s.Key == "" {
+		return Defaults[key]
+	}
+	return s.Value
+}
+
+// Float อ่านค่าเป็นตัวเลข ถ้าแปลงไม่ได้คืน 0
+func Float(db *gorm.DB, key string) float64 {
+	f, _ := strconv.ParseFloat(Get(db, key), 64)
+	return f
+}
+
+// All คืนค่าทุก key ที่รู้จัก (รวมค่าที่ admin แก้ไว้)
+func All(db *gorm.DB) (map[string]string, error) {
+	out := make(map[string]string, len(Defaults))
+	for k, v := range Defaults {
+		out[k] = v
+	}
+	var rows []models.Setting
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.Key] = r.Value
+	}
+	return out, nil
+}
+
+// Set บันทึกค่า (insert หรือ update)
+func Set(db *gorm.DB, key, value string) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.Setting{Key: key, Value: value}).Error
+}
@@ -0,0 +1,213 @@
+// Package stats สถิติเลขออกบ่อย/ไม่ค่อยออก (เลขร้อน/เลขเย็น) จากผลรางวัลย้อนหลัง
+//
+// ผลที่คำนวณแล้วเก็บไว้ในหน่วยความจำ คำนวณใหม่เมื่อมีการปล่อยรางวัล
+// (หรือเมื่อพบว่าตาราง draw_results เปลี่ยนไปจากที่ cache ไว้ เช่น ปล่อยรางวัลจาก server อีกเครื่อง)
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+// Count สถิติของเลข 1 ค่า
+type Count struct {
+	Number     string `json:"number"`
+	Count      int    `json:"count"`       // จำนวนงวดที่ออก
+	DrawsSince *int   `json:"draws_since"` // ไม่ออกมาแล้วกี่งวด (null = ไม่เคยออก)
+}
+
+// Table เลขร้อน (ออกบ่อยสุดก่อน) และเลขเย็น (ไม่ออกนานสุดก่อน) ของทุกค่าที่เป็นไปได้
+type Table struct {
+	Hot  []Count `json:"hot"`
+	Cold []Count `json:"cold"`
+}
+
+// Report สถิติทั้งหมด
+type Report struct {
+	Draws      int        `json:"draws"` // จำนวนงวดที่ใช้คำนวณ
+	LastDrawAt *time.Time `json:"last_draw_at"`
+	Last2      Table      `json:"last2"`     // เลขท้าย 2 ตัว (รางวัลที่ 5)
+	Last3      Table      `json:"last3"`     // เลขท้าย 3 ตัว (รางวัลที่ 4)
+	Positions  [6][]Count `json:"positions"` // เลขแต่ละหลักของรางวัลที่ 1 (หลักที่ 1-6)
+	ComputedAt time.Time  `json:"computed_at"`
+}
+
+var (
+	mu        sync.Mutex
+	cached    *Report
+	cachedKey string
+)
+
+// Get คืนสถิติจาก cache (คำนวณใหม่ถ้าผลรางวัลย้อนหลังเปลี่ยนไป)
+func Get(db *gorm.DB) (*Report, error) {
+	key, err := versionKey(db)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != nil && cachedKey == key {
+		return cached, nil
+	}
+	return recompute(db, key)
+}
+
+// Recompute คำนวณใหม่ทันที (เรียกหลังปล่อยรางวัล)
+func Recompute(db *gorm.DB) (*Report, error) {
+	key, err := versionKey(db)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return recompute(db, key)
+}
+
+func versionKey(db *gorm.DB) (string, error) {
+	var v struct {
+		Total int64
+		Last  *time.Time
+	}
+	if err := db.Raw("SELECT COUNT(*) AS total, MAX(released_at) AS last FROM draw_results").Scan(&v).Error; err != nil {
+		return "", err
+	}
+	if v.Last == nil {
+		return "empty", nil
+	}
+	return fmt.Sprintf("%d@%d", v.Total, v.Last.UnixNano()), nil
+}
+
+func recompute(db *gorm.DB, key string) (*Report, error) {
+	var results []models.DrawResult
+	if err := db.Order("released_at ASC, prize_tier ASC").Find(&results).Error; err != nil {
+		return nil, err
+	}
+	report := Compute(results)
+	cached, cachedKey = report, key
+	return report, nil
+}
+
+// draw ผลของ 1 งวด (ปล่อยรางวัล 1 ครั้ง)
+type draw struct {
+	first, last2, last3 string
+}
+
+// Compute คำนวณสถิติจากผลรางวัลที่เรียงตาม released_at แล้ว
+func Compute(results []models.DrawResult) *Report {
+	var draws []draw
+	var lastAt *time.Time
+	for i := 0; i < len(results); {
+		at := results[i].ReleasedAt
+		var d draw
+		for ; i < len(results) && results[i].ReleasedAt.Equal(at); i++ {
+			n := results[i].LottoNumber
+			if len(n) != 6 {
+				continue
+			}
+			switch results[i].PrizeTier {
+			case 1:
+				d.first = n
+			case 4:
+				d.last3 = n[3:]
+			case 5:
+				d.last2 = n[4:]
+			}
+		}
+		// ผลที่ไม่มีรางวัลเลขท้ายใช้เลขท้ายของรางวัลที่ 1 แทน
+		if d.first != "" {
+			if d.last3 == "" {
+				d.last3 = d.first[3:]
+			}
+			if d.last2 == "" {
+				d.last2 = d.first[4:]
+			}
+		}
+		if d.first != "" || d.last2 != "" || d.last3 != "" {
+			draws = append(draws, d)
+			t := at
+			lastAt = &t
+		}
+	}
+
+	r := &Report{Draws: len(draws), LastDrawAt: lastAt, ComputedAt: time.Now()}
+	r.Last2 = table(draws, 2, func(d draw) string { return d.last2 })
+	r.Last3 = table(draws, 3, func(d draw) string { return d.last3 })
+	for pos := 0; pos < 6; pos++ {
+		p := pos
+		r.Positions[pos] = table(draws, 1, func(d draw) string {
+			if d.first == "" {
+				return ""
+			}
+			return d.first[p : p+1]
+		}).Hot
+	}
+	return r
+}
+
+// table นับความถี่และจำนวนงวดที่ไม่ออกของทุกค่าที่มี width หลัก
+func table(draws []draw, width int, value func(draw) string) Table {
+	counts := map[string]int{}
+	lastSeen := map[string]int{}
+	for i, d := range draws {
+		v := value(d)
+		if v == "" {
+			continue
+		}
+		counts[v]++
+		lastSeen[v] = i
+	}
+
+	size := 1
+	for i := 0; i < width; i++ {
+		size *= 10
+	}
+	all := make([]Count, 0, size)
+	for n := 0; n < size; n++ {
+		number := fmt.Sprintf("%0*d", width, n)
+		c := Count{Number: number, Count: counts[number]}
+		if i, ok := lastSeen[number]; ok {
+			since := len(draws) - 1 - i
+			c.DrawsSince = &since
+		}
+		all = append(all, c)
+	}
+
+	hot := append([]Count(nil), all...)
+	sort.SliceStable(hot, func(i, j int) bool {
+		if hot[i].Count != hot[j].Count {
+			return hot[i].Count > hot[j].Count
+		}
+		return since(hot[i], len(draws)) < since(hot[j], len(draws))
+	})
+	cold := append([]Count(nil), all...)
+	sort.SliceStable(cold, func(i, j int) bool {
+		si, sj := since(cold[i], len(draws)), since(cold[j], len(draws))
+		if si != sj {
+			return si > sj
+		}
+		return cold[i].Count < cold[j].Count
+	})
+	return Table{Hot: hot, Cold: cold}
+}
+
+// since ค่าสำหรับเรียง: เลขที่ไม่เคยออกถือว่าไม่ออกมาทุกงวด
+func since(c Count, draws int) int {
+	if c.DrawsSince == nil {
+		return draws
+	}
+	return *c.DrawsSince
+}
+
+// Top n อันดับแรก
+func Top(list []Count, n int) []Count {
+	if n < len(list) {
+		return list[:n]
+	}
+	return list
+}
@@ -0,0 +1,193 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"my-go-project/agent"
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/pricing"
+	"my-go-project/purchase"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var patternRe = regexp.MustCompile(`^[0-9x]{6}$`)
+
+var ErrDrawNotOpen = errors.New("draw is not open for sale")
+
+// runMu กันไม่ให้รันซื้ออัตโนมัติซ้อนกัน (เช่น admin เพิ่มสลากหลายชุดติดกัน)
+var runMu sync.Mutex
+
+// NormalizePattern แปลงรูปแบบเลขให้เป็นมาตรฐาน (รับ * หรือ X แทน x ได้)
+// คืน false ถ้าไม่ใช่เลข 6 หลักที่มีแค่ 0-9 กับ x
+func NormalizePattern(p string) (string, bool) {
+	p = strings.ToLower(strings.TrimSpace(p))
+	p = strings.ReplaceAll(p, "*", "x")
+	return p, patternRe.MatchString(p)
+}
+
+// likePattern แปลง x เป็น _ สำหรับ LIKE (ตรงทีละหลัก)
+func likePattern(p string) string {
+	return strings.ReplaceAll(p, "x", "_")
+}
+
+// Outcome ผลของการซื้ออัตโนมัติ 1 รายการ
+type Outcome struct {
+	SubscriptionID uint   `json:"subscription_id"`
+	UserID         uint   `json:"user_id"`
+	Pattern        string `json:"pattern"`
+	Status         string `json:"status"` // success / failed / skipped
+	Bought         int    `json:"bought"`
+	PurchaseID     *uint  `json:"purchase_id,omitempty"`
+	Message        string `json:"message,omitempty"`
+}
+
+// Report สรุปผลการซื้ออัตโนมัติของงวด
+type Report struct {
+	DrawID    uint      `json:"draw_id"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	Outcomes  []Outcome `json:"outcomes"`
+}
+
+// RunForDraw ซื้ออัตโนมัติให้ทุก subscription ที่ยัง active ในงวดนี้
+// ใช้ purchase.Buy เหมือนการซื้อปกติ (ตรวจวงเงิน/ยอดเงินครบ)
+// subscription ที่ซื้อสำเร็จในงวดนี้แล้วจะถูกข้าม จึงเรียกซ้ำได้เมื่อมีสลากเพิ่ม
+func RunForDraw(db *gorm.DB, drawID uint) (*Report, error) {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	var draw models.Draw
+	if err := db.Where("draw_id = ?", drawID).First(&draw).Error; err != nil {
+		return nil, err
+	}
+	if draw.Status != "open" {
+		return nil, ErrDrawNotOpen
+	}
+
+	var subs []models.Subscription
+	if err := db.Where("active = ?", true).Order("created_at ASC, subscription_id ASC").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+
+	var runs []models.SubscriptionRun
+	if err := db.Where("draw_id = ?", drawID).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	prev := make(map[uint]models.SubscriptionRun, len(runs))
+	for _, r := range runs {
+		prev[r.SubscriptionID] = r
+	}
+
+	report := &Report{DrawID: drawID, Outcomes: []Outcome{}}
+	for _, sub := range subs {
+		out := Outcome{SubscriptionID: sub.SubscriptionID, UserID: sub.UserID, Pattern: sub.Pattern}
+		run, ran := prev[sub.SubscriptionID]
+		if ran && run.Status == "success" {
+			out.Status = "skipped"
+			out.Message = "already bought for this draw"
+			report.Skipped++
+			report.Outcomes = append(report.Outcomes, out)
+			continue
+		}
+
+		res, err := runOne(db, sub, drawID)
+		if err != nil {
+			out.Status = "failed"
+			out.Message = err.Error()
+			report.Failed++
+		} else {
+			out.Status = "success"
+			out.Bought = len(res.Items)
+			out.PurchaseID = &res.PurchaseID
+			report.Succeeded++
+		}
+		report.Outcomes = append(report.Outcomes, out)
+
+		if err := saveRun(db, drawID, out); err != nil {
+			return report, err
+		}
+
+		// แจ้งผลเข้ากล่องข้อความ (ถ้าล้มเหลวซ้ำในงวดเดิม ไม่ต้องแจ้งอีก)
+		data := map[string]any{"subscription_id": sub.SubscriptionID, "draw_id": drawID, "purchase_id": out.PurchaseID}
+		if out.Status == "success" {
+			notify.PublishQuiet(db, sub.UserID, notify.TypeSubscription,
+				"ซื้ออัตโนมัติสำเร็จ",
+				fmt.Sprintf("ซื้อเลข %s ให้แล้ว %d ใบ งวดวันที่ %s (ยอด %.2f บาท)", sub.Pattern, out.Bought, draw.DrawDate.Format("2006-01-02"), res.TotalPrice),
+				data)
+		} else if !ran {
+			notify.PublishQuiet(db, sub.UserID, notify.TypeSubscription,
+				"ซื้ออัตโนมัติไม่สำเร็จ",
+				fmt.Sprintf("ซื้อเลข %s งวดวันที่ %s ไม่สำเร็จ: %s", sub.Pattern, draw.DrawDate.Format("2006-01-02"), out.Message),
+				data)
+		}
+	}
+
+	log.Printf("subscription: draw %d done (success %d, failed %d, skipped %d)", drawID, report.Succeeded, report.Failed, report.Skipped)
+	return report, nil
+}
+
+// runOne เลือกสลากที่ตรงรูปแบบและราคาขายจริง (หลังใช้กฎราคา) ไม่เกินที่ตั้งไว้ แล้วซื้อ (สูงสุด Quantity ใบ)
+func runOne(db *gorm.DB, sub models.Subscription, drawID uint) (*purchase.Result, error) {
+	const candidatesPerTicket = 4 // ดึงเผื่อใบที่ราคาขึ้นเกินงบ
+
+	now := time.Now()
+	var candidates []models.Lotto
+	pickSQL := `
+		SELECT * FROM lotto
+		WHERE draw_id = ? AND status = ? AND lotto_number LIKE ? AND ` + purchase.NotReservedSQL + ` AND ` + agent.NotAllocatedSQL + `
+		ORDER BY lotto_number ASC, set_no ASC
+		LIMIT ?`
+	if err := db.Raw(pickSQL, drawID, "sell", likePattern(sub.Pattern), sub.UserID, now, 0, sub.Quantity*candidatesPerTicket).Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+	quotes, err := pricing.QuoteLottos(db, candidates, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, l := range candidates {
+		if len(ids) == sub.Quantity {
+			break
+		}
+		if quotes[l.LottoID].Price <= sub.MaxPrice {
+			ids = append(ids, l.LottoID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("ไม่มีสลากที่ตรงกับเลขที่ติดตามในราคาที่กำหนด")
+	}
+	return purchase.Buy(db, purchase.Request{UserID: sub.UserID, LottoIDs: ids})
+}
+
+func saveRun(db *gorm.DB, drawID uint, out Outcome) error {
+	run := models.SubscriptionRun{
+		SubscriptionID: out.SubscriptionID,
+		DrawID:         drawID,
+		Status:         out.Status,
+		PurchaseID:     out.PurchaseID,
+		Bought:         out.Bought,
+		Message:        truncate(out.Message, 255),
+	}
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"status", "purchase_id", "bought", "message", "updated_at"}),
+	}).Create(&run).Error
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,185 @@
+package syndicate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"my-go-project/models"
+	"my-go-project/wallet"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound         = errors.New("syndicate not found")
+	ErrNotMember        = errors.New("you are not a member of this syndicate")
+	ErrInsufficientPool = errors.New("เงินกองกลางของกลุ่มไม่เพียงพอ")
+	ErrNoContributions  = errors.New("syndicate has no contributions")
+)
+
+// Share ส่วนของสมาชิก 1 คน (ตามยอดเงินสมทบ)
+type Share struct {
+	UserID       uint    `json:"user_id"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Payout เงินรางวัลที่สมาชิก 1 คนได้รับ
+type Payout struct {
+	UserID uint    `json:"user_id"`
+	Amount float64 `json:"amount"`
+}
+
+// Split แบ่งเงินตามสัดส่วนเงินสมทบ คิดเป็นสตางค์
+// แต่ละคนได้ส่วนของตัวเองปัดลงเป็นสตางค์ก่อน แล้วเศษสตางค์ที่เหลือแจกทีละ 1 สตางค์
+// ให้คนที่มีเศษทศนิยมมากที่สุดก่อน (เท่ากันให้คนสมทบมากกว่า แล้วตาม user_id)
+// ผลรวมของทุกคนจึงเท่ากับ amount พอดี
+func Split(amount float64, shares []Share) ([]Payout, error) {
+	total := int64(0)
+	for _, s := range shares {
+		total += toSatang(s.Contribution)
+	}
+	if total <= 0 {
+		return nil, ErrNoContributions
+	}
+
+	pot := toSatang(amount)
+	type part struct {
+		Share
+		satang int64
+		rem    int64 // เศษจากการปัดลง (เทียบกันได้เพราะตัวหารเท่ากัน)
+	}
+	parts := make([]part, 0, len(shares))
+	given := int64(0)
+	for _, s := range shares {
+		num := pot * toSatang(s.Contribution)
+		p := part{Share: s, satang: num / total, rem: num % total}
+		given += p.satang
+		parts = append(parts, p)
+	}
+
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := parts[order[a]], parts[order[b]]
+		if pa.rem != pb.rem {
+			return pa.rem > pb.rem
+		}
+		if pa.Contribution != pb.Contribution {
+			return pa.Contribution > pb.Contribution
+		}
+		return pa.UserID < pb.UserID
+	})
+	for i := 0; given < pot; i++ {
+		parts[order[i%len(order)]].satang++
+		given++
+	}
+
+	out := make([]Payout, 0, len(parts))
+	for _, p := range parts {
+		out = append(out, Payout{UserID: p.UserID, Amount: float64(p.satang) / 100})
+	}
+	return out, nil
+}
+
+func toSatang(v float64) int64 { return int64(math.Round(v * 100)) }
+
+// Shares สมาชิกที่เข้าร่วมแล้วและยอดเงินสมทบ
+func Shares(db *gorm.DB, syndicateID uint) ([]Share, error) {
+	var shares []Share
+	err := db.Raw(`
+		SELECT user_id, contribution
+		FROM syndicate_members
+		WHERE syndicate_id = ? AND status = 'joined' AND contribution > 0
+		ORDER BY user_id ASC`, syndicateID).Scan(&shares).Error
+	return shares, err
+}
+
+// PurchaseShares สัดส่วนของสมาชิกที่บันทึกไว้ตอนกลุ่มซื้อบิลนี้
+func PurchaseShares(db *gorm.DB, purchaseID uint) ([]Share, error) {
+	var shares []Share
+	err := db.Raw(`
+		SELECT user_id, contribution
+		FROM syndicate_shares
+		WHERE purchase_id = ?
+		ORDER BY user_id ASC`, purchaseID).Scan(&shares).Error
+	return shares, err
+}
+
+// IsMember ผู้ใช้เป็นสมาชิกที่เข้าร่วมแล้วหรือไม่
+func IsMember(db *gorm.DB, syndicateID, userID uint) (bool, error) {
+	var count int64
+	err := db.Raw("SELECT COUNT(*) FROM syndicate_members WHERE syndicate_id = ? AND user_id = ? AND status = 'joined'", syndicateID, userID).Scan(&count).Error
+	return count > 0, err
+}
+
+// SpendPool ใช้เงินกองกลางซื้อสลากบิล purchaseID (ต้องเรียกภายใน transaction)
+// และบันทึกสัดส่วนเงินสมทบของสมาชิก ณ ตอนนี้ไว้แบ่งรางวัลของบิลนี้
+func SpendPool(tx *gorm.DB, syndicateID, userID, purchaseID uint, amount float64) error {
+	var s models.Syndicate
+	result := tx.Raw("SELECT * FROM syndicates WHERE syndicate_id = ? FOR UPDATE", syndicateID).Scan(&s)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	ok, err := IsMember(tx, syndicateID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotMember
+	}
+	if s.Pool < amount {
+		return ErrInsufficientPool
+	}
+	if err := tx.Exec(`
+		INSERT INTO syndicate_shares (purchase_id, user_id, contribution)
+		SELECT ?, user_id, contribution
+		FROM syndicate_members
+		WHERE syndicate_id = ? AND status = 'joined' AND contribution > 0`, purchaseID, syndicateID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE syndicates SET pool = pool - ? WHERE syndicate_id = ?", amount, syndicateID).Error
+}
+
+// Contribute โอนเงินจาก wallet ของสมาชิกเข้ากองกลาง (ต้องเรียกภายใน transaction)
+func Contribute(tx *gorm.DB, syndicateID, userID uint, amount float64) error {
+	ok, err := IsMember(tx, syndicateID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotMember
+	}
+	if err := wallet.Debit(tx, userID, amount, wallet.TypeSyndicateContribution, &syndicateID, ""); err != nil {
+		return err
+	}
+	if err := tx.Exec("UPDATE syndicate_members SET contribution = contribution + ? WHERE syndicate_id = ? AND user_id = ?", amount, syndicateID, userID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE syndicates SET pool = pool + ? WHERE syndicate_id = ?", amount, syndicateID).Error
+}
+
+// PayPrize แบ่งเงินรางวัลของสลากกลุ่มบิล purchaseID เข้า wallet ของสมาชิก
+// ตามสัดส่วนที่บันทึกไว้ตอนซื้อ (ต้องเรียกภายใน transaction)
+func PayPrize(tx *gorm.DB, syndicateID, purchaseID uint, amount float64, note string) ([]Payout, error) {
+	shares, err := PurchaseShares(tx, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := Split(amount, shares)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payouts {
+		if err := wallet.Credit(tx, p.UserID, p.Amount, wallet.TypeSyndicatePayout, &syndicateID, note); err != nil {
+			return nil, fmt.Errorf("pay user %d: %w", p.UserID, err)
+		}
+	}
+	return payouts, nil
+}
@@ -0,0 +1,92 @@
+package syndicate
+
+import (
+	"errors"
+	"testing"
+)
+
+func amounts(payouts []Payout) map[uint]float64 {
+	m := make(map[uint]float64, len(payouts))
+	for _, p := range payouts {
+		m[p.UserID] = p.Amount
+	}
+	return m
+}
+
+func TestSplitProportional(t *testing.T) {
+	got, err := Split(6000, []Share{{UserID: 1, Contribution: 300}, {UserID: 2, Contribution: 200}, {UserID: 3, Contribution: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]float64{1: 3000, 2: 2000, 3: 1000}
+	for id, amount := range amounts(got) {
+		if amount != want[id] {
+			t.Errorf("user %d got %v, want %v", id, amount, want[id])
+		}
+	}
+}
+
+// เศษสตางค์ไปที่คนที่มีเศษมากที่สุดก่อน
+func TestSplitRemainderToLargestFraction(t *testing.T) {
+	got, err := Split(10, []Share{{UserID: 1, Contribution: 200}, {UserID: 2, Contribution: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := amounts(got); m[1] != 6.67 || m[2] != 3.33 {
+		t.Errorf("Split = %v, want user 1: 6.67, user 2: 3.33", m)
+	}
+}
+
+// เศษเท่ากัน สมทบเท่ากัน → ให้ user_id น้อยก่อน
+func TestSplitTieBreakByUserID(t *testing.T) {
+	got, err := Split(100, []Share{{UserID: 9, Contribution: 50}, {UserID: 4, Contribution: 50}, {UserID: 7, Contribution: 50}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := amounts(got); m[4] != 33.34 || m[7] != 33.33 || m[9] != 33.33 {
+		t.Errorf("Split = %v, want user 4: 33.34, others 33.33", m)
+	}
+	// ลำดับผลลัพธ์ตามลำดับ shares ที่ส่งเข้ามา
+	if got[0].UserID != 9 || got[1].UserID != 4 || got[2].UserID != 7 {
+		t.Errorf("payout order = %v", got)
+	}
+}
+
+// ผลรวมต้องเท่ากับเงินรางวัลพอดีทุกกรณี
+func TestSplitSumsToAmount(t *testing.T) {
+	shares := []Share{{UserID: 1, Contribution: 33.33}, {UserID: 2, Contribution: 66.67}, {UserID: 3, Contribution: 0.01}, {UserID: 4, Contribution: 17}}
+	for _, amount := range []float64{0.01, 0.05, 1, 99.99, 2000, 6000000, 123456.78} {
+		got, err := Split(amount, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sum int64
+		for _, p := range got {
+			if p.Amount < 0 {
+				t.Errorf("Split(%v): negative payout %v", amount, p)
+			}
+			sum += toSatang(p.Amount)
+		}
+		if sum != toSatang(amount) {
+			t.Errorf("Split(%v) pays %d satang in total", amount, sum)
+		}
+	}
+}
+
+func TestSplitMemberWithoutContributionGetsNothing(t *testing.T) {
+	got, err := Split(500, []Share{{UserID: 1, Contribution: 100}, {UserID: 2, Contribution: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := amounts(got); m[1] != 500 || m[2] != 0 {
+		t.Errorf("Split = %v, want user 1: 500, user 2: 0", m)
+	}
+}
+
+func TestSplitNoContributions(t *testing.T) {
+	for _, shares := range [][]Share{nil, {{UserID: 1}}} {
+		if _, err := Split(100, shares); !errors.Is(err, ErrNoContributions) {
+			t.Errorf("Split(%v) err = %v, want ErrNoContributions", shares, err)
+		}
+	}
+}
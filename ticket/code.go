@@ -0,0 +1,101 @@
+package ticket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// รูปแบบรหัสยืนยันสลาก:
+//
+//	v1.<pd_id>.<lotto_number>.<draw_id>.<signature>
+//
+// signature = HMAC-SHA256 ของส่วนหน้า (ตัดเหลือ 16 byte, base64url)
+// ผู้ที่ถือรหัสจึงอ่านเลข/งวดได้ทันที แต่แก้ไขไม่ได้ถ้าไม่รู้ TICKET_SECRET
+const codeVersion = "v1"
+
+var (
+	ErrInvalidCode = errors.New("invalid ticket code")
+	ErrNoSecret    = errors.New("TICKET_SECRET is not set")
+)
+
+// Claims ข้อมูลที่อ่านได้จากรหัสยืนยันสลาก
+type Claims struct {
+	PDID        uint
+	LottoNumber string
+	DrawID      uint // 0 = ไม่ระบุงวด
+}
+
+// secret คีย์สำหรับเซ็นรหัส อ่านจาก TICKET_SECRET (ไม่มีค่าเริ่มต้น ใครก็ปลอมรหัสได้ถ้าคีย์เป็นที่รู้กัน)
+func secret() ([]byte, error) {
+	s := os.Getenv("TICKET_SECRET")
+	if s == "" {
+		return nil, ErrNoSecret
+	}
+	return []byte(s), nil
+}
+
+// CheckSecret ตรวจว่าตั้ง TICKET_SECRET แล้ว (เรียกตอนเริ่ม server)
+func CheckSecret() error {
+	_, err := secret()
+	return err
+}
+
+func sign(payload string) (string, error) {
+	key, err := secret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// Code สร้างรหัสยืนยันของสลาก 1 ใบ (purchases_detail 1 แถว)
+func Code(pdID uint, lottoNumber string, drawID *uint) (string, error) {
+	var d uint
+	if drawID != nil {
+		d = *drawID
+	}
+	payload := fmt.Sprintf("%s.%d.%s.%d", codeVersion, pdID, lottoNumber, d)
+	sig, err := sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + sig, nil
+}
+
+// Verify ตรวจลายเซ็นของรหัส แล้วคืนข้อมูลที่อยู่ในรหัส
+func Verify(code string) (Claims, error) {
+	i := strings.LastIndexByte(code, '.')
+	if i < 0 {
+		return Claims{}, ErrInvalidCode
+	}
+	payload, sig := code[:i], code[i+1:]
+	want, err := sign(payload)
+	if err != nil {
+		return Claims{}, err
+	}
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return Claims{}, ErrInvalidCode
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 || parts[0] != codeVersion {
+		return Claims{}, ErrInvalidCode
+	}
+	pdID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || pdID == 0 {
+		return Claims{}, ErrInvalidCode
+	}
+	drawID, err := strconv.ParseUint(parts[3], 10, 64)
+	if err != nil {
+		return Claims{}, ErrInvalidCode
+	}
+	return Claims{PDID: uint(pdID), LottoNumber: parts[2], DrawID: uint(drawID)}, nil
+}
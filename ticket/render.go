@@ -0,0 +1,201 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	qrcode "github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Info ข้อมูลของสลาก 1 ใบ สำหรับพิมพ์ลงรูป/ใบเสร็จ
+type Info struct {
+	PDID        uint
+	LottoNumber string
+	DrawDate    *time.Time
+	Price       float64
+	Code        string // รหัสยืนยันที่ใส่ใน QR
+}
+
+// Receipt ข้อมูลหัวบิล + รายการสลาก สำหรับสร้าง PDF
+type Receipt struct {
+	PurchaseID  uint
+	Username    string
+	Email       string
+	CreatedAt   time.Time
+	TotalPrice  float64 // ยอดที่จ่ายจริง
+	Discount    float64 // ส่วนลดจากโปรโมชัน (0 = ไม่มี)
+	Promotion   string  // ชื่อโปร/โค้ดที่ใช้
+	PointsUsed  int     // แต้มสะสมที่ใช้จ่าย
+	PointsValue float64 // มูลค่าแต้มที่ใช้ (บาท)
+	Items       []Info
+}
+
+func drawDateText(d *time.Time) string {
+	if d == nil {
+		return "-"
+	}
+	return d.Format("2006-01-02")
+}
+
+// ---------- PNG ----------
+
+const (
+	ticketWidth  = 640
+	ticketHeight = 280
+	qrSize       = 240
+)
+
+// drawText เขียนข้อความด้วยฟอนต์ basicfont แล้วขยาย scale เท่า (nearest neighbor)
+func drawText(dst draw.Image, x, y, scale int, s string, col color.Color) {
+	face := basicfont.Face7x13
+	w := font.MeasureString(face, s).Ceil()
+	h := face.Height
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	d := &font.Drawer{
+		Dst:  src,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	d.DrawString(s)
+	r := image.Rect(x, y, x+w*scale, y+h*scale)
+	draw.NearestNeighbor.Scale(dst, r, src, src.Bounds(), draw.Over, nil)
+}
+
+// RenderPNG สร้างรูปสลาก 1 ใบ (เลข, งวด, ราคา และ QR ที่มีรหัสยืนยัน)
+func RenderPNG(t Info) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, ticketWidth, ticketHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// กรอบ
+	border := color.RGBA{0xB7, 0x1C, 0x1C, 0xFF}
+	for x := 0; x < ticketWidth; x++ {
+		for i := 0; i < 4; i++ {
+			img.Set(x, i, border)
+			img.Set(x, ticketHeight-1-i, border)
+		}
+	}
+	for y := 0; y < ticketHeight; y++ {
+		for i := 0; i < 4; i++ {
+			img.Set(i, y, border)
+			img.Set(ticketWidth-1-i, y, border)
+		}
+	}
+
+	drawText(img, 24, 20, 2, "ORACEL999 LOTTERY", border)
+	drawText(img, 24, 64, 6, t.LottoNumber, color.Black)
+	drawText(img, 24, 160, 2, "Draw:  "+drawDateText(t.DrawDate), color.Black)
+	drawText(img, 24, 196, 2, fmt.Sprintf("Price: %.2f THB", t.Price), color.Black)
+	drawText(img, 24, 238, 1, fmt.Sprintf("Ticket #%d", t.PDID), color.Gray{0x66})
+
+	qr, err := qrcode.New(t.Code, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	qrImg := qr.Image(qrSize)
+	at := image.Pt(ticketWidth-qrSize-20, (ticketHeight-qrSize)/2)
+	draw.Draw(img, qrImg.Bounds().Add(at), qrImg, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ---------- PDF ----------
+
+// RenderReceiptPDF สร้างใบเสร็จการซื้อ 1 บิล พร้อม QR ของสลากแต่ละใบ
+func RenderReceiptPDF(r Receipt) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Receipt #%d", r.PurchaseID), false)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "ORACEL999 LOTTERY - RECEIPT", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Receipt no.: %d", r.PurchaseID), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Date: "+r.CreatedAt.Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Customer: %s <%s>", r.Username, r.Email), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// หัวตาราง
+	const rowH = 24.0
+	cols := []struct {
+		title string
+		w     float64
+	}{{"#", 10}, {"Number", 45}, {"Draw", 40}, {"Price (THB)", 35}, {"Verify", 50}}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFillColor(240, 240, 240)
+	for _, col := range cols {
+		pdf.CellFormat(col.w, 8, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 12)
+	_, pageH := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for i, it := range r.Items {
+		// ขึ้นหน้าใหม่เองก่อน เพื่อให้ตำแหน่ง QR ตรงกับแถว
+		if pdf.GetY()+rowH > pageH-bottom {
+			pdf.AddPage()
+		}
+
+		qr, err := qrcode.Encode(it.Code, qrcode.Medium, 256)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("qr-%d", it.PDID)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+
+		x, y := pdf.GetXY()
+		pdf.CellFormat(cols[0].w, rowH, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(cols[1].w, rowH, it.LottoNumber, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(cols[2].w, rowH, drawDateText(it.DrawDate), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(cols[3].w, rowH, fmt.Sprintf("%.2f", it.Price), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[4].w, rowH, "", "1", 0, "C", false, 0, "")
+		qrX := x + cols[0].w + cols[1].w + cols[2].w + cols[3].w + (cols[4].w-rowH+2)/2
+		pdf.ImageOptions(name, qrX, y+1, rowH-2, rowH-2, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.Ln(-1)
+	}
+
+	labelW := cols[0].w + cols[1].w + cols[2].w
+	if r.Discount > 0 || r.PointsValue > 0 {
+		pdf.SetFont("Helvetica", "", 12)
+		pdf.CellFormat(labelW, 8, "Subtotal", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[3].w, 8, fmt.Sprintf("%.2f", r.TotalPrice+r.Discount+r.PointsValue), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[4].w, 8, "", "1", 1, "C", false, 0, "")
+	}
+	if r.Discount > 0 {
+		pdf.CellFormat(labelW, 8, "Discount ("+r.Promotion+")", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[3].w, 8, fmt.Sprintf("-%.2f", r.Discount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[4].w, 8, "", "1", 1, "C", false, 0, "")
+	}
+	if r.PointsValue > 0 {
+		pdf.CellFormat(labelW, 8, fmt.Sprintf("Paid with points (%d pts)", r.PointsUsed), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[3].w, 8, fmt.Sprintf("-%.2f", r.PointsValue), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(cols[4].w, 8, "", "1", 1, "C", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(labelW, 8, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(cols[3].w, 8, fmt.Sprintf("%.2f", r.TotalPrice), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(cols[4].w, 8, "", "1", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package wallet
+
+import (
+	"errors"
+
+	"my-go-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ประเภทรายการใน wallet_transactions
+const (
+	TypePurchase              = "purchase"
+	TypePrize                 = "prize"
+	TypeSyndicateContribution = "syndicate_contribution"
+	TypeSyndicatePayout       = "syndicate_payout"
+	TypeMarketBuy             = "market_buy"
+	TypeMarketSale            = "market_sale"
+	TypeReferralBonus         = "referral_bonus"
+	TypeAgentCommission       = "agent_commission"
+)
+
+var ErrInsufficientFunds = errors.New("ยอดเงินในกระเป๋าไม่เพียงพอ")
+
+// Credit เพิ่มเงินเข้า wallet และบันทึกรายการ (ต้องเรียกภายใน transaction)
+func Credit(tx *gorm.DB, userID uint, amount float64, typ string, refID *uint, note string) error {
+	if amount == 0 {
+		return nil
+	}
+	if err := tx.Exec("UPDATE users SET wallet = wallet + ? WHERE user_id = ?", amount, userID).Error; err != nil {
+		return err
+	}
+	return record(tx, userID, amount, typ, refID, note)
+}
+
+// Debit หักเงินจาก wallet และบันทึกรายการ คืน ErrInsufficientFunds ถ้าเงินไม่พอ
+func Debit(tx *gorm.DB, userID uint, amount float64, typ string, refID *uint, note string) error {
+	if amount == 0 {
+		return nil
+	}
+	result := tx.Exec("UPDATE users SET wallet = wallet - ? WHERE user_id = ? AND wallet >= ?", amount, userID, amount)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientFunds
+	}
+	return record(tx, userID, -amount, typ, refID, note)
+}
+
+func record(tx *gorm.DB, userID uint, amount float64, typ string, refID *uint, note string) error {
+	var balance float64
+	if err := tx.Raw("SELECT wallet FROM users WHERE user_id = ?", userID).Scan(&balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.WalletTransaction{
+		UserID:       userID,
+		Amount:       amount,
+		BalanceAfter: balance,
+		Type:         typ,
+		RefID:        refID,
+		Note:         note,
+	}).Error
+}
@@ -0,0 +1,343 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"my-go-project/models"
+	"my-go-project/notify"
+	"my-go-project/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// Match ตรวจว่าเลขสลากตรงกับรูปแบบ (x = หลักใดก็ได้)
+func Match(pattern, number string) bool {
+	if len(pattern) != len(number) {
+		return false
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != 'x' && pattern[i] != number[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyBatch จำนวน lotto_id ต่อการค้น 1 ครั้งใน Notify
+const notifyBatch = 1000
+
+// maxListedNumbers จำนวนเลขที่แสดงในข้อความแจ้งเตือน ที่เหลือสรุปเป็น "และอีก N เลข"
+const maxListedNumbers = 10
+
+type available struct {
+	LottoID     uint
+	LottoNumber string
+	Price       float64
+	DrawID      *uint
+}
+
+// Notify แจ้งผู้ที่ติดตามเลขว่ามีสลากว่างจาก lottoIDs (เช่น admin เพิ่มสลาก หรือมีการยกเลิกการจอง)
+// ถ้าผู้ติดตามเปิด AutoReserve จะจองให้ 1 ใบตามเวลาที่ตั้งใน settings (ใครติดตามก่อนได้ก่อน)
+// except = ผู้ใช้ที่ไม่ต้องแจ้ง (เช่น คนที่เพิ่งปล่อยการจองใบนั้น) ส่ง 0 ถ้าไม่มี
+func Notify(db *gorm.DB, lottoIDs []uint, except uint) error {
+	if len(lottoIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+
+	// เฉพาะใบที่ยังขายได้ ไม่มีใครจองอยู่ และงวดยังเปิดขาย
+	// ค้นทีละ notifyBatch ใบ กันคำสั่ง IN ยาวเกินเมื่อสร้างสลากทีละมาก ๆ
+	var pool []available
+	const poolSQL = `
+		SELECT l.lotto_id, l.lotto_number, l.price, l.draw_id
+		FROM lotto AS l
+		LEFT JOIN draws AS d ON d.draw_id = l.draw_id
+		WHERE l.lotto_id IN (?) AND l.status = 'sell'
+		  AND (l.draw_id IS NULL OR d.status = 'open')
+		  AND NOT EXISTS (SELECT 1 FROM lotto_reservations AS r WHERE r.lotto_id = l.lotto_id AND r.expires_at > ?)
+		  AND NOT EXISTS (SELECT 1 FROM agent_allocations AS a WHERE a.lotto_id = l.lotto_id)
+		ORDER BY l.lotto_number ASC, l.set_no ASC, l.lotto_id ASC`
+	for start := 0; start < len(lottoIDs); start += notifyBatch {
+		end := start + notifyBatch
+		if end > len(lottoIDs) {
+			end = len(lottoIDs)
+		}
+		var batch []available
+		if err := db.Raw(poolSQL, lottoIDs[start:end], now).Scan(&batch).Error; err != nil {
+			return err
+		}
+		pool = append(pool, batch...)
+	}
+	sort.SliceStable(pool, func(i, j int) bool { return pool[i].LottoNumber < pool[j].LottoNumber })
+	if len(pool) == 0 {
+		return nil
+	}
+
+	// ใบว่างแยกตามเลข และหลักที่พบในแต่ละตำแหน่ง (ใช้กรองรายการติดตามที่ไม่มีทางตรง)
+	byNumber := map[string][]available{}
+	var numberList []string
+	digits := make([]map[byte]bool, 6)
+	for i := range digits {
+		digits[i] = map[byte]bool{}
+	}
+	for _, a := range pool {
+		if _, ok := byNumber[a.LottoNumber]; !ok {
+			numberList = append(numberList, a.LottoNumber)
+		}
+		byNumber[a.LottoNumber] = append(byNumber[a.LottoNumber], a)
+		for i := 0; i < len(a.LottoNumber) && i < len(digits); i++ {
+			digits[i][a.LottoNumber[i]] = true
+		}
+	}
+
+	q := db.Where("active = ?", true)
+	for i, set := range digits {
+		allowed := []string{"x"}
+		for d := range set {
+			allowed = append(allowed, string(d))
+		}
+		q = q.Where(fmt.Sprintf("SUBSTRING(pattern, %d, 1) IN ?", i+1), allowed)
+	}
+	var watches []models.Watch
+	if err := q.Order("created_at ASC, watch_id ASC").Find(&watches).Error; err != nil {
+		return err
+	}
+
+	minutes := settings.Float(db, settings.WatchReserveMinutes)
+	taken := map[uint]bool{} // ใบที่จองให้ผู้ติดตามคนก่อนไปแล้วในรอบนี้
+
+	for _, w := range watches {
+		if w.UserID == except {
+			continue
+		}
+		var matched []available
+		for _, n := range matchingNumbers(w.Pattern, numberList, byNumber) {
+			for _, a := range byNumber[n] {
+				if !taken[a.LottoID] {
+					matched = append(matched, a)
+				}
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		var reserved *models.Reservation
+		if w.AutoReserve && minutes > 0 {
+			r, err := reserveFirst(db, w, matched, now.Add(time.Duration(minutes*float64(time.Minute))))
+			if err != nil {
+				log.Printf("watch: reserve for watch %d failed: %v", w.WatchID, err)
+			} else if r != nil {
+				reserved = r
+				taken[r.LottoID] = true
+			}
+		}
+
+		numbers := distinctNumbers(matched)
+		shown := numbers
+		if len(shown) > maxListedNumbers {
+			shown = shown[:maxListedNumbers]
+		}
+		data := map[string]any{
+			"watch_id":      w.WatchID,
+			"pattern":       w.Pattern,
+			"numbers":       shown,
+			"total_numbers": len(numbers),
+		}
+		body := fmt.Sprintf("เลข %s ที่คุณติดตามมีสลากว่าง %d ใบ: %s", w.Pattern, len(matched), strings.Join(shown, ", "))
+		if more := len(numbers) - len(shown); more > 0 {
+			body += fmt.Sprintf(" และอีก %d เลข", more)
+		}
+		if reserved != nil {
+			data["reservation_id"] = reserved.ReservationID
+			data["lotto_id"] = reserved.LottoID
+			data["expires_at"] = reserved.ExpiresAt
+			body += fmt.Sprintf(" (จองให้ 1 ใบถึง %s)", reserved.ExpiresAt.Format("15:04"))
+		}
+		notify.PublishQuiet(db, w.UserID, notify.TypeWatch, "มีสลากเลขที่คุณติดตาม", body, data)
+	}
+	return nil
+}
+
+// reserveFirst จองใบแรกใน matched ที่ยังว่างอยู่ให้ผู้ติดตาม
+// ผู้ใช้มีการจองที่ยังไม่หมดเวลาจากรายการติดตามนี้อยู่แล้ว → ไม่จองเพิ่ม
+func reserveFirst(db *gorm.DB, w models.Watch, matched []available, expiresAt time.Time) (*models.Reservation, error) {
+	var reserved *models.Reservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var holding int64
+		if err := tx.Raw("SELECT COUNT(*) FROM lotto_reservations WHERE watch_id = ? AND expires_at > ?", w.WatchID, now).Scan(&holding).Error; err != nil {
+			return err
+		}
+		if holding > 0 {
+			return nil
+		}
+
+		for _, a := range matched {
+			var sell int64
+			if err := tx.Raw("SELECT COUNT(*) FROM lotto WHERE lotto_id = ? AND status = 'sell' FOR UPDATE", a.LottoID).Scan(&sell).Error; err != nil {
+				return err
+			}
+			if sell == 0 {
+				continue
+			}
+			// การจองเก่าที่หมดเวลาแล้วยังค้างในตาราง (lotto_id เป็น unique)
+			if err := tx.Exec("DELETE FROM lotto_reservations WHERE lotto_id = ? AND expires_at <= ?", a.LottoID, now).Error; err != nil {
+				return err
+			}
+			watchID := w.WatchID
+			r := models.Reservation{LottoID: a.LottoID, UserID: w.UserID, WatchID: &watchID, ExpiresAt: expiresAt}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&r)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				reserved = &r
+				return nil
+			}
+		}
+		return nil
+	})
+	return reserved, err
+}
+
+// matchingNumbers เลขใน numbers ที่ตรงกับ pattern (เรียงตาม numbers)
+// ถ้ารูปแบบมี x น้อย ไล่เฉพาะเลขที่เป็นไปได้ในแผนที่แทนการไล่ทุกเลข
+func matchingNumbers(pattern string, numbers []string, byNumber map[string][]available) []string {
+	combos := 1
+	for i := 0; i < len(pattern) && combos <= len(numbers); i++ {
+		if pattern[i] == 'x' {
+			combos *= 10
+		}
+	}
+	var out []string
+	if combos > len(numbers) {
+		for _, n := range numbers {
+			if Match(pattern, n) {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+
+	buf := []byte(pattern)
+	var expand func(i int)
+	expand = func(i int) {
+		if i == len(buf) {
+			if _, ok := byNumber[string(buf)]; ok {
+				out = append(out, string(buf))
+			}
+			return
+		}
+		if pattern[i] != 'x' {
+			expand(i + 1)
+			return
+		}
+		for d := byte('0'); d <= '9'; d++ {
+			buf[i] = d
+			expand(i + 1)
+		}
+		buf[i] = 'x'
+	}
+	expand(0)
+	return out
+}
+
+func distinctNumbers(items []available) []string {
+	seen := map[string]bool{}
+	var numbers []string
+	for _, a := range items {
+		if !seen[a.LottoNumber] {
+			seen[a.LottoNumber] = true
+			numbers = append(numbers, a.LottoNumber)
+		}
+	}
+	return numbers
+}
+
+// Cancel ผู้ใช้ยกเลิกการจองเอง แล้วแจ้งผู้ติดตามคนอื่นว่าใบนั้นว่างแล้ว
+func Cancel(db *gorm.DB, userID, reservationID uint) error {
+	var r models.Reservation
+	result := db.Where("reservation_id = ? AND user_id = ?", reservationID, userID).Limit(1).Find(&r)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotFound
+	}
+	if err := db.Delete(&models.Reservation{}, r.ReservationID).Error; err != nil {
+		return err
+	}
+	if r.ExpiresAt.After(time.Now()) {
+		return Notify(db, []uint{r.LottoID}, userID)
+	}
+	return nil
+}
+
+// ReleaseExpired ลบการจองที่หมดเวลา แล้วแจ้งผู้ติดตามคนอื่นว่าใบนั้นว่างแล้ว
+func ReleaseExpired(db *gorm.DB, now time.Time) (int, error) {
+	var expired []models.Reservation
+	if err := db.Where("expires_at <= ?", now).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, 0, len(expired))
+	byUser := map[uint][]uint{} // user_id -> lotto_ids ที่ปล่อย
+	for _, r := range expired {
+		ids = append(ids, r.ReservationID)
+		byUser[r.UserID] = append(byUser[r.UserID], r.LottoID)
+	}
+	if err := db.Exec("DELETE FROM lotto_reservations WHERE reservation_id IN (?)", ids).Error; err != nil {
+		return 0, err
+	}
+	for userID, lottoIDs := range byUser {
+		if err := Notify(db, lottoIDs, userID); err != nil {
+			log.Printf("watch: notify after expiry failed: %v", err)
+		}
+	}
+	return len(expired), nil
+}
+
+// StartSweeper ปล่อยการจองที่หมดเวลาเป็นระยะ (ทำเบื้องหลังตลอดอายุ server)
+func StartSweeper(db *gorm.DB, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := ReleaseExpired(db, time.Now()); err != nil {
+				log.Printf("watch: release expired reservations failed: %v", err)
+			}
+		}
+	}()
+}
+
+// Remove เลิกติดตามเลข การจองที่ได้จากรายการนี้จะถูกปล่อยให้ผู้ติดตามคนอื่น
+func Remove(db *gorm.DB, userID, watchID uint) error {
+	result := db.Exec("UPDATE watchlist SET active = ? WHERE watch_id = ? AND user_id = ? AND active = ?", false, watchID, userID, true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var lottoIDs []uint
+	if err := db.Raw("SELECT lotto_id FROM lotto_reservations WHERE watch_id = ? AND expires_at > ?", watchID, time.Now()).Scan(&lottoIDs).Error; err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM lotto_reservations WHERE watch_id = ?", watchID).Error; err != nil {
+		return err
+	}
+	return Notify(db, lottoIDs, userID)
+}